@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
 	"github.com/spf13/cobra"
@@ -18,6 +22,11 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "number of entries to show (0 for all)")
+	switchCmd.Flags().BoolVar(&switchForce, "force", false, "replace a .minecraft symlink that does not point to an instance")
+	configCmd.Flags().BoolVar(&configMove, "move", false, "with instances-path: move all instances to the new path")
 }
 
 var createCmd = &cobra.Command{
@@ -27,79 +36,170 @@ var createCmd = &cobra.Command{
 This will copy your current .minecraft directory structure to create a new instance.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
+		manager := newManager()
+
+		if dryRun {
+			plan, err := manager.PlanCreate(args[0])
+			printPlan("creating instance", plan, err)
+			return
 		}
 
+		// Ctrl+C cancels the copy and removes the partial instance
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
 		instanceName := args[0]
-		if err := manager.CreateInstance(instanceName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating instance: %v\n", err)
-			os.Exit(1)
+		progress, finish := progressPrinter("Copying")
+		err := manager.CreateInstanceContext(ctx, instanceName, progress)
+		finish()
+		if err != nil {
+			if ctx.Err() != nil {
+				exitWithError(codeCancelled, "creating instance", err)
+			}
+			exitWithError(codeOperationFailed, "creating instance", err)
 		}
 
-		fmt.Printf("Created instance: %s\n", instanceName)
-		fmt.Printf("Add mods to: %s/%s/%s/mods/\n", manager.AppDir, "instances", instanceName)
+		printResult("create", instanceName,
+			fmt.Sprintf("Created instance: %s", instanceName),
+			fmt.Sprintf("Add mods to: %s/", filepath.Join(manager.InstancePath(instanceName), "mods")))
 	},
 }
 
+var switchForce bool
+
 var switchCmd = &cobra.Command{
-	Use:   "switch <instance-name>",
+	Use:   "switch <instance-name|->",
 	Short: "Switch to a Minecraft instance",
 	Long: `Switch to the specified Minecraft instance.
-This will backup your current .minecraft directory and create a symlink to the instance.`,
+This will backup your current .minecraft directory and create a symlink to the instance.
+
+Use "switch -" to go back to the previously active instance.
+
+If .minecraft is a symlink to something other than an instance, switching is
+refused unless --force is given. Only the link is replaced, never its target.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
-		}
+		manager := newManager()
 
 		instanceName := args[0]
-		if err := manager.SwitchInstance(instanceName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error switching instance: %v\n", err)
-			os.Exit(1)
+		if dryRun {
+			if instanceName == "-" {
+				prev, err := manager.PreviousInstance()
+				if err != nil {
+					exitWithError(codeOperationFailed, "switching instance", err)
+				}
+				if prev == instance.DefaultInstanceName {
+					plan, err := manager.PlanRestore()
+					printPlan("restoring default", plan, err)
+					return
+				}
+				instanceName = prev
+			}
+			plan, err := manager.PlanSwitch(instanceName, switchForce)
+			printPlan("switching instance", plan, err)
+			return
 		}
 
-		fmt.Printf("Switched to instance: %s\n", instanceName)
-		fmt.Println("Launch Minecraft normally - it will use this instance")
+		if instanceName == "-" {
+			prev, err := manager.SwitchToPrevious()
+			if err != nil {
+				exitWithError(codeOperationFailed, "switching instance", err)
+			}
+			if prev == instance.DefaultInstanceName {
+				printResult("restore", "", "Restored default .minecraft directory")
+				return
+			}
+			instanceName = prev
+		} else {
+			switchFn := manager.SwitchInstance
+			if switchForce {
+				switchFn = manager.ForceSwitchInstance
+			}
+			if err := switchFn(instanceName); err != nil {
+				exitWithError(codeOperationFailed, "switching instance", err)
+			}
+		}
+
+		printResult("switch", instanceName,
+			fmt.Sprintf("Switched to instance: %s", instanceName),
+			"Launch Minecraft normally - it will use this instance")
 	},
 }
 
+// listOutput is the stable schema of `list`.
+type listOutput struct {
+	Instances   []instanceOutput     `json:"instances" yaml:"instances"`
+	Active      string               `json:"active" yaml:"active"`
+	ActiveState instance.ActiveState `json:"active_state" yaml:"active_state"`
+}
+
+type instanceOutput struct {
+	Name    string `json:"name" yaml:"name"`
+	Path    string `json:"path" yaml:"path"`
+	Mods    int    `json:"mods" yaml:"mods"`
+	Configs int    `json:"configs" yaml:"configs"`
+	Saves   int    `json:"saves" yaml:"saves"`
+	// ResourcePacks and ShaderPacks count the entries of those folders
+	ResourcePacks int  `json:"resource_packs" yaml:"resource_packs"`
+	ShaderPacks   int  `json:"shader_packs" yaml:"shader_packs"`
+	Active        bool `json:"active" yaml:"active"`
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all Minecraft instances",
 	Long:  `List all available Minecraft instances with their mod counts and status.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
-		}
+		manager := newManager()
 
 		instances, err := manager.ListInstances()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error listing instances: %v\n", err)
-			os.Exit(1)
+			exitWithError(codeOperationFailed, "listing instances", err)
 		}
 
-		fmt.Println("Available instances:")
-		if len(instances) == 0 {
-			fmt.Println("  No instances found")
-		} else {
-			for _, inst := range instances {
-				status := "Inactive"
-				if inst.IsActive {
-					status = "ACTIVE"
+		active := manager.ActiveState()
+		out := listOutput{
+			Instances:   make([]instanceOutput, 0, len(instances)),
+			Active:      manager.GetActiveInstance(),
+			ActiveState: active,
+		}
+		for _, inst := range instances {
+			out.Instances = append(out.Instances, instanceOutput{
+				Name:          inst.Name,
+				Path:          inst.Path,
+				Mods:          inst.ModCount,
+				Configs:       inst.ConfigCount,
+				Saves:         inst.SaveCount,
+				ResourcePacks: inst.ResourcePackCount,
+				ShaderPacks:   inst.ShaderPackCount,
+				Active:        inst.IsActive,
+			})
+		}
+
+		render(out, func() {
+			fmt.Println("Available instances:")
+			if len(instances) == 0 {
+				fmt.Println("  No instances found")
+			} else {
+				for _, inst := range instances {
+					status := "Inactive"
+					if inst.IsActive {
+						status = "ACTIVE"
+					}
+					fmt.Printf("  - %-20s (%d mods, %d configs, %d saves, %d resource packs, %d shader packs) [%s]\n",
+						inst.Name, inst.ModCount, inst.ConfigCount, inst.SaveCount,
+						inst.ResourcePackCount, inst.ShaderPackCount, status)
 				}
-				fmt.Printf("  - %-20s (%d mods, %d configs, %d saves) [%s]\n",
-					inst.Name, inst.ModCount, inst.ConfigCount, inst.SaveCount, status)
 			}
-		}
 
-		fmt.Printf("\nCurrent instance: %s\n", manager.GetActiveInstance())
+			fmt.Printf("\nCurrent instance: %s\n", active)
+			switch active.Kind {
+			case instance.ActiveForeign:
+				fmt.Println("  .minecraft links to a folder that is not an instance; 'switch --force' replaces the link")
+			case instance.ActiveMissing:
+				fmt.Println("  .minecraft does not exist; run 'doctor' for details")
+			}
+		})
 	},
 }
 
@@ -109,55 +209,79 @@ var restoreCmd = &cobra.Command{
 	Long: `Restore the original .minecraft directory by removing the current symlink
 and restoring from the backup.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
+		manager := newManager()
+
+		if dryRun {
+			plan, err := manager.PlanRestore()
+			printPlan("restoring default", plan, err)
+			return
 		}
 
 		if err := manager.RestoreDefault(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error restoring default: %v\n", err)
-			os.Exit(1)
+			exitWithError(codeOperationFailed, "restoring default", err)
 		}
 
-		fmt.Println("Restored default .minecraft directory")
+		printResult("restore", "", "Restored default .minecraft directory")
 	},
 }
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete <instance-name>",
 	Short: "Delete a Minecraft instance",
-	Long: `Delete the specified Minecraft instance permanently.
+	Long: `Delete the specified Minecraft instance by moving it to the trash.
+It can be brought back with "trash restore" until the trash retention period
+(trash-retention-days, 30 by default) expires.
 Note: You cannot delete the currently active instance.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
-		}
+		manager := newManager()
 
 		instanceName := args[0]
 
-		// Confirm deletion
-		fmt.Printf("Are you sure you want to delete instance '%s'? This cannot be undone. (y/N): ", instanceName)
-		var response string
-		fmt.Scanln(&response)
+		if dryRun {
+			plan, err := manager.PlanDelete(instanceName)
+			printPlan("deleting instance", plan, err)
+			return
+		}
 
-		if response != "y" && response != "Y" {
+		// Confirm deletion
+		if !confirm(fmt.Sprintf("Are you sure you want to delete instance '%s'? It will be moved to the trash.", instanceName)) {
+			if structuredOutput() {
+				exitWithError(codeCancelled, "deleting instance", fmt.Errorf("deletion cancelled"))
+			}
 			fmt.Println("Deletion cancelled")
 			return
 		}
 
-		if err := manager.DeleteInstance(instanceName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error deleting instance: %v\n", err)
-			os.Exit(1)
+		entry, err := manager.DeleteInstance(instanceName)
+		if err != nil {
+			exitWithError(codeOperationFailed, "deleting instance", err)
 		}
 
-		fmt.Printf("Deleted instance: %s\n", instanceName)
+		out := resultOutput{Action: "delete", Instance: instanceName, Status: "ok", TrashID: entry.ID}
+		render(out, func() {
+			fmt.Printf("Deleted instance: %s\n", instanceName)
+			fmt.Printf("Undo with: trash restore %s\n", entry.ID)
+		})
 	},
 }
 
+// configShowOutput is the stable schema of `config show`.
+type configShowOutput struct {
+	Platform string            `json:"platform" yaml:"platform"`
+	Config   map[string]string `json:"config" yaml:"config"`
+	// Sources tells for each key whether the value came from a flag, the
+	// environment, the config file or the built-in default.
+	Sources map[string]string `json:"sources" yaml:"sources"`
+}
+
+// configValueOutput is the stable schema of `config <key> [value]`.
+type configValueOutput struct {
+	Key     string `json:"key" yaml:"key"`
+	Value   string `json:"value" yaml:"value"`
+	Updated bool   `json:"updated" yaml:"updated"`
+}
+
 /*
 config command usage:
 
@@ -165,76 +289,287 @@ config command usage:
 	config <key>
 	config <key> <path>
 
-Supported keys: minecraft-path, instances-path, backup-path
+Supported keys: minecraft-path, instances-path, backup-path,
+trash-retention-days, relative-symlinks
 */
+var configMove bool
+
 var configCmd = &cobra.Command{
 	Use:   "config <key|show> [path]",
 	Short: "Get or set configuration values",
 	Long: `Get or set configuration values.
 Examples:
   config show
+  config validate
   config minecraft-path
   config minecraft-path /home/user/.minecraft
   config instances-path /path/to/instances
+  config instances-path /path/to/instances --move
+  config relative-symlinks true
+
+Values are taken from, highest precedence first: command-line flags such as
+--instances-path, MCIM_* environment variables such as MCIM_INSTANCES_PATH,
+the config file (--config or MCIM_CONFIG; JSON, or YAML for .yaml/.yml) and
+the built-in defaults. 'config show' tells where each value came from. Setting
+a key only changes the config file; a flag or variable still overrides it.
+
+With --move, every instance is moved to the new instances-path and the active
+.minecraft link is updated. An interrupted move is resumed by running the same
+command again.
 `,
 	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
-		manager, err := instance.NewManager()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing manager: %v\n", err)
-			os.Exit(1)
-		}
+		// Invalid settings must be viewable and fixable here
+		opts := managerOptions()
+		opts.AllowInvalid = true
+		manager := newManagerWithOptions(opts)
 
 		key := strings.ToLower(args[0])
 
 		if key == "show" || key == "list" {
-			cfg := manager.GetConfig()
-			fmt.Printf("Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
-			fmt.Println("Configuration:")
-			for k, v := range cfg {
-				fmt.Printf("  %s: %s\n", k, v)
+			out := configShowOutput{
+				Platform: fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+				Config:   manager.GetConfig(),
+				Sources:  make(map[string]string),
 			}
+			for k := range out.Config {
+				out.Sources[k] = manager.ConfigSource(k).String()
+			}
+			render(out, func() {
+				fmt.Printf("Platform: %s\n", out.Platform)
+				fmt.Println("Configuration:")
+				for _, k := range instance.ConfigKeys {
+					if v, ok := out.Config[k]; ok {
+						fmt.Printf("  %s: %s (%s)\n", k, v, out.Sources[k])
+					}
+				}
+			})
 			return
 		}
 
-		// normalize keys
-		switch key {
-		case "minecraft", "minecraft-dir":
-			key = "minecraft-path"
-		case "instances", "instances-dir":
-			key = "instances-path"
-		case "backup", "backup-dir":
-			key = "backup-path"
+		if key == "validate" {
+			validateConfig(manager)
+			return
 		}
 
+		key = instance.CanonicalConfigKey(key)
+
 		if len(args) == 1 {
 			// show single value
 			cfg := manager.GetConfig()
 			val, ok := cfg[key]
 			if !ok {
-				fmt.Fprintf(os.Stderr, "Unknown config key: %s\n", key)
-				os.Exit(1)
+				exitWithError(codeUnknownKey, "reading config", fmt.Errorf("unknown config key: %s", key))
 			}
-			fmt.Printf("%s: %s\n", key, val)
+			render(configValueOutput{Key: key, Value: val}, func() {
+				fmt.Printf("%s: %s\n", key, val)
+			})
 			return
 		}
 
 		// set new value
 		newPath := args[1]
+		if configMove {
+			if key != "instances-path" {
+				exitWithError(codeInvalidArgs, "updating config", fmt.Errorf("--move only applies to instances-path"))
+			}
+			moveInstances(manager, newPath)
+			return
+		}
+		if dryRun {
+			plan, err := manager.PlanUpdateConfig(key, newPath)
+			printPlan("updating config", plan, err)
+			return
+		}
+		oldPath := manager.InstancesPath
+		oldInstances, _ := manager.ListInstances()
 		if err := manager.UpdateConfig(key, newPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error updating config: %v\n", err)
-			os.Exit(1)
+			exitWithError(codeOperationFailed, "updating config", err)
+		}
+		render(configValueOutput{Key: key, Value: manager.GetConfig()[key], Updated: true}, func() {
+			fmt.Printf("Updated %s -> %s\n", key, newPath)
+			if src := manager.ConfigSource(key); src.Layer == instance.SourceFlag || src.Layer == instance.SourceEnv {
+				fmt.Printf("Note: %s still overrides the config file for this key\n", src)
+			}
+			if key == "instances-path" && manager.InstancesPath != oldPath && len(oldInstances) > 0 {
+				fmt.Printf("Note: %d instance(s) were left in the old directory; use --move to relocate them\n", len(oldInstances))
+			}
+		})
+	},
+}
+
+// configValidateOutput is the stable schema of `config validate`.
+type configValidateOutput struct {
+	Valid    bool                     `json:"valid" yaml:"valid"`
+	Version  int                      `json:"version" yaml:"version"`
+	Problems []instance.ConfigProblem `json:"problems" yaml:"problems"`
+}
+
+// validateConfig implements `config validate`; it exits 1 on problems.
+func validateConfig(manager *instance.Manager) {
+	problems := manager.ValidateConfig()
+	out := configValidateOutput{
+		Valid:    len(problems) == 0,
+		Version:  instance.ConfigVersion,
+		Problems: problems,
+	}
+	if out.Problems == nil {
+		out.Problems = []instance.ConfigProblem{}
+	}
+	render(out, func() {
+		if out.Valid {
+			fmt.Printf("Configuration is valid (schema version %d)\n", out.Version)
+			return
+		}
+		fmt.Println("Configuration problems:")
+		for _, p := range problems {
+			fmt.Printf("  - %s\n", p)
+		}
+	})
+	if !out.Valid {
+		os.Exit(1)
+	}
+}
+
+// moveInstances implements `config instances-path <path> --move`.
+func moveInstances(manager *instance.Manager, newPath string) {
+	if dryRun {
+		plan, err := manager.PlanMoveInstancesPath(newPath)
+		printPlan("moving instances", plan, err)
+		return
+	}
+
+	// Ctrl+C stops after the current file; rerunning the command resumes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	progress, finish := progressPrinter("Moving")
+	err := manager.MoveInstancesPath(ctx, newPath, progress)
+	finish()
+	if err != nil {
+		if ctx.Err() != nil {
+			exitWithError(codeCancelled, "moving instances", fmt.Errorf("%w; run the command again to resume", err))
+		}
+		exitWithError(codeOperationFailed, "moving instances", err)
+	}
+
+	render(configValueOutput{Key: "instances-path", Value: manager.InstancesPath, Updated: true}, func() {
+		fmt.Printf("Moved instances to %s\n", manager.InstancesPath)
+	})
+}
+
+var historyLimit int
+
+// historyOutput is the stable schema of `history`.
+type historyOutput struct {
+	Entries []instance.HistoryEntry `json:"entries" yaml:"entries"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent instance switches",
+	Long: `Show the most recent switches between instances, newest first. Only
+the last few thousand switches are kept.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+
+		entries, err := manager.History(historyLimit)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading history", err)
 		}
-		fmt.Printf("Updated %s -> %s\n", key, newPath)
+
+		render(historyOutput{Entries: entries}, func() {
+			if len(entries) == 0 {
+				fmt.Println("No switches recorded yet")
+				return
+			}
+			for _, e := range entries {
+				from := e.From
+				if from == "" {
+					from = "(no instance)"
+				}
+				fmt.Printf("  %s  %-20s -> %-20s (%s)\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"), from, e.To, e.User)
+			}
+		})
 	},
 }
 
+// versionOutput is the stable schema of `version`.
+type versionOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	Version  string `json:"version" yaml:"version"`
+	Platform string `json:"platform" yaml:"platform"`
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
 	Long:  `Display the current version of the Minecraft Instance Manager.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("%s %s\n", AppName, Version)
-		fmt.Printf("Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		out := versionOutput{
+			Name:     AppName,
+			Version:  Version,
+			Platform: fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		}
+		render(out, func() {
+			fmt.Printf("%s %s\n", out.Name, out.Version)
+			fmt.Printf("Platform: %s\n", out.Platform)
+		})
 	},
 }
+
+// progressPrinter returns a ProgressFunc that redraws a single status line on
+// stderr, at most a few times per second, and a finish func that terminates
+// the line once the operation is over.
+func progressPrinter(label string) (instance.ProgressFunc, func()) {
+	// A redrawn line only makes sense on a terminal; scripts, cron jobs and
+	// structured output get no progress at all
+	if structuredOutput() || !stderrIsTerminal() {
+		return nil, func() {}
+	}
+	var last time.Time
+	printed := false
+	finish := func() {
+		if printed {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	return func(p instance.CopyProgress) {
+		done := p.FilesDone == p.FilesTotal
+		if !done && time.Since(last) < 100*time.Millisecond {
+			return
+		}
+		last = time.Now()
+
+		line := fmt.Sprintf("%s: %d/%d files, %s/%s (%.0f%%)", label,
+			p.FilesDone, p.FilesTotal, formatBytes(p.BytesDone), formatBytes(p.BytesTotal), p.Percent()*100)
+		if eta := p.ETA(); eta > 0 {
+			line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+		printed = true
+	}, finish
+}
+
+// stderrIsTerminal reports whether stderr is a terminal rather than a pipe
+// or file.
+func stderrIsTerminal() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// formatBytes renders a byte count using binary units (KiB, MiB, ...).
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
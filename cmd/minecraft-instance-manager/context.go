@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextCreateCmd)
+	rootCmd.AddCommand(contextCmd)
+}
+
+// contextListOutput is the stable schema of `context list`.
+type contextListOutput struct {
+	Current  string             `json:"current" yaml:"current"`
+	Source   string             `json:"source" yaml:"source"`
+	Contexts []instance.Context `json:"contexts" yaml:"contexts"`
+}
+
+// contextOutput is the stable schema of `context use` and `context create`.
+type contextOutput struct {
+	Action  string           `json:"action" yaml:"action"`
+	Context instance.Context `json:"context" yaml:"context"`
+	Status  string           `json:"status" yaml:"status"`
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "List, select or create contexts",
+	Long: `A context is a named set of minecraft-path, instances-path and backup-path,
+e.g. one per launcher or for a server directory. Every command works on the
+current context unless --context or MCIM_CONTEXT selects another one.
+
+The "default" context uses the paths set with 'config'. Named contexts keep
+their default instances, backup and switch history in
+<config dir>/minecraft-instance/contexts/<name>.`,
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Listing contexts helps to leave a broken one
+		opts := managerOptions()
+		opts.AllowInvalid = true
+		manager := newManagerWithOptions(opts)
+
+		out := contextListOutput{
+			Current:  manager.Context,
+			Source:   manager.ContextSource().String(),
+			Contexts: manager.ListContexts(),
+		}
+		render(out, func() {
+			for _, c := range out.Contexts {
+				mark := " "
+				if c.Current {
+					mark = "*"
+				}
+				fmt.Printf("%s %s\n", mark, c.Name)
+				fmt.Printf("    minecraft-path: %s\n", c.MinecraftPath)
+				fmt.Printf("    instances-path: %s\n", c.InstancesPath)
+				fmt.Printf("    backup-path:    %s\n", c.BackupPath)
+			}
+		})
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a context the current one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		// The context to switch to may not be the one selected for this run
+		opts := managerOptions()
+		opts.Context = ""
+		opts.AllowInvalid = true // leaving a broken context must work
+		manager := newManagerWithOptions(opts)
+
+		if dryRun {
+			plan, err := manager.PlanUseContext(name)
+			printPlan("switching context", plan, err)
+			return
+		}
+
+		if err := manager.UseContext(name); err != nil {
+			exitWithError(codeOperationFailed, "switching context", err)
+		}
+		opts.Context = name
+		used := newManagerWithOptions(opts)
+
+		out := contextOutput{Action: "use", Context: currentContext(used), Status: "ok"}
+		render(out, func() {
+			fmt.Printf("Switched to context: %s\n", name)
+			if src := manager.ContextSource(); src.Layer == instance.SourceEnv {
+				fmt.Printf("Note: %s still selects another context\n", src)
+			}
+		})
+	},
+}
+
+var contextCreateCmd = &cobra.Command{
+	Use:   "create <name> --minecraft-path <path> [--instances-path <path>] [--backup-path <path>]",
+	Short: "Create a context",
+	Long: `Create a context with its own paths. --minecraft-path is required; instances
+and backup default to directories below the context's state directory.
+The new context does not become current; run 'context use <name>' for that.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		// Here the path flags describe the new context instead of
+		// overriding the current one
+		opts := managerOptions()
+		paths := instance.ContextConfig{
+			MinecraftPath: opts.Flags["minecraft-path"],
+			InstancesPath: opts.Flags["instances-path"],
+			BackupPath:    opts.Flags["backup-path"],
+		}
+		delete(opts.Flags, "minecraft-path")
+		delete(opts.Flags, "instances-path")
+		delete(opts.Flags, "backup-path")
+		opts.AllowInvalid = true // the new context is validated on its own
+		manager := newManagerWithOptions(opts)
+
+		if dryRun {
+			plan, err := manager.PlanCreateContext(name, paths)
+			printPlan("creating context", plan, err)
+			return
+		}
+
+		if err := manager.CreateContext(name, paths); err != nil {
+			exitWithError(codeOperationFailed, "creating context", err)
+		}
+
+		var created instance.Context
+		for _, c := range manager.ListContexts() {
+			if c.Name == name {
+				created = c
+			}
+		}
+		out := contextOutput{Action: "create", Context: created, Status: "ok"}
+		render(out, func() {
+			fmt.Printf("Created context: %s\n", name)
+			fmt.Printf("  minecraft-path: %s\n", created.MinecraftPath)
+			fmt.Printf("  instances-path: %s\n", created.InstancesPath)
+			fmt.Printf("  backup-path:    %s\n", created.BackupPath)
+			fmt.Printf("Switch to it with: context use %s\n", name)
+		})
+	},
+}
+
+// currentContext returns the manager's active context.
+func currentContext(manager *instance.Manager) instance.Context {
+	for _, c := range manager.ListContexts() {
+		if c.Current {
+			return c
+		}
+	}
+	return instance.Context{Name: manager.Context}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/crash"
+	"github.com/spf13/cobra"
+)
+
+var crashList bool
+
+func init() {
+	crashCmd.Flags().BoolVar(&crashList, "list", false, "list the crash reports instead of explaining one")
+	rootCmd.AddCommand(crashCmd)
+}
+
+// crashOutput is the stable schema of `crash`.
+type crashOutput struct {
+	Instance string          `json:"instance" yaml:"instance"`
+	Report   *crash.Analysis `json:"report" yaml:"report"`
+}
+
+// crashListOutput is the stable schema of `crash --list`.
+type crashListOutput struct {
+	Instance string       `json:"instance" yaml:"instance"`
+	Reports  []crash.File `json:"reports" yaml:"reports"`
+}
+
+var crashCmd = &cobra.Command{
+	Use:   "crash <instance> [report]",
+	Short: "Explain the latest crash of an instance",
+	Long: `Read the newest crash report of an instance, either one the game wrote to
+crash-reports/ or an hs_err_pid*.log the Java VM left when it crashed
+itself, unless another report is named.
+
+The description, the exception and its causes, and the mods the game
+suspects are shown. Stack frames are mapped back to the installed jars in
+mods/ by the jar and mod names in the frames and the Java packages of each
+jar, followed by a short diagnosis.
+
+Showing the newest report clears the crash badge of the instance in the TUI.
+
+Examples:
+  crash survival
+  crash survival --list
+  crash survival crash-2026-10-18_12.00.00-client.txt`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		dir := instanceDir(manager, args[0], "reading crash reports")
+
+		files, err := crash.Files(dir)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading crash reports", err)
+		}
+		if files == nil {
+			files = []crash.File{}
+		}
+
+		if crashList {
+			render(crashListOutput{Instance: args[0], Reports: files}, func() {
+				if len(files) == 0 {
+					fmt.Printf("Instance '%s' has no crash reports\n", args[0])
+					return
+				}
+				for _, f := range files {
+					fmt.Printf("  %-48s %-4s  %s\n", f.Name, f.Kind, f.ModTime.Local().Format("2006-01-02 15:04"))
+				}
+			})
+			return
+		}
+
+		if len(files) == 0 {
+			render(crashOutput{Instance: args[0]}, func() {
+				fmt.Printf("Instance '%s' has no crash reports\n", args[0])
+			})
+			return
+		}
+		file := files[0]
+		if len(args) == 2 {
+			found := false
+			for _, f := range files {
+				if f.Name == args[1] {
+					file, found = f, true
+				}
+			}
+			if !found {
+				exitWithError(codeInvalidArgs, "reading crash reports", fmt.Errorf("instance '%s' has no crash report %q", args[0], args[1]))
+			}
+		}
+
+		report, err := crash.Parse(file)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading crash reports", err)
+		}
+		analysis, err := crash.Analyze(report, filepath.Join(dir, "mods"))
+		if err != nil {
+			exitWithError(codeOperationFailed, "analyzing crash report", err)
+		}
+		// The badge only tracks the newest report, so an older one shown
+		// does not clear it
+		seen := crash.LoadSeen(filepath.Join(manager.AppDir, crash.SeenFileName))
+		if err := seen.Mark(dir, file); err != nil {
+			exitWithError(codeOperationFailed, "reading crash reports", err)
+		}
+
+		render(crashOutput{Instance: args[0], Report: analysis}, func() {
+			printCrash(analysis)
+		})
+	},
+}
+
+func printCrash(a *crash.Analysis) {
+	fmt.Printf("%s (%s)\n", a.Name, a.ModTime.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("\nDescription: %s\n", a.Description)
+	if a.MinecraftVersion != "" {
+		fmt.Printf("Minecraft:   %s\n", a.MinecraftVersion)
+	}
+	if a.Signal != "" {
+		fmt.Printf("Signal:      %s\n", a.Signal)
+	}
+	if a.ProblematicFrame != "" {
+		fmt.Printf("Frame:       %s\n", a.ProblematicFrame)
+	}
+
+	if len(a.Exceptions) > 0 {
+		fmt.Println("\nException:")
+		for i, e := range a.Exceptions {
+			prefix := "  "
+			if i > 0 {
+				prefix = "  Caused by: "
+			}
+			first, _, _ := strings.Cut(e.String(), "\n")
+			fmt.Printf("%s%s\n", prefix, first)
+		}
+	}
+
+	if len(a.SuspectedMods) > 0 {
+		fmt.Println("\nSuspected mods:")
+		for _, s := range a.SuspectedMods {
+			fmt.Printf("  %s (%s) %s\n", s.Name, s.ID, s.Version)
+		}
+	}
+
+	if len(a.Culprits) > 0 {
+		fmt.Println("\nInstalled mods in the stack trace:")
+		for _, c := range a.Culprits {
+			frames := fmt.Sprintf("%d frames", c.Frames)
+			if c.Frames == 1 {
+				frames = "1 frame"
+			}
+			fmt.Printf("  %-40s %-10s  %s\n", c.Jar, frames, c.Label())
+		}
+	}
+
+	fmt.Println("\nDiagnosis:")
+	for _, d := range a.Diagnosis {
+		fmt.Printf("  • %s\n", d)
+	}
+}
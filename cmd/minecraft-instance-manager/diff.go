@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffOutput is the stable schema of `diff`.
+type diffOutput struct {
+	A         string        `json:"a" yaml:"a"`
+	B         string        `json:"b" yaml:"b"`
+	Identical bool          `json:"identical" yaml:"identical"`
+	Sections  []diffSection `json:"sections" yaml:"sections"` // in the order of instance.InstanceSections
+}
+
+// diffSection lists the entries of one folder that differ between the two
+// instances. Entries are paths relative to the folder.
+type diffSection struct {
+	Name    string   `json:"name" yaml:"name"`
+	OnlyInA []string `json:"only_in_a" yaml:"only_in_a"`
+	OnlyInB []string `json:"only_in_b" yaml:"only_in_b"`
+	Changed []string `json:"changed" yaml:"changed"` // files in both whose contents differ
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <instance-a> <instance-b>",
+	Short: "Compare the mods, configs, worlds and packs of two instances",
+	Long: `Compare two instances folder by folder: mods, config (including
+subfolders), saves, resourcepacks and shaderpacks. Entries only present in
+one instance are listed with - (only in the first) or + (only in the second);
+files present in both but with different contents are listed with ~.
+
+Worlds and packs that are folders are compared by name only.
+
+Examples:
+  diff survival creative
+  diff survival creative --output json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		a, b := args[0], args[1]
+
+		infoA, err := manager.GetInstanceInfo(a)
+		if err != nil {
+			exitWithError(codeOperationFailed, "comparing instances", err)
+		}
+		infoB, err := manager.GetInstanceInfo(b)
+		if err != nil {
+			exitWithError(codeOperationFailed, "comparing instances", err)
+		}
+
+		entries := func(info *instance.InstanceInfo) map[string][]string {
+			return map[string][]string{
+				"mods":          info.ModsDir,
+				"config":        info.ConfigsDir,
+				"saves":         info.SavesDir,
+				"resourcepacks": info.ResourcePacksDir,
+				"shaderpacks":   info.ShaderPacksDir,
+			}
+		}
+		entriesA, entriesB := entries(infoA), entries(infoB)
+
+		out := diffOutput{A: a, B: b, Identical: true, Sections: []diffSection{}}
+		for _, section := range instance.InstanceSections {
+			dirA := filepath.Join(manager.InstancePath(a), section)
+			dirB := filepath.Join(manager.InstancePath(b), section)
+			s, err := diffEntries(section, entriesA[section], entriesB[section], dirA, dirB)
+			if err != nil {
+				exitWithError(codeOperationFailed, "comparing instances", err)
+			}
+			if len(s.OnlyInA)+len(s.OnlyInB)+len(s.Changed) > 0 {
+				out.Identical = false
+			}
+			out.Sections = append(out.Sections, s)
+		}
+
+		render(out, func() {
+			if out.Identical {
+				fmt.Printf("Instances '%s' and '%s' have the same mods, configs, worlds and packs\n", a, b)
+				return
+			}
+			fmt.Printf("- only in %s, + only in %s, ~ changed\n", a, b)
+			for _, s := range out.Sections {
+				if len(s.OnlyInA)+len(s.OnlyInB)+len(s.Changed) == 0 {
+					continue
+				}
+				fmt.Printf("\n%s:\n", s.Name)
+				for _, e := range s.OnlyInA {
+					fmt.Printf("  - %s\n", e)
+				}
+				for _, e := range s.OnlyInB {
+					fmt.Printf("  + %s\n", e)
+				}
+				for _, e := range s.Changed {
+					fmt.Printf("  ~ %s\n", e)
+				}
+			}
+		})
+	},
+}
+
+// diffEntries compares the sorted entry lists of one section, reading files
+// present in both from dirA and dirB.
+func diffEntries(section string, a, b []string, dirA, dirB string) (diffSection, error) {
+	s := diffSection{Name: section, OnlyInA: []string{}, OnlyInB: []string{}, Changed: []string{}}
+	inB := make(map[string]bool, len(b))
+	for _, e := range b {
+		inB[e] = true
+	}
+	inA := make(map[string]bool, len(a))
+	for _, e := range a {
+		inA[e] = true
+		if !inB[e] {
+			s.OnlyInA = append(s.OnlyInA, e)
+			continue
+		}
+		same, err := sameContents(filepath.Join(dirA, filepath.FromSlash(e)), filepath.Join(dirB, filepath.FromSlash(e)))
+		if err != nil {
+			return s, err
+		}
+		if !same {
+			s.Changed = append(s.Changed, e)
+		}
+	}
+	for _, e := range b {
+		if !inA[e] {
+			s.OnlyInB = append(s.OnlyInB, e)
+		}
+	}
+	return s, nil
+}
+
+// sameContents reports whether two files have the same contents. Folders
+// always count as the same. Symlinks are followed; dangling ones are the
+// same only if both point at the same target.
+func sameContents(a, b string) (bool, error) {
+	infoA, err := statEntry(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := statEntry(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Mode()&os.ModeSymlink != 0 || infoB.Mode()&os.ModeSymlink != 0 {
+		targetA, _ := os.Readlink(a)
+		targetB, _ := os.Readlink(b)
+		return infoA.Mode().Type() == infoB.Mode().Type() && targetA == targetB, nil
+	}
+	if infoA.IsDir() || infoB.IsDir() {
+		return infoA.IsDir() == infoB.IsDir(), nil
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA, bufB := make([]byte, 64<<10), make([]byte, 64<<10)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// statEntry returns the FileInfo of the file a symlink at path points to,
+// or of the link itself if it dangles.
+func statEntry(path string) (os.FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return info, err
+	}
+	if target, err := os.Stat(path); err == nil {
+		return target, nil
+	}
+	return info, nil
+}
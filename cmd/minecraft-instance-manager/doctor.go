@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "offer to apply safe repairs")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorOutput is the stable schema of `doctor`.
+type doctorOutput struct {
+	*instance.DoctorReport
+	DryRun bool `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose and repair the instance setup",
+	Long: `Check for a dangling or foreign .minecraft symlink, a backup that would be
+overwritten, directories nested inside .minecraft and unexpanded ~ paths in
+the config file. With --fix, each safe repair is shown and applied after
+confirmation (or --yes). Exits with status 1 while errors remain.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// A broken configuration is what doctor is for
+		opts := managerOptions()
+		opts.AllowInvalid = true
+		manager := newManagerWithOptions(opts)
+		report := manager.Diagnose()
+
+		if doctorFix && !dryRun {
+			fixed := map[string]bool{}
+			for _, f := range report.Findings {
+				if f.Fix == nil || f.Severity == instance.SeverityOK {
+					continue
+				}
+				if structuredOutput() {
+					fmt.Fprintf(os.Stderr, "%s: %s\n", f.Check, f.Message)
+				} else {
+					fmt.Printf("%s\n", f.Message)
+				}
+				if !confirm(fmt.Sprintf("Apply fix for %s?", f.Check)) {
+					continue
+				}
+				if err := manager.Repair(f); err != nil {
+					exitWithError(codeOperationFailed, "repairing "+f.Check, err)
+				}
+				fixed[f.Check] = true
+			}
+			// Diagnose again so the report reflects the repaired state
+			report = manager.Diagnose()
+			for i := range report.Findings {
+				report.Findings[i].Fixed = fixed[report.Findings[i].Check]
+			}
+		}
+
+		render(doctorOutput{DoctorReport: report, DryRun: doctorFix && dryRun}, func() {
+			printDoctorReport(report)
+		})
+		if !report.Healthy {
+			os.Exit(1)
+		}
+	},
+}
+
+func printDoctorReport(report *instance.DoctorReport) {
+	marks := map[instance.Severity]string{
+		instance.SeverityOK:      "✓",
+		instance.SeverityWarning: "!",
+		instance.SeverityError:   "✗",
+	}
+	for _, f := range report.Findings {
+		fmt.Printf("%s %s\n", marks[f.Severity], f.Message)
+		if f.Fixed {
+			fmt.Println("    Repaired by doctor --fix")
+		}
+		if f.Severity == instance.SeverityOK {
+			continue
+		}
+		if f.Explanation != "" {
+			fmt.Printf("    %s\n", f.Explanation)
+		}
+		if f.Fix != nil {
+			label := "Fix (doctor --fix)"
+			if dryRun {
+				label = "Fix would"
+			}
+			for _, step := range f.Fix.Steps {
+				fmt.Printf("    %s: %s\n", label, step)
+			}
+		}
+	}
+
+	if report.Healthy {
+		fmt.Println("\nNo problems found that would break switching.")
+	} else {
+		fmt.Println("\nProblems found; see above.")
+	}
+}
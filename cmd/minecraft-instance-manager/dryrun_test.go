@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+)
+
+// snapshotTree describes every entry below dir by its mode, modification
+// time, contents and link target.
+func snapshotTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	tree := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		desc := fmt.Sprintf("%v %d", info.Mode(), info.ModTime().UnixNano())
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			target, _ := os.Readlink(p)
+			desc += " -> " + target
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			desc += fmt.Sprintf(" %x", sha256.Sum256(data))
+		}
+		tree[p] = desc
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestDryRunTouchesNothing(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	appDir := filepath.Join(home, ".config", instance.AppFolderName)
+	files := map[string]string{
+		".minecraft/options.txt":                   "fov:70",
+		"backup/options.txt":                       "older",
+		"instances/survival/options.txt":           "fov:90",
+		"instances/survival/mods/sodium.jar":       "jar",
+		"instances/survival/saves/World/level.dat": strings.Repeat("x", 64),
+		"instances/creative/options.txt":           "fov:80",
+	}
+	for name, content := range files {
+		p := filepath.Join(appDir, filepath.FromSlash(name))
+		if strings.HasPrefix(name, ".minecraft/") {
+			p = filepath.Join(home, filepath.FromSlash(name))
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout.Close(); os.Stdout = stdout }()
+	t.Cleanup(func() { dryRun, assumeYes = false, false })
+
+	// Let the first run create the config file and state folders
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotTree(t, home)
+	if _, ok := before[filepath.Join(appDir, "config.json")]; !ok {
+		t.Fatalf("the first run wrote no config file: %v", before)
+	}
+
+	for _, args := range [][]string{
+		{"switch", "survival"},
+		{"create", "fresh"},
+		{"delete", "creative"},
+		{"restore"},
+		{"config", "instances-path", filepath.Join(home, "elsewhere")},
+		{"context", "create", "server", "--minecraft-path", filepath.Join(home, "server")},
+		{"context", "use", "default"},
+		{"worlds", "delete", "survival", "World"},
+		{"worlds", "rename", "survival", "World", "Renamed"},
+		{"worlds", "copy", "survival", "World", "creative"},
+		{"worlds", "snapshot", "survival", "World"},
+		{"servers", "add", "survival", "Example", "mc.example.org"},
+		{"doctor", "--fix"},
+		{"trash", "empty"},
+	} {
+		rootCmd.SetArgs(append([]string{"--dry-run", "--yes"}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%s: %v", strings.Join(args, " "), err)
+			continue
+		}
+		after := snapshotTree(t, home)
+		if !reflect.DeepEqual(after, before) {
+			for p, desc := range after {
+				if old, ok := before[p]; !ok {
+					t.Errorf("--dry-run %s created %s", strings.Join(args, " "), p)
+				} else if desc != old {
+					t.Errorf("--dry-run %s changed %s", strings.Join(args, " "), p)
+				}
+			}
+			for p := range before {
+				if _, ok := after[p]; !ok {
+					t.Errorf("--dry-run %s removed %s", strings.Join(args, " "), p)
+				}
+			}
+			before = after
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(duCmd)
+}
+
+// duOutput is the stable schema of `du`.
+type duOutput struct {
+	Instances  []duInstance `json:"instances" yaml:"instances"`
+	TotalBytes int64        `json:"total_bytes" yaml:"total_bytes"`
+}
+
+// duInstance is the disk usage of one instance.
+type duInstance struct {
+	Name      string      `json:"name" yaml:"name"`
+	SizeBytes int64       `json:"size_bytes" yaml:"size_bytes"`
+	Sections  []duSection `json:"sections" yaml:"sections"` // in the order of instance.InstanceSections
+}
+
+type duSection struct {
+	Name      string `json:"name" yaml:"name"`
+	SizeBytes int64  `json:"size_bytes" yaml:"size_bytes"`
+}
+
+var duCmd = &cobra.Command{
+	Use:   "du [instance...]",
+	Short: "Show the disk usage of instances",
+	Long: `Show how much disk space each instance uses, in total and per folder
+(mods, config, saves, resourcepacks, shaderpacks), largest first. Without
+arguments every instance is measured.
+
+Examples:
+  du
+  du survival creative
+  du --output json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+
+		names := args
+		if len(names) == 0 {
+			instances, err := manager.ListInstances()
+			if err != nil {
+				exitWithError(codeOperationFailed, "measuring instances", err)
+			}
+			for _, inst := range instances {
+				names = append(names, inst.Name)
+			}
+		}
+
+		out := duOutput{Instances: []duInstance{}}
+		for _, name := range names {
+			stats, err := manager.GetInstanceStats(name)
+			if err != nil {
+				exitWithError(codeOperationFailed, "measuring instances", err)
+			}
+			entry := duInstance{Name: name, SizeBytes: stats.Size, Sections: []duSection{}}
+			for _, section := range instance.InstanceSections {
+				entry.Sections = append(entry.Sections, duSection{Name: section, SizeBytes: stats.Sizes[section]})
+			}
+			out.Instances = append(out.Instances, entry)
+			out.TotalBytes += stats.Size
+		}
+		sort.SliceStable(out.Instances, func(i, j int) bool {
+			return out.Instances[i].SizeBytes > out.Instances[j].SizeBytes
+		})
+
+		render(out, func() {
+			if len(out.Instances) == 0 {
+				fmt.Println("No instances found")
+				return
+			}
+			fmt.Printf("%-20s %10s", "INSTANCE", "TOTAL")
+			for _, section := range instance.InstanceSections {
+				fmt.Printf(" %13s", section)
+			}
+			fmt.Println()
+			for _, inst := range out.Instances {
+				fmt.Printf("%-20s %10s", inst.Name, formatBytes(inst.SizeBytes))
+				for _, s := range inst.Sections {
+					fmt.Printf(" %13s", formatBytes(s.SizeBytes))
+				}
+				fmt.Println()
+			}
+			if len(out.Instances) > 1 {
+				fmt.Printf("%-20s %10s\n", "total", formatBytes(out.TotalBytes))
+			}
+		})
+	},
+}
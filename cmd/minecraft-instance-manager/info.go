@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	infoModsOnly    bool
+	infoConfigsOnly bool
+	infoSavesOnly   bool
+	infoMatch       string
+)
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoModsOnly, "mods-only", false, "only list mods")
+	infoCmd.Flags().BoolVar(&infoConfigsOnly, "configs-only", false, "only list configs")
+	infoCmd.Flags().BoolVar(&infoSavesOnly, "saves-only", false, "only list saves")
+	infoCmd.Flags().StringVar(&infoMatch, "match", "", "only list entries whose name or path matches this glob, e.g. '*fabric*' (config files in subfolders match by file name too)")
+	rootCmd.AddCommand(infoCmd)
+}
+
+// infoOutput is the stable schema of `info`.
+type infoOutput struct {
+	Name      string          `json:"name" yaml:"name"`
+	Path      string          `json:"path" yaml:"path"`
+	Active    bool            `json:"active" yaml:"active"`
+	SizeBytes int64           `json:"size_bytes" yaml:"size_bytes"`
+	LastUsed  *time.Time      `json:"last_used,omitempty" yaml:"last_used,omitempty"`
+	Sections  []sectionOutput `json:"sections" yaml:"sections"`
+}
+
+type sectionOutput struct {
+	Name      string   `json:"name" yaml:"name"`
+	Total     int      `json:"total" yaml:"total"` // entries before filtering
+	Count     int      `json:"count" yaml:"count"` // entries listed below
+	SizeBytes int64    `json:"size_bytes" yaml:"size_bytes"`
+	Entries   []string `json:"entries" yaml:"entries"`
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info <instance-name>",
+	Short: "Show the contents of an instance",
+	Long: `Show the mods, configs, saves, resource packs and shader packs of an
+instance together with its size, last-used time and status.
+
+Examples:
+  info modpack-1.20.1
+  info modpack-1.20.1 --mods-only --match '*create*'
+  info modpack-1.20.1 --configs-only --match '*.cfg'
+  info modpack-1.20.1 --output json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		name := args[0]
+
+		if infoMatch != "" {
+			if _, err := path.Match(infoMatch, ""); err != nil {
+				exitWithError(codeInvalidArgs, "parsing --match", err)
+			}
+		}
+
+		info, err := manager.GetInstanceInfo(name)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading instance", err)
+		}
+		stats, err := manager.GetInstanceStats(name)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading instance", err)
+		}
+
+		out := infoOutput{
+			Name:      name,
+			Path:      manager.InstancePath(name),
+			Active:    manager.GetActiveInstance() == name,
+			SizeBytes: stats.Size,
+		}
+		if !stats.LastUsed.IsZero() {
+			out.LastUsed = &stats.LastUsed
+		}
+
+		sections := []struct {
+			name    string
+			entries []string
+			only    bool
+		}{
+			{"mods", info.ModsDir, infoModsOnly},
+			{"config", info.ConfigsDir, infoConfigsOnly},
+			{"saves", info.SavesDir, infoSavesOnly},
+			{"resourcepacks", info.ResourcePacksDir, false},
+			{"shaderpacks", info.ShaderPacksDir, false},
+		}
+		anyOnly := infoModsOnly || infoConfigsOnly || infoSavesOnly
+
+		for _, s := range sections {
+			if anyOnly && !s.only {
+				continue
+			}
+			entries := filterEntries(s.entries, infoMatch)
+			out.Sections = append(out.Sections, sectionOutput{
+				Name:      s.name,
+				Total:     len(s.entries),
+				Count:     len(entries),
+				SizeBytes: stats.Sizes[s.name],
+				Entries:   entries,
+			})
+		}
+
+		render(out, func() { printInfoTable(out) })
+	},
+}
+
+// filterEntries returns the entries matching the glob (case-insensitive), or
+// all entries for an empty glob. Nested config paths such as jei/jei.cfg
+// match on their file name as well as on the full path, since * does not
+// cross a slash. The result is never nil so JSON shows [].
+func filterEntries(entries []string, glob string) []string {
+	result := []string{}
+	for _, e := range entries {
+		if glob != "" {
+			pattern, name := strings.ToLower(glob), strings.ToLower(e)
+			full, _ := path.Match(pattern, name)
+			base, _ := path.Match(pattern, path.Base(name))
+			if !full && !base {
+				continue
+			}
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+func printInfoTable(out infoOutput) {
+	status := "Inactive"
+	if out.Active {
+		status = "ACTIVE"
+	}
+	lastUsed := "never"
+	if out.LastUsed != nil {
+		lastUsed = out.LastUsed.Local().Format("2006-01-02 15:04")
+	}
+
+	fmt.Printf("Instance:  %s [%s]\n", out.Name, status)
+	fmt.Printf("Path:      %s\n", out.Path)
+	fmt.Printf("Size:      %s\n", formatBytes(out.SizeBytes))
+	fmt.Printf("Last used: %s\n", lastUsed)
+
+	for _, s := range out.Sections {
+		count := fmt.Sprintf("%d", s.Total)
+		if s.Count != s.Total {
+			count = fmt.Sprintf("%d of %d", s.Count, s.Total)
+		}
+		fmt.Printf("\n%s (%s, %s):\n", s.Name, count, formatBytes(s.SizeBytes))
+		if len(s.Entries) == 0 {
+			fmt.Println("  (none)")
+		}
+		for _, e := range s.Entries {
+			fmt.Printf("  - %s\n", e)
+		}
+	}
+}
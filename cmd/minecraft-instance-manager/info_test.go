@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterEntries(t *testing.T) {
+	entries := []string{"jei/jei.cfg", "jei/world/server.cfg", "sodium-options.json", "Create-0.5.1.jar"}
+	tests := []struct {
+		glob string
+		want []string
+	}{
+		{"", entries},
+		{"*.cfg", []string{"jei/jei.cfg", "jei/world/server.cfg"}},
+		{"jei/*", []string{"jei/jei.cfg"}},
+		{"jei.cfg", []string{"jei/jei.cfg"}},
+		{"*create*", []string{"Create-0.5.1.jar"}},
+		{"*.toml", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			if got := filterEntries(entries, tt.glob); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterEntries(%q) = %q, want %q", tt.glob, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"go.yaml.in/yaml/v3"
+)
+
+// legacyConfigName is the config file in the home directory that versions
+// before 1.3 read. Only its verbose key ever had an effect.
+const legacyConfigName = ".minecraft-instance-manager.yaml"
+
+// legacyVerbose is the verbose key of the legacy config file.
+var legacyVerbose bool
+
+// readLegacyConfig reads the legacy config file, if there is one. It keeps
+// honouring verbose and explains how to move the settings the file holds
+// into the config file, since nothing else of it is applied.
+func readLegacyConfig() {
+	legacyVerbose = false
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(home, legacyConfigName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		if !structuredOutput() {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", path, err)
+		}
+		return
+	}
+	if v, ok := values["verbose"].(bool); ok {
+		legacyVerbose = v
+	}
+
+	var moves []string
+	for key, value := range values {
+		setting := instance.CanonicalConfigKey(strings.ReplaceAll(key, "_", "-"))
+		if slices.Contains(instance.SettingKeys, setting) {
+			moves = append(moves, fmt.Sprintf("  minecraft-instance-manager config %s %v", setting, value))
+		}
+	}
+	if len(moves) == 0 || structuredOutput() {
+		return
+	}
+	sort.Strings(moves)
+	fmt.Fprintf(os.Stderr, "Warning: %s is no longer read except for verbose. Move its settings with\n%s\nand remove them from it.\n",
+		path, strings.Join(moves, "\n"))
+}
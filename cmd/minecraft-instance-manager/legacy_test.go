@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLegacyConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("MCIM_VERBOSE", "")
+	os.Unsetenv("MCIM_VERBOSE")
+	legacy := filepath.Join(home, legacyConfigName)
+	instances := filepath.Join(home, "games", "instances")
+	if err := os.WriteFile(legacy, []byte("verbose: true\ninstances_path: "+instances+"\ntheme: dark\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout.Close(); os.Stdout = stdout }()
+	t.Cleanup(func() { outputFormat = outputTable; legacyVerbose = false })
+	run := func(args ...string) string {
+		return captureStderr(t, func() {
+			rootCmd.SetArgs(args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Errorf("Execute(%v): %v", args, err)
+			}
+		})
+	}
+
+	got := run("list")
+	if !strings.Contains(got, legacy) || !strings.Contains(got, "config instances-path "+instances) {
+		t.Errorf("stderr = %q, want a warning with the command that moves instances_path", got)
+	}
+	if strings.Contains(got, "theme") {
+		t.Errorf("stderr = %q, want keys that are no settings left out", got)
+	}
+	if !strings.Contains(got, "Using config file:") {
+		t.Errorf("stderr = %q, want verbose output from the legacy verbose key", got)
+	}
+
+	// The environment still wins over the legacy file
+	t.Setenv("MCIM_VERBOSE", "false")
+	if got := run("list"); strings.Contains(got, "Using config file:") {
+		t.Errorf("MCIM_VERBOSE=false printed %q", got)
+	}
+	if got := run("list", "--output", "json"); got != "" {
+		t.Errorf("stderr with --output json = %q, want nothing", got)
+	}
+
+	os.Remove(legacy)
+	if got := run("list", "--output", "table"); got != "" {
+		t.Errorf("stderr without a legacy file = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/logs"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsFollow bool
+	logsLevel  string
+	logsGrep   string
+	logsLines  int
+	logsList   bool
+)
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep printing lines as the game writes them")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "only lines of this level or above (TRACE, DEBUG, INFO, WARN, ERROR, FATAL)")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "only lines matching this regular expression (case-insensitive)")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 0, "only the last this many lines (0 for all)")
+	logsCmd.Flags().BoolVar(&logsList, "list", false, "list the log files instead of printing one")
+	rootCmd.AddCommand(logsCmd)
+}
+
+// logsOutput is the stable schema of `logs`.
+type logsOutput struct {
+	Instance string      `json:"instance" yaml:"instance"`
+	File     string      `json:"file" yaml:"file"`
+	Lines    []logs.Line `json:"lines" yaml:"lines"`
+}
+
+// logsListOutput is the stable schema of `logs --list`.
+type logsListOutput struct {
+	Instance string      `json:"instance" yaml:"instance"`
+	Files    []logs.File `json:"files" yaml:"files"`
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <instance> [file]",
+	Short: "Show or follow the game logs of an instance",
+	Long: `Print a log of an instance, logs/latest.log unless another file of the
+logs folder is named. Archived .log.gz files are decompressed on the fly.
+
+Lines are coloured by their log4j level. Lines without a level of their own,
+such as stack traces, count as the level of the line they belong to, so
+--level ERROR keeps the whole trace.
+
+With --follow the command keeps printing lines while the game writes them,
+and starts over at the top of latest.log when the game is started again.
+
+Examples:
+  logs survival
+  logs survival --level WARN --grep mixin
+  logs survival -f -n 20
+  logs survival --list
+  logs survival 2026-10-18-1.log.gz`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		dir := instanceDir(manager, args[0], "reading logs")
+
+		if logsList {
+			files, err := logs.Files(dir)
+			if err != nil {
+				exitWithError(codeOperationFailed, "listing logs", err)
+			}
+			if files == nil {
+				files = []logs.File{}
+			}
+			render(logsListOutput{Instance: args[0], Files: files}, func() {
+				if len(files) == 0 {
+					fmt.Printf("Instance '%s' has no logs\n", args[0])
+					return
+				}
+				for _, f := range files {
+					fmt.Printf("  %-32s %10s  %s\n", f.Name, formatBytes(f.Size), f.ModTime.Local().Format("2006-01-02 15:04"))
+				}
+			})
+			return
+		}
+
+		filter := logs.Filter{MinLevel: logs.LevelTrace}
+		if logsLevel != "" {
+			level, err := logs.ParseLevel(logsLevel)
+			if err != nil {
+				exitWithError(codeInvalidArgs, "reading logs", err)
+			}
+			filter.MinLevel = level
+		}
+		if logsGrep != "" {
+			re, err := regexp.Compile("(?i)" + logsGrep)
+			if err != nil {
+				exitWithError(codeInvalidArgs, "reading logs", fmt.Errorf("invalid --grep: %w", err))
+			}
+			filter.Grep = re
+		}
+
+		name := logs.Latest
+		if len(args) == 2 {
+			name = args[1]
+		}
+		if name != filepath.Base(name) {
+			exitWithError(codeInvalidArgs, "reading logs", fmt.Errorf("invalid log file name %q", name))
+		}
+		reader := logs.NewReader(filepath.Join(logs.Dir(dir), name), filter)
+		if logsFollow && structuredOutput() {
+			exitWithError(codeInvalidArgs, "reading logs", fmt.Errorf("--follow cannot be combined with --output %s", outputFormat))
+		}
+		if logsFollow && reader.Compressed() {
+			exitWithError(codeInvalidArgs, "reading logs", fmt.Errorf("%s is an archived log and cannot be followed", name))
+		}
+
+		lines, err := reader.Read(logsLines)
+		if os.IsNotExist(err) && !logsFollow {
+			exitWithError(codeOperationFailed, "reading logs", fmt.Errorf("instance '%s' has no log %s", args[0], name))
+		}
+		if err != nil && !os.IsNotExist(err) {
+			exitWithError(codeOperationFailed, "reading logs", err)
+		}
+
+		if lines == nil {
+			lines = []logs.Line{}
+		}
+		render(logsOutput{Instance: args[0], File: name, Lines: lines}, func() {
+			printLogLines(lines)
+		})
+		if !logsFollow {
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := reader.Follow(ctx, printLogLines); err != nil {
+			exitWithError(codeOperationFailed, "following logs", err)
+		}
+	},
+}
+
+// logLevelStyles colour lines by level; lipgloss drops the colours when
+// stdout is not a terminal.
+var logLevelStyles = map[logs.Level]lipgloss.Style{
+	logs.LevelTrace: lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).Foreground(lipgloss.Color("#666666")),
+	logs.LevelDebug: lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).Foreground(lipgloss.Color("#666666")),
+	logs.LevelWarn:  lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).Foreground(lipgloss.Color("#FFB000")),
+	logs.LevelError: lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).Foreground(lipgloss.Color("#FF0000")),
+	logs.LevelFatal: lipgloss.NewStyle().TabWidth(lipgloss.NoTabConversion).Foreground(lipgloss.Color("#FF0000")).Bold(true),
+}
+
+func printLogLines(lines []logs.Line) {
+	for _, l := range lines {
+		if style, ok := logLevelStyles[l.Level]; ok {
+			fmt.Println(style.Render(l.Text))
+		} else {
+			fmt.Println(l.Text)
+		}
+	}
+}
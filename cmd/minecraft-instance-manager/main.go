@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
 	"github.com/Gerry3010/minecraft-instance-switcher/internal/tui"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"github.com/spf13/pflag"
 )
 
 // Version information
@@ -24,46 +26,76 @@ to instantly switch between different Minecraft setups without copying files.
 Features a beautiful terminal interface for easy instance management.
 
 Version: ` + Version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := validateOutputFormat(); err != nil {
+			outputFormat = outputTable
+			exitWithError(codeInvalidArgs, "parsing flags", err)
+		}
+		readLegacyConfig()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// If no subcommand is specified, run the TUI
-		tui.RunTUI()
+		tui.RunTUI(managerOptions())
 	},
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	// Errors are reported by main so they can honour --output
+	rootCmd.SilenceErrors = true
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.minecraft-instance-manager.yaml)")
-	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is <config dir>/minecraft-instance/config.json; .yaml/.yml for YAML)")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "use this context instead of the current one (or set "+instance.ContextEnv+")")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "verbose output (or set "+instance.EnvPrefix+"VERBOSE)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "output format: table, json or yaml")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to all confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would change without touching the disk")
 
-	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	// Every config key can be overridden for a single run
+	for _, key := range instance.SettingKeys {
+		rootCmd.PersistentFlags().String(key, "", "override "+key+" for this run (or set "+instance.EnvName(key)+")")
+		settingFlags = append(settingFlags, rootCmd.PersistentFlags().Lookup(key))
+	}
 }
 
-var cfgFile string
-
-func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
-	} else {
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
+var (
+	cfgFile      string
+	contextName  string
+	verbose      bool
+	settingFlags []*pflag.Flag
+)
 
-		viper.AddConfigPath(home)
-		viper.SetConfigType("yaml")
-		viper.SetConfigName(".minecraft-instance-manager")
+// managerOptions collects --config, --context and the config key flags that were set
+// on the command line. The environment is read by the manager itself.
+func managerOptions() instance.Options {
+	opts := instance.Options{ConfigFile: cfgFile, Context: contextName, Flags: make(map[string]string)}
+	for _, flag := range settingFlags {
+		if flag.Changed {
+			opts.Flags[flag.Name] = flag.Value.String()
+		}
 	}
+	return opts
+}
 
-	viper.AutomaticEnv()
-
-	if err := viper.ReadInConfig(); err == nil && viper.GetBool("verbose") {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+// isVerbose reports whether --verbose or MCIM_VERBOSE is set, or else
+// verbose in the legacy config file.
+func isVerbose() bool {
+	if verbose {
+		return true
+	}
+	if v, ok := os.LookupEnv(instance.EnvPrefix + "VERBOSE"); ok {
+		enabled, _ := strconv.ParseBool(v)
+		return enabled
 	}
+	return legacyVerbose
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		if structuredOutput() {
+			exitWithError(codeInvalidArgs, "", err)
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats accepted by --output.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var (
+	outputFormat string
+	assumeYes    bool
+	dryRun       bool
+)
+
+// Stable error codes emitted in structured error output. Scripts may rely on
+// these; never change or reuse an existing code.
+const (
+	codeInvalidArgs     = "invalid_arguments"
+	codeManagerInit     = "manager_init_failed"
+	codeEmptyName       = "empty_instance_name"
+	codeReservedName    = "reserved_instance_name"
+	codeInstanceExists  = "instance_exists"
+	codeInstanceMissing = "instance_not_found"
+	codeInstanceActive  = "instance_active"
+	codeUnknownKey      = "unknown_config_key"
+	codeTrashMissing    = "trash_entry_not_found"
+	codeForeignSymlink  = "foreign_symlink"
+	codeInvalidConfig   = "invalid_config"
+	codeContextMissing  = "context_not_found"
+	codeContextExists   = "context_exists"
+	codeCancelled       = "cancelled"
+	codeNeedsConfirm    = "confirmation_required"
+	codeOperationFailed = "operation_failed"
+)
+
+// errorCodes maps Manager sentinel errors to their stable codes.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{instance.ErrEmptyName, codeEmptyName},
+	{instance.ErrReservedName, codeReservedName},
+	{instance.ErrInstanceExists, codeInstanceExists},
+	{instance.ErrInstanceNotFound, codeInstanceMissing},
+	{instance.ErrInstanceActive, codeInstanceActive},
+	{instance.ErrUnknownConfigKey, codeUnknownKey},
+	{instance.ErrTrashNotFound, codeTrashMissing},
+	{instance.ErrForeignSymlink, codeForeignSymlink},
+	{instance.ErrInvalidConfig, codeInvalidConfig},
+	{instance.ErrContextNotFound, codeContextMissing},
+	{instance.ErrContextExists, codeContextExists},
+}
+
+// errorOutput is the schema of structured errors written to stderr.
+type errorOutput struct {
+	Error errorDetail `json:"error" yaml:"error"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// resultOutput is the schema for commands that only report success.
+type resultOutput struct {
+	Action   string `json:"action" yaml:"action"`
+	Instance string `json:"instance,omitempty" yaml:"instance,omitempty"`
+	Status   string `json:"status" yaml:"status"`
+	TrashID  string `json:"trash_id,omitempty" yaml:"trash_id,omitempty"`
+}
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q: must be one of table, json, yaml", outputFormat)
+}
+
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// render writes v to stdout in the selected structured format, or calls table
+// for the human-readable default.
+func render(v any, table func()) {
+	if !structuredOutput() {
+		table()
+		return
+	}
+	if err := encode(os.Stdout, v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func encode(w io.Writer, v any) error {
+	if outputFormat == outputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// exitWithError reports err and exits. In table mode it prints the familiar
+// "Error <doing>: <err>" line; otherwise a structured error with a stable code
+// is written to stderr. fallback is used when err has no more specific code.
+func exitWithError(fallback, doing string, err error) {
+	if !structuredOutput() {
+		fmt.Fprintf(os.Stderr, "Error %s: %v\n", doing, err)
+		os.Exit(1)
+	}
+
+	code := fallback
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			code = ec.code
+			break
+		}
+	}
+	encode(os.Stderr, errorOutput{Error: errorDetail{Code: code, Message: err.Error()}})
+	os.Exit(1)
+}
+
+// newManager creates the instance manager or exits with a structured error.
+func newManager() *instance.Manager {
+	return newManagerWithOptions(managerOptions())
+}
+
+func newManagerWithOptions(opts instance.Options) *instance.Manager {
+	manager, err := instance.NewManagerWithOptions(opts)
+	if err != nil {
+		exitWithError(codeManagerInit, "initializing manager", err)
+	}
+	if isVerbose() {
+		fmt.Fprintln(os.Stderr, "Using config file:", manager.ConfigFile)
+		fmt.Fprintln(os.Stderr, "Using context:", manager.Context)
+	}
+	return manager
+}
+
+// dryRunOutput is the stable schema of every command run with --dry-run.
+type dryRunOutput struct {
+	DryRun bool           `json:"dry_run" yaml:"dry_run"`
+	Plan   *instance.Plan `json:"plan" yaml:"plan"`
+}
+
+// printPlan reports what a command would do under --dry-run. err is the
+// error returned while planning, so invalid operations fail the dry run too.
+func printPlan(doing string, plan *instance.Plan, err error) {
+	if err != nil {
+		exitWithError(codeOperationFailed, doing, err)
+	}
+	render(dryRunOutput{DryRun: true, Plan: plan}, func() {
+		fmt.Printf("Dry run: %s would perform %d step(s):\n", plan.Action, len(plan.Steps))
+		for _, step := range plan.Steps {
+			fmt.Printf("  - %s\n", step)
+		}
+	})
+}
+
+// confirm asks a yes/no question and reports the answer. With --yes it
+// returns true without asking; without a terminal to ask on it exits with
+// codeNeedsConfirm instead of blocking a script.
+func confirm(question string) bool {
+	if assumeYes {
+		return true
+	}
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		exitWithError(codeNeedsConfirm, "asking for confirmation",
+			fmt.Errorf("%s: stdin is not a terminal, pass --yes to confirm", question))
+	}
+
+	// Keep stdout clean for structured output
+	prompt := os.Stdout
+	if structuredOutput() {
+		prompt = os.Stderr
+	}
+	fmt.Fprintf(prompt, "%s (y/N): ", question)
+	var response string
+	fmt.Scanln(&response)
+	return response == "y" || response == "Y"
+}
+
+// printResult reports a successful action; lines are printed in table mode.
+func printResult(action, name string, lines ...string) {
+	render(resultOutput{Action: action, Instance: name, Status: "ok"}, func() {
+		fmt.Println(strings.Join(lines, "\n"))
+	})
+}
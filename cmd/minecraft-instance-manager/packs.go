@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/packs"
+	"github.com/spf13/cobra"
+)
+
+var packsKind string
+
+func init() {
+	packsCmd.PersistentFlags().StringVar(&packsKind, "kind", "", "only resource or shader packs")
+
+	packsCmd.AddCommand(packsListCmd)
+	packsCmd.AddCommand(packsEnableCmd)
+	packsCmd.AddCommand(packsDisableCmd)
+	packsCmd.AddCommand(packsDeleteCmd)
+	rootCmd.AddCommand(packsCmd)
+}
+
+// packsListOutput is the stable schema of `packs list`.
+type packsListOutput struct {
+	Instance string       `json:"instance" yaml:"instance"`
+	Packs    []packs.Pack `json:"packs" yaml:"packs"`
+}
+
+// packResultOutput is the stable schema of `packs enable|disable`.
+type packResultOutput struct {
+	Action   string     `json:"action" yaml:"action"`
+	Instance string     `json:"instance" yaml:"instance"`
+	Pack     packs.Pack `json:"pack" yaml:"pack"`
+	Status   string     `json:"status" yaml:"status"`
+}
+
+var packsCmd = &cobra.Command{
+	Use:   "packs",
+	Short: "List, enable, disable or delete resource and shader packs",
+	Long: `Manage the resource packs and shader packs of an instance. Packs are
+addressed by their file name; disabled packs get a .disabled suffix so the
+game skips them.
+
+Resource packs whose pack.mcmeta declares a pack format other than the one
+of the instance's Minecraft version are flagged with a warning.
+
+Examples:
+  packs list survival
+  packs disable survival Faithful.zip
+  packs enable survival BSL.zip --kind shader
+  packs delete survival "Old Pack.zip"`,
+}
+
+var packsListCmd = &cobra.Command{
+	Use:   "list <instance>",
+	Short: "List the packs of an instance",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		dir := instanceDir(manager, args[0], "listing packs")
+
+		list := []packs.Pack{}
+		for _, kind := range packKinds("listing packs") {
+			found, err := packs.List(dir, kind)
+			if err != nil {
+				exitWithError(codeOperationFailed, "listing packs", err)
+			}
+			list = append(list, found...)
+		}
+
+		render(packsListOutput{Instance: args[0], Packs: list}, func() {
+			if len(list) == 0 {
+				fmt.Printf("Instance '%s' has no packs\n", args[0])
+				return
+			}
+			var kind packs.Kind
+			for _, p := range list {
+				if p.Kind != kind {
+					kind = p.Kind
+					if kind == packs.KindShader {
+						fmt.Printf("Shader packs of %s:\n", args[0])
+					} else {
+						fmt.Printf("Resource packs of %s:\n", args[0])
+					}
+				}
+				state := ""
+				if !p.Enabled {
+					state = " (disabled)"
+				}
+				format := ""
+				if p.PackFormat > 0 {
+					format = fmt.Sprintf(" [format %d]", p.PackFormat)
+				}
+				fmt.Printf("  - %s%s%s\n", p.Name, format, state)
+				if p.Description != "" {
+					fmt.Printf("      %s\n", strings.ReplaceAll(p.Description, "\n", " "))
+				}
+				if p.Warning != "" {
+					fmt.Printf("      ⚠ %s\n", p.Warning)
+				}
+			}
+		})
+	},
+}
+
+var packsEnableCmd = &cobra.Command{
+	Use:   "enable <instance> <pack>",
+	Short: "Enable a disabled pack",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setPackEnabled(args[0], args[1], true)
+	},
+}
+
+var packsDisableCmd = &cobra.Command{
+	Use:   "disable <instance> <pack>",
+	Short: "Disable a pack without deleting it",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setPackEnabled(args[0], args[1], false)
+	},
+}
+
+var packsDeleteCmd = &cobra.Command{
+	Use:   "delete <instance> <pack>",
+	Short: "Move a pack to the trash",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		p := findPack(manager, args[0], args[1], "deleting pack")
+
+		if dryRun {
+			plan, err := manager.PlanTrashFile(args[0], p.Kind.Dir(), p.File)
+			printPlan("deleting pack", plan, err)
+			return
+		}
+
+		if !confirm(fmt.Sprintf("Are you sure you want to delete %s '%s' of '%s'? It will be moved to the trash.", p.Kind.Label(), p.File, args[0])) {
+			exitWithError(codeCancelled, "deleting pack", fmt.Errorf("cancelled"))
+		}
+
+		entry, err := manager.TrashFile(args[0], p.Kind.Dir(), p.File)
+		if err != nil {
+			exitWithError(codeOperationFailed, "deleting pack", err)
+		}
+		out := resultOutput{Action: "delete-" + string(p.Kind), Instance: args[0], Status: "ok", TrashID: entry.ID}
+		render(out, func() {
+			fmt.Printf("Deleted %s: %s\n", p.Kind.Label(), p.File)
+			fmt.Printf("Undo with: trash restore %s\n", entry.ID)
+		})
+	},
+}
+
+func setPackEnabled(instanceName, name string, enabled bool) {
+	action, doing := "disable", "disabling pack"
+	if enabled {
+		action, doing = "enable", "enabling pack"
+	}
+	manager := newManager()
+	p := findPack(manager, instanceName, name, doing)
+
+	if dryRun {
+		plan, err := packs.PlanSetEnabled(*p, enabled)
+		printPlan(doing, plan, err)
+		return
+	}
+
+	status := "unchanged"
+	if p.Enabled != enabled {
+		path, err := packs.SetEnabled(*p, enabled)
+		if err != nil {
+			exitWithError(codeOperationFailed, doing, err)
+		}
+		p, err = packs.Find(instanceDir(manager, instanceName, doing), p.Kind, p.Name)
+		if err != nil {
+			exitWithError(codeOperationFailed, doing, fmt.Errorf("%s: %w", path, err))
+		}
+		status = "ok"
+	}
+	render(packResultOutput{Action: action, Instance: instanceName, Pack: *p, Status: status}, func() {
+		switch {
+		case status == "unchanged" && enabled:
+			fmt.Printf("%s is already enabled\n", p.Name)
+		case status == "unchanged":
+			fmt.Printf("%s is already disabled\n", p.Name)
+		case enabled:
+			fmt.Printf("Enabled %s %s\n", p.Kind.Label(), p.Name)
+		default:
+			fmt.Printf("Disabled %s %s\n", p.Kind.Label(), p.Name)
+		}
+	})
+}
+
+// packKinds returns the kinds selected by --kind or exits.
+func packKinds(doing string) []packs.Kind {
+	switch packsKind {
+	case "":
+		return packs.Kinds
+	case "resource", string(packs.KindResource):
+		return []packs.Kind{packs.KindResource}
+	case "shader", string(packs.KindShader):
+		return []packs.Kind{packs.KindShader}
+	}
+	exitWithError(codeInvalidArgs, doing, fmt.Errorf("invalid --kind %q: use resource or shader", packsKind))
+	return nil
+}
+
+// findPack returns the pack called name of an existing instance or exits.
+// A name found among both kinds needs --kind.
+func findPack(manager *instance.Manager, instanceName, name, doing string) *packs.Pack {
+	dir := instanceDir(manager, instanceName, doing)
+	var found []*packs.Pack
+	for _, kind := range packKinds(doing) {
+		if p, err := packs.Find(dir, kind, name); err == nil {
+			found = append(found, p)
+		}
+	}
+	switch len(found) {
+	case 0:
+		exitWithError(codeOperationFailed, doing, fmt.Errorf("instance '%s' has no pack '%s'", instanceName, name))
+	case 1:
+		return found[0]
+	}
+	exitWithError(codeInvalidArgs, doing, fmt.Errorf("'%s' is both a resource and a shader pack; use --kind", name))
+	return nil
+}
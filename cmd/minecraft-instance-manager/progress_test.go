@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+)
+
+// captureStderr runs f with os.Stderr redirected to a pipe, as it is under
+// cron, and returns what was written to it.
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-done
+}
+
+func TestProgressPrinterSilent(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{"table on a pipe", outputTable},
+		{"json", outputJSON},
+		{"yaml", outputYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(f string) { outputFormat = f }(outputFormat)
+			outputFormat = tt.format
+
+			got := captureStderr(t, func() {
+				progress, finish := progressPrinter("Copying")
+				if progress != nil {
+					progress(instance.CopyProgress{FilesDone: 1, FilesTotal: 1, BytesDone: 10, BytesTotal: 10})
+				}
+				finish()
+			})
+			if got != "" {
+				t.Errorf("stderr = %q, want nothing", got)
+			}
+		})
+	}
+}
+
+func TestSnapshotAllWithoutTerminal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	world := filepath.Join(home, ".config", instance.AppFolderName, "instances", "survival", "saves", "World")
+	if err := os.MkdirAll(world, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(world, "level.dat"), []byte(strings.Repeat("x", 1<<16)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout.Close(); os.Stdout = stdout }()
+	// Flags keep their values between runs of rootCmd
+	t.Cleanup(func() { snapshotAll = false })
+
+	got := captureStderr(t, func() {
+		rootCmd.SetArgs([]string{"worlds", "snapshot", "--all"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("Execute: %v", err)
+		}
+	})
+	if got != "" {
+		t.Errorf("stderr = %q, want nothing", got)
+	}
+
+	snapshots, err := filepath.Glob(filepath.Join(home, ".config", instance.AppFolderName, "snapshots", "survival", "*"))
+	if err != nil || len(snapshots) == 0 {
+		t.Errorf("no snapshot was written (%v)", err)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"go.yaml.in/yaml/v3"
+)
+
+// captureStdout runs the command line args with os.Stdout redirected to a
+// pipe and returns what was written to it.
+func captureStdout(t *testing.T, args ...string) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	t.Cleanup(func() { outputFormat = outputTable })
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- b
+	}()
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Execute(%v): %v", args, err)
+	}
+	w.Close()
+	return <-done
+}
+
+// setupInstances creates the instances files in a fresh home directory,
+// by slash path relative to the instances folder.
+func setupInstances(t *testing.T, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	instances := filepath.Join(home, ".config", instance.AppFolderName, "instances")
+	for name, content := range files {
+		p := filepath.Join(instances, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return instances
+}
+
+// keys returns the sorted keys of a decoded JSON or YAML object.
+func keys(t *testing.T, v any) []string {
+	t.Helper()
+	m, ok := v.(map[string]any)
+	if !ok {
+		t.Fatalf("%v is not an object", v)
+	}
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// decodeBoth runs args with -o json and -o yaml and returns both results.
+func decodeBoth(t *testing.T, args ...string) map[string]map[string]any {
+	t.Helper()
+	out := make(map[string]map[string]any)
+	for _, format := range []string{outputJSON, outputYAML} {
+		data := captureStdout(t, append(args, "--output", format)...)
+		var v map[string]any
+		var err error
+		if format == outputJSON {
+			err = json.Unmarshal(data, &v)
+		} else {
+			err = yaml.Unmarshal(data, &v)
+		}
+		if err != nil {
+			t.Fatalf("%s output %q: %v", format, data, err)
+		}
+		out[format] = v
+	}
+	return out
+}
+
+func TestDuSchema(t *testing.T) {
+	setupInstances(t, map[string]string{
+		"small/mods/a.jar":            "12345",
+		"large/mods/a.jar":            "1234567890",
+		"large/saves/World/level.dat": "1234567890",
+		"large/options.txt":           "12345",
+	})
+
+	for format, v := range decodeBoth(t, "du") {
+		if got := keys(t, v); !reflect.DeepEqual(got, []string{"instances", "total_bytes"}) {
+			t.Errorf("%s: du keys = %v", format, got)
+		}
+		if fmt.Sprint(v["total_bytes"]) != "30" {
+			t.Errorf("%s: total_bytes = %v, want 30", format, v["total_bytes"])
+		}
+		list, _ := v["instances"].([]any)
+		if len(list) != 2 {
+			t.Fatalf("%s: instances = %v", format, v["instances"])
+		}
+		var names []string
+		for _, item := range list {
+			inst := item.(map[string]any)
+			names = append(names, inst["name"].(string))
+			if got := keys(t, inst); !reflect.DeepEqual(got, []string{"name", "sections", "size_bytes"}) {
+				t.Errorf("%s: instance keys = %v", format, got)
+			}
+			var sections []string
+			for _, s := range inst["sections"].([]any) {
+				section := s.(map[string]any)
+				sections = append(sections, section["name"].(string))
+				if got := keys(t, section); !reflect.DeepEqual(got, []string{"name", "size_bytes"}) {
+					t.Errorf("%s: section keys = %v", format, got)
+				}
+			}
+			if !reflect.DeepEqual(sections, instance.InstanceSections) {
+				t.Errorf("%s: sections = %v, want %v", format, sections, instance.InstanceSections)
+			}
+		}
+		if !reflect.DeepEqual(names, []string{"large", "small"}) {
+			t.Errorf("%s: instances = %v, want the largest first", format, names)
+		}
+	}
+}
+
+func TestDiffSchema(t *testing.T) {
+	instances := setupInstances(t, map[string]string{
+		"a/mods/both.jar":        "same",
+		"a/mods/changed.jar":     "old",
+		"a/mods/only-a.jar":      "a",
+		"a/config/jei/jei.cfg":   "a",
+		"b/mods/both.jar":        "same",
+		"b/mods/changed.jar":     "new",
+		"b/config/jei/jei.cfg":   "a",
+		"b/shaderpacks/only-b/x": "b",
+	})
+	// Dangling links are compared by their targets instead of failing
+	links := map[string]string{
+		"a/mods/broken.jar":  "/nowhere/a.jar",
+		"b/mods/broken.jar":  "/nowhere/b.jar",
+		"a/mods/missing.jar": "/nowhere/same.jar",
+		"b/mods/missing.jar": "/nowhere/same.jar",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(instances, filepath.FromSlash(name))); err != nil {
+			t.Skip("symlinks are not available:", err)
+		}
+	}
+
+	for format, v := range decodeBoth(t, "diff", "a", "b") {
+		if got := keys(t, v); !reflect.DeepEqual(got, []string{"a", "b", "identical", "sections"}) {
+			t.Errorf("%s: diff keys = %v", format, got)
+		}
+		if v["a"] != "a" || v["b"] != "b" || v["identical"] != false {
+			t.Errorf("%s: diff = %v", format, v)
+		}
+
+		want := map[string]map[string][]string{
+			"mods":        {"only_in_a": {"only-a.jar"}, "only_in_b": {}, "changed": {"broken.jar", "changed.jar"}},
+			"shaderpacks": {"only_in_a": {}, "only_in_b": {"only-b"}, "changed": {}},
+		}
+		var names []string
+		for _, s := range v["sections"].([]any) {
+			section := s.(map[string]any)
+			name := section["name"].(string)
+			names = append(names, name)
+			if got := keys(t, section); !reflect.DeepEqual(got, []string{"changed", "name", "only_in_a", "only_in_b"}) {
+				t.Errorf("%s: section keys = %v", format, got)
+			}
+			for _, field := range []string{"only_in_a", "only_in_b", "changed"} {
+				// Empty lists are encoded as lists, never null
+				items, ok := section[field].([]any)
+				if !ok {
+					t.Errorf("%s: %s.%s = %#v, want a list", format, name, field, section[field])
+				}
+				got := []string{}
+				for _, item := range items {
+					got = append(got, item.(string))
+				}
+				wantItems := want[name][field]
+				if wantItems == nil {
+					wantItems = []string{}
+				}
+				if !reflect.DeepEqual(got, wantItems) {
+					t.Errorf("%s: %s.%s = %v, want %v", format, name, field, got, wantItems)
+				}
+			}
+		}
+		if !reflect.DeepEqual(names, instance.InstanceSections) {
+			t.Errorf("%s: sections = %v, want %v", format, names, instance.InstanceSections)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchLimit    int
+	searchKind     string
+	searchInstance string
+	searchContent  bool
+)
+
+func init() {
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "show at most this many results (0 for all)")
+	searchCmd.Flags().StringVar(&searchKind, "kind", "", "only results of this kind (instance, mod, config, config-text, world, resourcepack, shaderpack)")
+	searchCmd.Flags().StringVar(&searchInstance, "instance", "", "only results from this instance")
+	searchCmd.Flags().BoolVar(&searchContent, "content", false, "also search the text inside config files")
+	rootCmd.AddCommand(searchCmd)
+}
+
+// searchOutput is the stable schema of `search`.
+type searchOutput struct {
+	Query   string          `json:"query" yaml:"query"`
+	Results []search.Result `json:"results" yaml:"results"`
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the contents of all instances",
+	Long: `Search every instance for files, mods, worlds and packs. Mods are also
+found by the ids, names and versions in their jar metadata, and worlds by
+their in-game names. Every word of the query must match, fuzzily, so
+"sod fab" finds sodium-fabric-0.5.8.jar.
+
+With --content the lines of config files are searched as well; there every
+word must appear as written.
+
+The metadata of jars is cached, so later searches only read jars that
+changed.
+
+Examples:
+  search sodium
+  search "jei client" --kind config
+  search maxEntities --content --instance survival`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := strings.Join(args, " ")
+		if searchKind != "" && !slices.Contains(search.Kinds, search.Kind(searchKind)) {
+			exitWithError(codeInvalidArgs, "searching", fmt.Errorf("unknown kind %q", searchKind))
+		}
+		if searchKind == string(search.KindConfigText) {
+			searchContent = true
+		}
+
+		manager := newManager()
+		instances, err := manager.ListInstances()
+		if err != nil {
+			exitWithError(codeOperationFailed, "searching", err)
+		}
+		if searchInstance != "" {
+			instanceDir(manager, searchInstance, "searching")
+		}
+
+		index := search.New(filepath.Join(manager.AppDir, search.CacheFileName), search.Options{ConfigText: searchContent})
+		if err := index.Update(context.Background(), instances, nil); err != nil {
+			exitWithError(codeOperationFailed, "searching", err)
+		}
+
+		results := []search.Result{}
+		for _, r := range index.Search(query, 0) {
+			if searchKind != "" && r.Kind != search.Kind(searchKind) ||
+				searchInstance != "" && r.Instance != searchInstance {
+				continue
+			}
+			results = append(results, r)
+		}
+		if searchLimit > 0 && len(results) > searchLimit {
+			results = results[:searchLimit]
+		}
+
+		render(searchOutput{Query: query, Results: results}, func() {
+			if len(results) == 0 {
+				fmt.Printf("Nothing matches '%s'\n", query)
+				return
+			}
+			for _, r := range results {
+				where := r.Path
+				if r.Kind == search.KindConfigText {
+					where = fmt.Sprintf("%s:%d", r.Path, r.Line)
+				}
+				fmt.Printf("  %-16s %-12s %s\n", r.Instance, r.Kind, where)
+				if r.Kind == search.KindConfigText {
+					fmt.Printf("      %s\n", r.Name)
+				} else if r.Detail != "" {
+					fmt.Printf("      %s\n", r.Detail)
+				}
+			}
+		})
+	},
+}
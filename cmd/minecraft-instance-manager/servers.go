@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/servers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serverHidden        bool
+	serverResourcePacks string
+	serversSyncAll      bool
+	serversKeepExtra    bool
+)
+
+func init() {
+	serversAddCmd.Flags().BoolVar(&serverHidden, "hidden", false, "hide the server from the multiplayer list")
+	serversAddCmd.Flags().StringVar(&serverResourcePacks, "resource-packs", servers.ResourcePacksPrompt, "server resource packs: prompt, enabled or disabled")
+	serversSyncCmd.Flags().BoolVar(&serversSyncAll, "all", false, "push the list to every other instance")
+	serversSyncCmd.Flags().BoolVar(&serversKeepExtra, "keep-extra", false, "keep servers the targets list in addition")
+
+	serversCmd.AddCommand(serversListCmd)
+	serversCmd.AddCommand(serversAddCmd)
+	serversCmd.AddCommand(serversRemoveCmd)
+	serversCmd.AddCommand(serversSyncCmd)
+	rootCmd.AddCommand(serversCmd)
+}
+
+// serversListOutput is the stable schema of `servers list`.
+type serversListOutput struct {
+	Instance string           `json:"instance" yaml:"instance"`
+	Servers  []servers.Server `json:"servers" yaml:"servers"`
+}
+
+// serverResultOutput is the stable schema of `servers add|remove`.
+type serverResultOutput struct {
+	Action   string         `json:"action" yaml:"action"`
+	Instance string         `json:"instance" yaml:"instance"`
+	Server   servers.Server `json:"server" yaml:"server"`
+	Status   string         `json:"status" yaml:"status"`
+}
+
+// serversSyncOutput is the stable schema of `servers sync`.
+type serversSyncOutput struct {
+	Source  string             `json:"source" yaml:"source"`
+	Servers int                `json:"servers" yaml:"servers"`
+	Targets []serversSyncEntry `json:"targets" yaml:"targets"`
+}
+
+// serversSyncEntry is the outcome of `servers sync` for one instance.
+type serversSyncEntry struct {
+	Instance string `json:"instance" yaml:"instance"`
+	Status   string `json:"status" yaml:"status"` // "updated" or "unchanged"
+}
+
+var serversCmd = &cobra.Command{
+	Use:   "servers",
+	Short: "List and edit the multiplayer server list of instances",
+	Long: `Edit servers.dat, the multiplayer server list of an instance, and keep it
+the same across instances with 'servers sync'.
+
+The game rewrites servers.dat from memory while it is running, so close
+it before editing.
+
+Examples:
+  servers list survival
+  servers add survival "Team Server" mc.example.org:25565
+  servers remove survival mc.example.org:25565
+  servers sync survival --all`,
+}
+
+var serversListCmd = &cobra.Command{
+	Use:   "list <instance>",
+	Short: "List the servers of an instance",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		list := loadServers(manager, args[0], "listing servers")
+		entries := list.Servers()
+
+		render(serversListOutput{Instance: args[0], Servers: entries}, func() {
+			if len(entries) == 0 {
+				fmt.Printf("Instance '%s' has no servers\n", args[0])
+				return
+			}
+			fmt.Printf("Servers of %s:\n", args[0])
+			for _, s := range entries {
+				flags := ""
+				if s.Hidden {
+					flags = " (hidden)"
+				}
+				fmt.Printf("  - %-24s %s%s\n", s.Name, s.Address, flags)
+			}
+		})
+	},
+}
+
+var serversAddCmd = &cobra.Command{
+	Use:   "add <instance> <name> <address>",
+	Short: "Add a server to an instance",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		list := loadServers(manager, args[0], "adding server")
+		server := servers.Server{Name: args[1], Address: args[2], Hidden: serverHidden, ResourcePacks: serverResourcePacks}
+		if err := list.Add(server); err != nil {
+			exitWithError(codeInvalidArgs, "adding server", err)
+		}
+
+		path := serversPath(manager, args[0])
+		if dryRun {
+			printPlan("adding server", serversPlan("add-server", path, "add "+server.Name+" ("+server.Address+")"), nil)
+			return
+		}
+		if err := list.Save(path); err != nil {
+			exitWithError(codeOperationFailed, "adding server", err)
+		}
+		render(serverResultOutput{Action: "add", Instance: args[0], Server: server, Status: "ok"}, func() {
+			fmt.Printf("Added server %s (%s) to %s\n", server.Name, server.Address, args[0])
+		})
+	},
+}
+
+var serversRemoveCmd = &cobra.Command{
+	Use:   "remove <instance> <name-or-address>",
+	Short: "Remove a server from an instance",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		list := loadServers(manager, args[0], "removing server")
+		removed, err := list.Remove(args[1])
+		if err != nil {
+			exitWithError(codeOperationFailed, "removing server", err)
+		}
+
+		path := serversPath(manager, args[0])
+		if dryRun {
+			printPlan("removing server", serversPlan("remove-server", path, "remove "+removed.Name+" ("+removed.Address+")"), nil)
+			return
+		}
+		if err := list.Save(path); err != nil {
+			exitWithError(codeOperationFailed, "removing server", err)
+		}
+		render(serverResultOutput{Action: "remove", Instance: args[0], Server: removed, Status: "ok"}, func() {
+			fmt.Printf("Removed server %s (%s) from %s\n", removed.Name, removed.Address, args[0])
+		})
+	},
+}
+
+var serversSyncCmd = &cobra.Command{
+	Use:   "sync <instance> [target-instance...]",
+	Short: "Copy the server list of an instance to other instances",
+	Long: `Make the server list of the target instances, or with --all of every other
+instance, the same as the list of <instance>. Servers the targets have in
+addition are removed unless --keep-extra is given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		source := args[0]
+		canonical := loadServers(manager, source, "syncing servers")
+
+		targets := args[1:]
+		if serversSyncAll {
+			instances, err := manager.ListInstances()
+			if err != nil {
+				exitWithError(codeOperationFailed, "syncing servers", err)
+			}
+			targets = nil
+			for _, inst := range instances {
+				if inst.Name != source {
+					targets = append(targets, inst.Name)
+				}
+			}
+		}
+		if len(targets) == 0 {
+			exitWithError(codeInvalidArgs, "syncing servers", fmt.Errorf("name target instances or use --all"))
+		}
+
+		lists := make(map[string]*servers.List, len(targets))
+		plan := &instance.Plan{Action: "sync-servers"}
+		for _, name := range targets {
+			list := loadServers(manager, name, "syncing servers")
+			if list.Sync(canonical, serversKeepExtra) {
+				lists[name] = list
+				plan.Steps = append(plan.Steps, instance.Step{Op: instance.OpWrite, Path: serversPath(manager, name), Detail: "server list of " + source})
+			}
+		}
+
+		if dryRun {
+			printPlan("syncing servers", plan, nil)
+			return
+		}
+		if len(lists) > 0 && !serversKeepExtra &&
+			!confirm(fmt.Sprintf("Replace the server list of %d instance(s) with the list of '%s'?", len(lists), source)) {
+			exitWithError(codeCancelled, "syncing servers", fmt.Errorf("cancelled"))
+		}
+
+		out := serversSyncOutput{Source: source, Servers: len(canonical.Servers())}
+		for _, name := range targets {
+			status := "unchanged"
+			if list, ok := lists[name]; ok {
+				if err := list.Save(serversPath(manager, name)); err != nil {
+					exitWithError(codeOperationFailed, "syncing servers", fmt.Errorf("%s: %w", name, err))
+				}
+				status = "updated"
+			}
+			out.Targets = append(out.Targets, serversSyncEntry{Instance: name, Status: status})
+		}
+		render(out, func() {
+			for _, t := range out.Targets {
+				fmt.Printf("  - %-24s %s\n", t.Instance, t.Status)
+			}
+			fmt.Printf("Synced %d server(s) from %s to %d instance(s)\n", out.Servers, source, len(lists))
+		})
+	},
+}
+
+// serversPath returns the servers.dat of an instance.
+func serversPath(manager *instance.Manager, name string) string {
+	return servers.Path(manager.InstancePath(name))
+}
+
+// loadServers reads the server list of an existing instance or exits.
+func loadServers(manager *instance.Manager, name, doing string) *servers.List {
+	list, err := servers.Load(servers.Path(instanceDir(manager, name, doing)))
+	if err != nil {
+		exitWithError(codeOperationFailed, doing, err)
+	}
+	return list
+}
+
+// serversPlan is the dry run of a change to one servers.dat.
+func serversPlan(action, path, detail string) *instance.Plan {
+	return &instance.Plan{Action: action, Steps: []instance.Step{
+		{Op: instance.OpWrite, Path: path, Detail: detail},
+	}}
+}
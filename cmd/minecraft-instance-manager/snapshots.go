@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotAll       bool
+	snapshotForce     bool
+	snapshotRetention = worlds.DefaultRetention
+	restoreAs         string
+)
+
+func init() {
+	worldsSnapshotCmd.Flags().BoolVar(&snapshotAll, "all", false, "snapshot every world of every instance")
+	worldsSnapshotCmd.Flags().BoolVar(&snapshotForce, "force", false, "also snapshot worlds that are open in the game")
+	worldsSnapshotCmd.Flags().IntVar(&snapshotRetention.Hourly, "keep-hourly", worlds.DefaultRetention.Hourly, "hourly snapshots to keep per world")
+	worldsSnapshotCmd.Flags().IntVar(&snapshotRetention.Daily, "keep-daily", worlds.DefaultRetention.Daily, "daily snapshots to keep per world")
+	worldsSnapshotCmd.Flags().IntVar(&snapshotRetention.Weekly, "keep-weekly", worlds.DefaultRetention.Weekly, "weekly snapshots to keep per world")
+	worldsRestoreCmd.Flags().StringVar(&restoreAs, "as", "", "folder name of the restored world (default: the original name)")
+
+	worldsCmd.AddCommand(worldsSnapshotCmd)
+	worldsCmd.AddCommand(worldsSnapshotsCmd)
+	worldsCmd.AddCommand(worldsRestoreCmd)
+}
+
+// snapshotOutput is the stable schema of `worlds snapshot`.
+type snapshotOutput struct {
+	Created   []worlds.Snapshot `json:"created" yaml:"created"`
+	Skipped   []snapshotSkipped `json:"skipped" yaml:"skipped"`
+	Pruned    []worlds.Snapshot `json:"pruned" yaml:"pruned"`
+	Retention worlds.Retention  `json:"retention" yaml:"retention"`
+}
+
+// snapshotSkipped is a world that `worlds snapshot` did not save, and why.
+type snapshotSkipped struct {
+	Instance string `json:"instance" yaml:"instance"`
+	World    string `json:"world" yaml:"world"`
+	Reason   string `json:"reason" yaml:"reason"`
+}
+
+// snapshotsListOutput is the stable schema of `worlds snapshots`.
+type snapshotsListOutput struct {
+	Instance  string            `json:"instance" yaml:"instance"`
+	Snapshots []worlds.Snapshot `json:"snapshots" yaml:"snapshots"`
+}
+
+// snapshotTarget is a world `worlds snapshot` saves.
+type snapshotTarget struct {
+	instance string
+	path     string
+}
+
+var worldsSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [<instance> [world]]",
+	Short: "Save compressed, timestamped snapshots of worlds",
+	Long: `Save a compressed snapshot of a world, of every world of an instance, or
+with --all of every world of every instance. Snapshots are kept per instance
+in the snapshot store, outside the instances themselves.
+
+Worlds that are open in the game (their session.lock is held) are skipped
+unless --force is given. After saving, older snapshots of each world are
+pruned: the newest snapshot of each of the last --keep-hourly hours,
+--keep-daily days and --keep-weekly weeks is kept.
+
+The --all mode is meant for a cron job or systemd timer, e.g.:
+  0 * * * *  minecraft-instance-manager worlds snapshot --all`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if snapshotAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		targets := snapshotTargets(manager, args)
+		now := time.Now()
+
+		if dryRun {
+			plan := &instance.Plan{Action: "snapshot-worlds"}
+			for _, t := range targets {
+				p, err := snapshotStore(manager, t.instance).PlanCreate(t.path, now, snapshotRetention)
+				if err != nil {
+					exitWithError(codeOperationFailed, "taking snapshots", err)
+				}
+				plan.Steps = append(plan.Steps, p.Steps...)
+			}
+			printPlan("taking snapshots", plan, nil)
+			return
+		}
+
+		// Ctrl+C stops the current snapshot and removes the partial archive
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		out := snapshotOutput{Created: []worlds.Snapshot{}, Skipped: []snapshotSkipped{}, Pruned: []worlds.Snapshot{}, Retention: snapshotRetention}
+		failed := 0
+		for _, t := range targets {
+			world := filepath.Base(t.path)
+			if inUse, err := worlds.InUse(t.path); err == nil && inUse && !snapshotForce {
+				out.Skipped = append(out.Skipped, snapshotSkipped{t.instance, world, "in use"})
+				continue
+			}
+
+			store := snapshotStore(manager, t.instance)
+			progress, finish := progressPrinter("Saving " + t.instance + "/" + world)
+			snap, err := store.Create(ctx, t.path, now, progress)
+			finish()
+			if ctx.Err() != nil {
+				exitWithError(codeCancelled, "taking snapshots", ctx.Err())
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error taking snapshot of %s/%s: %v\n", t.instance, world, err)
+				out.Skipped = append(out.Skipped, snapshotSkipped{t.instance, world, err.Error()})
+				failed++
+				continue
+			}
+			out.Created = append(out.Created, *snap)
+
+			pruned, err := store.Prune(world, snapshotRetention)
+			out.Pruned = append(out.Pruned, pruned...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error pruning snapshots of %s/%s: %v\n", t.instance, world, err)
+				failed++
+			}
+		}
+
+		render(out, func() {
+			for _, snap := range out.Created {
+				fmt.Printf("Saved %s (%s)\n", snap.ID, formatBytes(snap.SizeBytes))
+			}
+			for _, s := range out.Skipped {
+				fmt.Printf("Skipped %s/%s: %s\n", s.Instance, s.World, s.Reason)
+			}
+			if len(out.Pruned) > 0 {
+				fmt.Printf("Pruned %d expired snapshot(s)\n", len(out.Pruned))
+			}
+			if len(targets) == 0 {
+				fmt.Println("No worlds to snapshot")
+			}
+		})
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var worldsSnapshotsCmd = &cobra.Command{
+	Use:   "snapshots <instance> [world]",
+	Short: "List the snapshots of an instance, newest first",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		name := args[0]
+		if _, err := os.Stat(manager.SnapshotPath(name)); err != nil {
+			instanceDir(manager, name, "listing snapshots")
+		}
+		world := ""
+		if len(args) == 2 {
+			world = args[1]
+		}
+
+		snaps, err := snapshotStore(manager, name).List(world)
+		if err != nil {
+			exitWithError(codeOperationFailed, "listing snapshots", err)
+		}
+		if snaps == nil {
+			snaps = []worlds.Snapshot{}
+		}
+
+		render(snapshotsListOutput{Instance: name, Snapshots: snaps}, func() {
+			if len(snaps) == 0 {
+				fmt.Printf("Instance '%s' has no snapshots\n", name)
+				return
+			}
+			fmt.Printf("Snapshots of %s:\n", name)
+			for _, snap := range snaps {
+				fmt.Printf("  - %-48s %s  %10s\n", snap.ID, snap.Created.Local().Format("2006-01-02 15:04"), formatBytes(snap.SizeBytes))
+			}
+		})
+	},
+}
+
+var worldsRestoreCmd = &cobra.Command{
+	Use:   "restore <snapshot>",
+	Short: "Restore a world from a snapshot",
+	Long: `Restore a world from a snapshot into its instance. The snapshot is named by
+its ID as shown by 'worlds snapshots', <instance>/<world>/<timestamp>.
+An existing world is never overwritten: delete it first or restore the
+snapshot under another folder name with --as.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		name, world, created, err := worlds.ParseSnapshotID(args[0])
+		if err != nil {
+			exitWithError(codeInvalidArgs, "restoring snapshot", err)
+		}
+		savesDir := worlds.SavesDir(instanceDir(manager, name, "restoring snapshot"))
+		snap, err := snapshotStore(manager, name).Get(world, created)
+		if err != nil {
+			exitWithError(codeOperationFailed, "restoring snapshot", err)
+		}
+
+		if dryRun {
+			plan, err := worlds.PlanRestore(*snap, savesDir, restoreAs)
+			printPlan("restoring snapshot", plan, err)
+			return
+		}
+
+		// Ctrl+C stops the restore and removes the partial world
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		progress, finish := progressPrinter("Restoring")
+		dst, err := worlds.Restore(ctx, *snap, savesDir, restoreAs, progress)
+		finish()
+		if err != nil {
+			if ctx.Err() != nil {
+				exitWithError(codeCancelled, "restoring snapshot", err)
+			}
+			exitWithError(codeOperationFailed, "restoring snapshot", err)
+		}
+
+		out := worldResultOutput{Action: "restore", From: snap.Path, To: dst, Status: "ok"}
+		render(out, func() {
+			fmt.Printf("Restored %s to %s\n", snap.ID, dst)
+		})
+	},
+}
+
+// snapshotStore returns the snapshot store of the named instance.
+func snapshotStore(manager *instance.Manager, name string) worlds.Store {
+	return worlds.Store{Instance: name, Dir: manager.SnapshotPath(name)}
+}
+
+// snapshotTargets resolves the arguments of `worlds snapshot` to worlds.
+func snapshotTargets(manager *instance.Manager, args []string) []snapshotTarget {
+	var names []string
+	if snapshotAll {
+		instances, err := manager.ListInstances()
+		if err != nil {
+			exitWithError(codeOperationFailed, "taking snapshots", err)
+		}
+		for _, inst := range instances {
+			names = append(names, inst.Name)
+		}
+	} else if len(args) == 2 {
+		return []snapshotTarget{{args[0], worldPath(manager, args[0], args[1], "taking snapshot")}}
+	} else {
+		names = args
+	}
+
+	var targets []snapshotTarget
+	for _, name := range names {
+		list, err := worlds.List(worlds.SavesDir(instanceDir(manager, name, "taking snapshots")))
+		if err != nil {
+			exitWithError(codeOperationFailed, "taking snapshots", err)
+		}
+		for _, w := range list {
+			targets = append(targets, snapshotTarget{name, w.Path})
+		}
+	}
+	return targets
+}
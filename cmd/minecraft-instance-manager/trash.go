@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashEmptyOlderThan time.Duration
+	trashEmptyExpired   bool
+)
+
+func init() {
+	trashEmptyCmd.Flags().DurationVar(&trashEmptyOlderThan, "older-than", 0, "only remove entries deleted longer ago than this (e.g. 168h)")
+	trashEmptyCmd.Flags().BoolVar(&trashEmptyExpired, "expired", false, "only remove entries past the retention period")
+
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
+}
+
+// trashListOutput is the stable schema of `trash list` and `trash empty`.
+type trashListOutput struct {
+	Entries []instance.TrashEntry `json:"entries" yaml:"entries"`
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or empty deleted instances and files",
+	Long: `Deleted instances, mods, configs and saves are moved to the trash first.
+Entries older than trash-retention-days are purged automatically.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trash entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+
+		entries, err := manager.ListTrash()
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading trash", err)
+		}
+
+		render(trashListOutput{Entries: entries}, func() {
+			if len(entries) == 0 {
+				fmt.Println("Trash is empty")
+				return
+			}
+			expires := manager.TrashRetention()
+			for _, e := range entries {
+				what := e.Name
+				if e.Kind != "instance" {
+					what = fmt.Sprintf("%s/%s", e.Instance, e.Name)
+				}
+				fmt.Printf("  %s\n    %-8s %s (%s), deleted %s, expires %s\n",
+					e.ID, e.Kind, what, formatBytes(e.SizeBytes),
+					e.DeletedAt.Local().Format("2006-01-02 15:04"),
+					e.DeletedAt.Add(expires).Local().Format("2006-01-02"))
+			}
+		})
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <trash-id>",
+	Short: "Restore a trash entry to its original location",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+
+		if dryRun {
+			plan, err := manager.PlanRestoreTrash(args[0])
+			printPlan("restoring from trash", plan, err)
+			return
+		}
+
+		entry, err := manager.RestoreTrash(args[0])
+		if err != nil {
+			exitWithError(codeOperationFailed, "restoring from trash", err)
+		}
+
+		render(entry, func() {
+			fmt.Printf("Restored %s %s to %s\n", entry.Kind, entry.Name, entry.Origin)
+		})
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trash entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+
+		olderThan := trashEmptyOlderThan
+		if trashEmptyExpired {
+			olderThan = manager.TrashRetention()
+		}
+
+		if dryRun {
+			plan, err := manager.PlanEmptyTrash(olderThan)
+			printPlan("emptying trash", plan, err)
+			return
+		}
+
+		if !confirm("Permanently delete the selected trash entries? This cannot be undone.") {
+			exitWithError(codeCancelled, "emptying trash", fmt.Errorf("cancelled"))
+		}
+
+		removed, err := manager.EmptyTrash(olderThan)
+		if err != nil {
+			exitWithError(codeOperationFailed, "emptying trash", err)
+		}
+
+		render(trashListOutput{Entries: removed}, func() {
+			fmt.Printf("Permanently deleted %d trash entries\n", len(removed))
+		})
+	},
+}
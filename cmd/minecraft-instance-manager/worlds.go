@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
+	"github.com/spf13/cobra"
+)
+
+var (
+	worldsTransferAs string
+	worldsRenameName string
+)
+
+func init() {
+	worldsCopyCmd.Flags().StringVar(&worldsTransferAs, "as", "", "folder name in the target instance (default: the same name)")
+	worldsMoveCmd.Flags().StringVar(&worldsTransferAs, "as", "", "folder name in the target instance (default: the same name)")
+	worldsRenameCmd.Flags().StringVar(&worldsRenameName, "name", "", "also set the display name stored in level.dat")
+
+	worldsCmd.AddCommand(worldsListCmd)
+	worldsCmd.AddCommand(worldsInfoCmd)
+	worldsCmd.AddCommand(worldsCopyCmd)
+	worldsCmd.AddCommand(worldsMoveCmd)
+	worldsCmd.AddCommand(worldsRenameCmd)
+	worldsCmd.AddCommand(worldsDeleteCmd)
+	rootCmd.AddCommand(worldsCmd)
+}
+
+// worldsListOutput is the stable schema of `worlds list`.
+type worldsListOutput struct {
+	Instance string         `json:"instance" yaml:"instance"`
+	Worlds   []worlds.World `json:"worlds" yaml:"worlds"`
+}
+
+// worldResultOutput is the stable schema of `worlds copy|move|rename`.
+type worldResultOutput struct {
+	Action string `json:"action" yaml:"action"`
+	From   string `json:"from" yaml:"from"`
+	To     string `json:"to" yaml:"to"`
+	Status string `json:"status" yaml:"status"`
+}
+
+var worldsCmd = &cobra.Command{
+	Use:   "worlds",
+	Short: "List, inspect, copy, move, rename or delete worlds",
+	Long: `Manage the worlds (saves) of an instance. Worlds are addressed by their
+folder name inside the instance's saves directory.
+
+Examples:
+  worlds list survival
+  worlds info survival "New World"
+  worlds copy survival "New World" creative --as "New World (copy)"
+  worlds delete survival "Old World"`,
+}
+
+var worldsListCmd = &cobra.Command{
+	Use:   "list <instance>",
+	Short: "List the worlds of an instance, most recently played first",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		dir := instanceDir(manager, args[0], "listing worlds")
+
+		list, err := worlds.List(worlds.SavesDir(dir))
+		if err != nil {
+			exitWithError(codeOperationFailed, "listing worlds", err)
+		}
+		if list == nil {
+			list = []worlds.World{}
+		}
+
+		render(worldsListOutput{Instance: args[0], Worlds: list}, func() {
+			if len(list) == 0 {
+				fmt.Printf("Instance '%s' has no worlds\n", args[0])
+				return
+			}
+			fmt.Printf("Worlds of %s:\n", args[0])
+			for _, w := range list {
+				if w.Error != "" {
+					fmt.Printf("  - %-24s (unreadable: %s)\n", w.Folder, w.Error)
+					continue
+				}
+				fmt.Printf("  - %-24s %-10s %-8s %10s  last played %s\n",
+					w.Folder, w.GameMode, w.Version, formatBytes(w.SizeBytes), formatLastPlayed(w))
+			}
+		})
+	},
+}
+
+var worldsInfoCmd = &cobra.Command{
+	Use:   "info <instance> <world>",
+	Short: "Show the level.dat details of a world",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		path := worldPath(manager, args[0], args[1], "reading world")
+
+		w, err := worlds.Read(path)
+		if err != nil {
+			exitWithError(codeOperationFailed, "reading world", err)
+		}
+
+		render(w, func() {
+			fmt.Printf("World: %s\n", w.Folder)
+			fmt.Printf("  Path:        %s\n", w.Path)
+			if w.Error != "" {
+				fmt.Printf("  Error:       %s\n", w.Error)
+				fmt.Printf("  Size:        %s\n", formatBytes(w.SizeBytes))
+				return
+			}
+			mode := w.GameMode
+			if w.Hardcore {
+				mode += " (hardcore)"
+			}
+			fmt.Printf("  Name:        %s\n", w.Name)
+			fmt.Printf("  Game mode:   %s\n", mode)
+			fmt.Printf("  Seed:        %d\n", w.Seed)
+			if w.Version != "" {
+				fmt.Printf("  Version:     %s (data version %d)\n", w.Version, w.DataVersion)
+			}
+			fmt.Printf("  Last played: %s\n", formatLastPlayed(*w))
+			fmt.Printf("  Size:        %s\n", formatBytes(w.SizeBytes))
+		})
+	},
+}
+
+var worldsCopyCmd = &cobra.Command{
+	Use:   "copy <instance> <world> <target-instance>",
+	Short: "Copy a world to another instance",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		transferWorld(worldTransfer{"copy", "copying world", "Copying", "Copied"}, args, worlds.PlanCopy, worlds.Copy)
+	},
+}
+
+var worldsMoveCmd = &cobra.Command{
+	Use:   "move <instance> <world> <target-instance>",
+	Short: "Move a world to another instance",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		transferWorld(worldTransfer{"move", "moving world", "Moving", "Moved"}, args, worlds.PlanMove, worlds.Move)
+	},
+}
+
+// worldTransfer holds the wording of `worlds copy` or `worlds move`.
+type worldTransfer struct {
+	action, doing, progress, done string
+}
+
+// transferWorld implements `worlds copy` and `worlds move`.
+func transferWorld(t worldTransfer, args []string,
+	plan func(src, dstSavesDir, folder string) (*instance.Plan, error),
+	run func(ctx context.Context, src, dstSavesDir, folder string, progress instance.ProgressFunc) (string, error)) {
+	manager := newManager()
+	doing := t.doing
+	src := worldPath(manager, args[0], args[1], doing)
+	dstSaves := worlds.SavesDir(instanceDir(manager, args[2], doing))
+
+	if dryRun {
+		p, err := plan(src, dstSaves, worldsTransferAs)
+		printPlan(doing, p, err)
+		return
+	}
+
+	// Ctrl+C stops the copy and removes the partial world
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	progress, finish := progressPrinter(t.progress)
+	dst, err := run(ctx, src, dstSaves, worldsTransferAs, progress)
+	finish()
+	if err != nil {
+		if ctx.Err() != nil {
+			exitWithError(codeCancelled, doing, err)
+		}
+		exitWithError(codeOperationFailed, doing, err)
+	}
+
+	out := worldResultOutput{Action: t.action, From: src, To: dst, Status: "ok"}
+	render(out, func() {
+		fmt.Printf("%s world %s to %s\n", t.done, args[1], dst)
+	})
+}
+
+var worldsRenameCmd = &cobra.Command{
+	Use:   "rename <instance> <world> [new-folder-name]",
+	Short: "Rename the folder and/or display name of a world",
+	Long: `Rename the folder of a world, and with --name change the display name shown
+in the game's world list. Either may be given alone:
+
+  worlds rename survival "New World" main-base
+  worlds rename survival main-base --name "Main Base"`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 2 && worldsRenameName == "" {
+			exitWithError(codeInvalidArgs, "renaming world", fmt.Errorf("give a new folder name, --name, or both"))
+		}
+		manager := newManager()
+		src := worldPath(manager, args[0], args[1], "renaming world")
+
+		if dryRun {
+			plan := &instance.Plan{Action: "rename-world"}
+			if worldsRenameName != "" {
+				p, err := worlds.PlanSetName(src, worldsRenameName)
+				if err != nil {
+					printPlan("renaming world", nil, err)
+				}
+				plan.Steps = append(plan.Steps, p.Steps...)
+			}
+			if len(args) == 3 {
+				p, err := worlds.PlanRename(src, args[2])
+				if err != nil {
+					printPlan("renaming world", nil, err)
+				}
+				plan.Steps = append(plan.Steps, p.Steps...)
+			}
+			printPlan("renaming world", plan, nil)
+			return
+		}
+
+		// Check the folder name before touching level.dat
+		if len(args) == 3 {
+			if _, err := worlds.PlanRename(src, args[2]); err != nil {
+				exitWithError(codeOperationFailed, "renaming world", err)
+			}
+		}
+		if worldsRenameName != "" {
+			if err := worlds.SetName(src, worldsRenameName); err != nil {
+				exitWithError(codeOperationFailed, "renaming world", err)
+			}
+		}
+		dst := src
+		if len(args) == 3 {
+			var err error
+			if dst, err = worlds.Rename(src, args[2]); err != nil {
+				exitWithError(codeOperationFailed, "renaming world", err)
+			}
+		}
+		render(worldResultOutput{Action: "rename", From: src, To: dst, Status: "ok"}, func() {
+			if len(args) == 3 {
+				fmt.Printf("Renamed world %s -> %s\n", args[1], args[2])
+			}
+			if worldsRenameName != "" {
+				fmt.Printf("Set display name of %s to %q\n", filepath.Base(dst), worldsRenameName)
+			}
+		})
+	},
+}
+
+var worldsDeleteCmd = &cobra.Command{
+	Use:   "delete <instance> <world>",
+	Short: "Move a world to the trash",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := newManager()
+		worldPath(manager, args[0], args[1], "deleting world")
+
+		if dryRun {
+			plan, err := manager.PlanTrashFile(args[0], worlds.SavesDirName, args[1])
+			printPlan("deleting world", plan, err)
+			return
+		}
+
+		if !confirm(fmt.Sprintf("Are you sure you want to delete world '%s' of '%s'? It will be moved to the trash.", args[1], args[0])) {
+			exitWithError(codeCancelled, "deleting world", fmt.Errorf("cancelled"))
+		}
+
+		entry, err := manager.TrashFile(args[0], worlds.SavesDirName, args[1])
+		if err != nil {
+			exitWithError(codeOperationFailed, "deleting world", err)
+		}
+		out := resultOutput{Action: "delete-world", Instance: args[0], Status: "ok", TrashID: entry.ID}
+		render(out, func() {
+			fmt.Printf("Deleted world: %s\n", args[1])
+			fmt.Printf("Undo with: trash restore %s\n", entry.ID)
+		})
+	},
+}
+
+// instanceDir returns the directory of an existing instance or exits.
+func instanceDir(manager *instance.Manager, name, doing string) string {
+	dir, err := manager.LookupInstance(name)
+	if err != nil {
+		exitWithError(codeOperationFailed, doing, err)
+	}
+	return dir
+}
+
+// worldPath returns the directory of an existing world or exits.
+func worldPath(manager *instance.Manager, instanceName, world, doing string) string {
+	path := filepath.Join(worlds.SavesDir(instanceDir(manager, instanceName, doing)), world)
+	if world != filepath.Base(world) {
+		exitWithError(codeInvalidArgs, doing, fmt.Errorf("invalid world name %q", world))
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		exitWithError(codeOperationFailed, doing, fmt.Errorf("instance '%s' has no world '%s'", instanceName, world))
+	}
+	return path
+}
+
+func formatLastPlayed(w worlds.World) string {
+	if w.LastPlayed.IsZero() {
+		return "never"
+	}
+	return w.LastPlayed.Local().Format("2006-01-02 15:04")
+}
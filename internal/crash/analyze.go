@@ -0,0 +1,243 @@
+package crash
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/mods"
+)
+
+// Culprit is an installed mod jar that code in the crash's stack traces
+// belongs to.
+type Culprit struct {
+	Jar    string     `json:"jar" yaml:"jar"`
+	Mods   []mods.Mod `json:"mods" yaml:"mods"`
+	Frames int        `json:"frames" yaml:"frames"` // stack frames mapped to the jar
+	// Suspected is set if the game named one of the jar's mods as suspect
+	Suspected bool `json:"suspected" yaml:"suspected"`
+}
+
+// Label names the culprit's mods, or its jar if it declares none.
+func (c Culprit) Label() string {
+	var names []string
+	for _, m := range c.Mods {
+		s := strings.TrimSpace(m.ID + " " + m.Version)
+		if m.Name != "" && m.Name != m.ID {
+			s = m.Name + " (" + s + ")"
+		}
+		names = append(names, s)
+	}
+	if len(names) == 0 {
+		return c.Jar
+	}
+	return strings.Join(names, ", ")
+}
+
+// Analysis is a report with the culprits found among the installed mods and
+// a short diagnosis.
+type Analysis struct {
+	*Report   `yaml:",inline"`
+	Culprits  []Culprit `json:"culprits" yaml:"culprits"`
+	Diagnosis []string  `json:"diagnosis" yaml:"diagnosis"`
+}
+
+// platformIDs are mod ids of the game and loaders; frames of theirs say
+// nothing about which mod is at fault.
+var platformIDs = map[string]bool{
+	"minecraft": true, "forge": true, "neoforge": true, "fml": true,
+	"fabricloader": true, "quilt_loader": true, "java": true, "mixinextras": true,
+}
+
+var (
+	// "~[create-1.20.1-0.5.1.f.jar%23123!/:0.5.1.f]" after a Forge frame
+	jarHintPattern = regexp.MustCompile(`[~ ]\[([^\]\s%!/]+\.jar)`)
+	// "handler$zza000$create$onRender" and the like, named by Mixin after
+	// the mod whose mixin added the method
+	mixinMethodPattern = regexp.MustCompile(`^(?:handler|redirect|modify\w*|wrapOperation|wrapWithCondition|localvar|constant)\$\w+?\$(\w+)\$`)
+)
+
+// frame is the part of a stack frame used to map it to a jar.
+type frame struct {
+	class  string // fully qualified
+	method string
+	modID  string // from a "TRANSFORMER/create@0.5.1.f/" module prefix
+	jar    string // from a "~[name.jar]" suffix
+}
+
+func parseFrame(s string) frame {
+	var f frame
+	if m := jarHintPattern.FindStringSubmatch(s); m != nil {
+		f.jar = m[1]
+	}
+	call, _, _ := strings.Cut(s, "(")
+	if i := strings.LastIndex(call, "/"); i >= 0 {
+		module := call[:i]
+		if j := strings.LastIndex(module, "/"); j >= 0 {
+			module = module[j+1:]
+		}
+		f.modID, _, _ = strings.Cut(module, "@")
+		call = call[i+1:]
+	}
+	if i := strings.LastIndex(call, "."); i >= 0 {
+		f.class, f.method = call[:i], call[i+1:]
+	}
+	return f
+}
+
+// Analyze maps the stack frames of r to the mod jars in modsDir, by the jar
+// and module names Forge adds to frames, the mod ids Mixin puts into the
+// names of injected methods, and the Java packages of each jar, and
+// diagnoses the crash.
+func Analyze(r *Report, modsDir string) (*Analysis, error) {
+	jars, err := mods.List(modsDir)
+	if err != nil {
+		return nil, err
+	}
+	byFile := make(map[string]int)
+	byID := make(map[string]int)
+	byPackage := make(map[string]int) // -1 if several jars have the package
+	for i, jar := range jars {
+		byFile[jar.File] = i
+		for _, id := range jar.IDs() {
+			byID[id] = i
+		}
+		packages, err := mods.Packages(jar.Path)
+		if err != nil {
+			continue
+		}
+		for _, p := range packages {
+			if _, ok := byPackage[p]; ok {
+				byPackage[p] = -1
+			} else {
+				byPackage[p] = i
+			}
+		}
+	}
+
+	locate := func(f frame) int {
+		if i, ok := byFile[f.jar]; ok {
+			return i
+		}
+		if i, ok := byID[f.modID]; ok && !platformIDs[f.modID] {
+			return i
+		}
+		if m := mixinMethodPattern.FindStringSubmatch(f.method); m != nil {
+			if i, ok := byID[m[1]]; ok {
+				return i
+			}
+		}
+		if i := strings.LastIndex(f.class, "."); i >= 0 {
+			if j, ok := byPackage[f.class[:i]]; ok {
+				return j
+			}
+		}
+		return -1
+	}
+
+	frames := append([]string{}, r.Frames...)
+	for _, e := range r.Exceptions {
+		frames = append(frames, e.Frames...)
+	}
+	counts := make(map[int]int)
+	for _, s := range frames {
+		if i := locate(parseFrame(s)); i >= 0 {
+			counts[i]++
+		}
+	}
+	suspected := make(map[int]bool)
+	for _, s := range r.SuspectedMods {
+		if i, ok := byID[s.ID]; ok && !platformIDs[s.ID] {
+			suspected[i] = true
+		}
+	}
+
+	a := &Analysis{Report: r, Culprits: []Culprit{}}
+	for i, jar := range jars {
+		if counts[i] > 0 || suspected[i] {
+			a.Culprits = append(a.Culprits, Culprit{Jar: jar.File, Mods: jar.Mods, Frames: counts[i], Suspected: suspected[i]})
+		}
+	}
+	sort.SliceStable(a.Culprits, func(i, j int) bool {
+		ci, cj := a.Culprits[i], a.Culprits[j]
+		if ci.Suspected != cj.Suspected {
+			return ci.Suspected
+		}
+		return ci.Frames > cj.Frames
+	})
+	a.Diagnosis = diagnose(a)
+	return a, nil
+}
+
+// graphicsDriverPattern matches native libraries of graphics drivers.
+var graphicsDriverPattern = regexp.MustCompile(`(?i)nvoglv|nvidia|atio|atig|amdvlk|radeon|ig\d+icd|iris|mesa|libgl|_dri\.|opengl32`)
+
+// diagnose explains the crash in a few sentences, most telling first.
+func diagnose(a *Analysis) []string {
+	d := []string{}
+	text := a.Description
+	for _, e := range a.Exceptions {
+		text += "\n" + e.String()
+	}
+	has := func(subs ...string) bool {
+		for _, s := range subs {
+			if strings.Contains(text, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if a.Kind == KindJVM {
+		where := a.ProblematicFrame
+		if where == "" {
+			where = "native code"
+		}
+		d = append(d, fmt.Sprintf("The Java VM itself crashed (%s) in %s.", firstWord(a.Signal), where))
+		if graphicsDriverPattern.MatchString(a.ProblematicFrame) {
+			d = append(d, "The crash is inside the graphics driver; updating it, or removing rendering mods such as shaders, often helps.")
+		}
+	}
+	switch {
+	case has("OutOfMemoryError"):
+		d = append(d, "The game ran out of memory; give it more with -Xmx in the launcher, or remove mods.")
+	case has("Mixin", "mixin"):
+		d = append(d, "A mixin failed to apply: two mods change the same code, or a mod is built for another version.")
+	case has("ClassNotFoundException", "NoClassDefFoundError", "NoSuchMethodError", "NoSuchFieldError", "AbstractMethodError"):
+		d = append(d, "Code a mod expects is missing: a dependency is not installed, or a mod is built for another Minecraft or loader version.")
+	case has("Mod loading", "ModResolutionException", "Incompatible mod", "unsupported mandatory dependencies"):
+		d = append(d, "The loader refused to start the game; check the mods' dependencies and Minecraft versions.")
+	}
+
+	for _, c := range a.Culprits {
+		if c.Suspected {
+			d = append(d, fmt.Sprintf("The game suspects %s [%s].", c.Label(), c.Jar))
+		}
+	}
+	// Suspected culprits come first; the one with the most frames may not
+	top := -1
+	for i, c := range a.Culprits {
+		if c.Frames > 0 && (top < 0 || c.Frames > a.Culprits[top].Frames) {
+			top = i
+		}
+	}
+	if top >= 0 && !a.Culprits[top].Suspected {
+		c := a.Culprits[top]
+		d = append(d, fmt.Sprintf("Most of the stack trace that belongs to a mod runs through %s [%s].", c.Label(), c.Jar))
+	}
+	if len(a.Culprits) == 0 && a.Kind == KindGame {
+		d = append(d, "No installed mod appears in the stack trace; the crash may be in Minecraft or the loader itself.")
+	}
+	return d
+}
+
+func firstWord(s string) string {
+	if word, _, ok := strings.Cut(s, " "); ok {
+		return word
+	}
+	if s == "" {
+		return "unknown signal"
+	}
+	return s
+}
@@ -0,0 +1,284 @@
+// Package crash finds the crash reports of an instance, the game's own
+// crash-reports/*.txt and the hs_err_pid*.log the Java VM writes when it
+// crashes itself, and explains them.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportsDirName is the folder of an instance the game writes its crash
+// reports to.
+const ReportsDirName = "crash-reports"
+
+// Kind tells crash reports of the game from those of the Java VM.
+type Kind string
+
+const (
+	KindGame Kind = "game" // crash-reports/crash-*.txt
+	KindJVM  Kind = "jvm"  // hs_err_pid*.log in the instance folder
+)
+
+// File is a crash report of an instance.
+type File struct {
+	Name    string    `json:"name" yaml:"name"`
+	Path    string    `json:"path" yaml:"path"`
+	Kind    Kind      `json:"kind" yaml:"kind"`
+	ModTime time.Time `json:"mod_time" yaml:"mod_time"`
+}
+
+// Files lists the crash reports of the instance at instanceDir, newest
+// first.
+func Files(instanceDir string) ([]File, error) {
+	var files []File
+	add := func(dir string, kind Kind, match func(string) bool) error {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filepath.Base(dir), err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || !match(e.Name()) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			files = append(files, File{
+				Name:    e.Name(),
+				Path:    filepath.Join(dir, e.Name()),
+				Kind:    kind,
+				ModTime: info.ModTime(),
+			})
+		}
+		return nil
+	}
+	if err := add(filepath.Join(instanceDir, ReportsDirName), KindGame, func(name string) bool {
+		return strings.HasSuffix(name, ".txt")
+	}); err != nil {
+		return nil, err
+	}
+	if err := add(instanceDir, KindJVM, func(name string) bool {
+		return strings.HasPrefix(name, "hs_err_pid") && strings.HasSuffix(name, ".log")
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.After(files[j].ModTime) })
+	return files, nil
+}
+
+// Newest returns the most recent crash report of the instance at
+// instanceDir, or nil if it has none.
+func Newest(instanceDir string) (*File, error) {
+	files, err := Files(instanceDir)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	return &files[0], nil
+}
+
+// Exception is one exception of the chain a crash report shows; those
+// after the first are its causes.
+type Exception struct {
+	Class   string   `json:"class" yaml:"class"`
+	Message string   `json:"message,omitempty" yaml:"message,omitempty"`
+	Frames  []string `json:"frames" yaml:"frames"` // without the leading "at "
+}
+
+// String returns the exception as Java prints its first line.
+func (e Exception) String() string {
+	if e.Message == "" {
+		return e.Class
+	}
+	return e.Class + ": " + e.Message
+}
+
+// SuspectedMod is a mod the game itself blames for the crash.
+type SuspectedMod struct {
+	Name    string `json:"name" yaml:"name"`
+	ID      string `json:"id" yaml:"id"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ListedMod is a mod of the crash report's mod list.
+type ListedMod struct {
+	ID      string `json:"id" yaml:"id"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// Report is the parsed content of a crash report.
+type Report struct {
+	File             `yaml:",inline"`
+	Time             string         `json:"time,omitempty" yaml:"time,omitempty"`
+	Description      string         `json:"description,omitempty" yaml:"description,omitempty"`
+	MinecraftVersion string         `json:"minecraft_version,omitempty" yaml:"minecraft_version,omitempty"`
+	Exceptions       []Exception    `json:"exceptions" yaml:"exceptions"`
+	SuspectedMods    []SuspectedMod `json:"suspected_mods" yaml:"suspected_mods"`
+	Mods             []ListedMod    `json:"mods" yaml:"mods"`
+	// Signal and ProblematicFrame are those of a crash of the Java VM,
+	// whose Java frames are in Frames
+	Signal           string   `json:"signal,omitempty" yaml:"signal,omitempty"`
+	ProblematicFrame string   `json:"problematic_frame,omitempty" yaml:"problematic_frame,omitempty"`
+	Frames           []string `json:"frames,omitempty" yaml:"frames,omitempty"`
+}
+
+// Parse reads the crash report f.
+func Parse(f File) (*Report, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crash report: %w", err)
+	}
+	r := &Report{File: f, Exceptions: []Exception{}, SuspectedMods: []SuspectedMod{}, Mods: []ListedMod{}}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if f.Kind == KindJVM {
+		parseJVM(r, lines)
+	} else {
+		parseGame(r, lines)
+	}
+	return r, nil
+}
+
+var (
+	// "\tCreate (create), Version: 0.5.1.f"
+	suspectedPattern = regexp.MustCompile(`^\t([^\t].*?) \(([^)]+)\), Version: (.*)$`)
+	// "\t\tsodium: Sodium 0.5.8" of Fabric's mod list
+	fabricModPattern = regexp.MustCompile(`^\t\t([^\s:]+): (.*) (\S+)$`)
+)
+
+// parseGame reads a crash report the game wrote.
+func parseGame(r *Report, lines []string) {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case r.Time == "" && strings.HasPrefix(line, "Time: "):
+			r.Time = strings.TrimPrefix(line, "Time: ")
+		case r.Description == "" && strings.HasPrefix(line, "Description: "):
+			r.Description = strings.TrimPrefix(line, "Description: ")
+			// The exception follows after an empty line
+			for i+1 < len(lines) && lines[i+1] == "" {
+				i++
+			}
+			i = parseExceptions(r, lines, i+1)
+		case strings.HasPrefix(line, "Suspected Mod"):
+			i = parseSuspected(r, lines, i+1)
+		case r.MinecraftVersion == "" && strings.HasPrefix(line, "\tMinecraft Version: "):
+			r.MinecraftVersion = strings.TrimPrefix(line, "\tMinecraft Version: ")
+		case strings.HasPrefix(line, "\tMod List:"):
+			i = parseModList(r, lines, i+1)
+		case strings.HasPrefix(line, "\tFabric Mods:"), strings.HasPrefix(line, "\tQuilt Mods:"):
+			i = parseFabricMods(r, lines, i+1)
+		}
+	}
+}
+
+// parseExceptions reads the exception chain starting at lines[i] up to the
+// next empty line, and returns the index of the last line it read.
+func parseExceptions(r *Report, lines []string, i int) int {
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "at "):
+			if n := len(r.Exceptions); n > 0 {
+				r.Exceptions[n-1].Frames = append(r.Exceptions[n-1].Frames, strings.TrimPrefix(trimmed, "at "))
+			}
+		case strings.HasPrefix(trimmed, "..."), strings.HasPrefix(trimmed, "Suppressed: "):
+		case strings.HasPrefix(trimmed, "Caused by: "), len(r.Exceptions) == 0:
+			r.Exceptions = append(r.Exceptions, parseException(strings.TrimPrefix(trimmed, "Caused by: ")))
+		default:
+			// A message that spans lines
+			e := &r.Exceptions[len(r.Exceptions)-1]
+			e.Message = strings.TrimSpace(e.Message + "\n" + line)
+		}
+	}
+	return i - 1
+}
+
+// parseException splits "java.lang.Foo: message" into class and message.
+func parseException(s string) Exception {
+	e := Exception{Class: s, Frames: []string{}}
+	if class, msg, ok := strings.Cut(s, ": "); ok && !strings.Contains(class, " ") {
+		e.Class, e.Message = class, msg
+	}
+	return e
+}
+
+// parseSuspected reads the mods listed below "Suspected Mods:". Reports
+// may repeat the list for each of their sections.
+func parseSuspected(r *Report, lines []string, i int) int {
+	for ; i < len(lines) && strings.HasPrefix(lines[i], "\t"); i++ {
+		m := suspectedPattern.FindStringSubmatch(lines[i])
+		if m != nil && !slices.ContainsFunc(r.SuspectedMods, func(s SuspectedMod) bool { return s.ID == m[2] }) {
+			r.SuspectedMods = append(r.SuspectedMods, SuspectedMod{Name: m[1], ID: m[2], Version: m[3]})
+		}
+	}
+	return i - 1
+}
+
+// parseModList reads Forge's "file |name |id |version |state |..." rows.
+func parseModList(r *Report, lines []string, i int) int {
+	for ; i < len(lines) && strings.HasPrefix(lines[i], "\t\t"); i++ {
+		cols := strings.Split(lines[i], "|")
+		if len(cols) < 4 {
+			continue
+		}
+		r.Mods = append(r.Mods, ListedMod{
+			File:    strings.TrimSpace(cols[0]),
+			Name:    strings.TrimSpace(cols[1]),
+			ID:      strings.TrimSpace(cols[2]),
+			Version: strings.TrimSpace(cols[3]),
+		})
+	}
+	return i - 1
+}
+
+// parseFabricMods reads Fabric's "id: Name version" rows. Mods nested in
+// other mods' jars are indented further and skipped.
+func parseFabricMods(r *Report, lines []string, i int) int {
+	for ; i < len(lines) && strings.HasPrefix(lines[i], "\t\t"); i++ {
+		if m := fabricModPattern.FindStringSubmatch(lines[i]); m != nil {
+			r.Mods = append(r.Mods, ListedMod{ID: m[1], Name: m[2], Version: m[3]})
+		}
+	}
+	return i - 1
+}
+
+// parseJVM reads an hs_err_pid*.log of the Java VM.
+func parseJVM(r *Report, lines []string) {
+	r.Description = "The Java VM crashed"
+	inJavaFrames := false
+	for i, line := range lines {
+		switch {
+		case r.Signal == "" && strings.HasPrefix(line, "#  ") && strings.Contains(line, " at pc="):
+			r.Signal = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		case strings.HasPrefix(line, "# Problematic frame:") && i+1 < len(lines):
+			r.ProblematicFrame = strings.Join(strings.Fields(strings.TrimPrefix(lines[i+1], "#")), " ")
+		case r.Time == "" && strings.HasPrefix(line, "Time: "):
+			r.Time, _, _ = strings.Cut(strings.TrimPrefix(line, "Time: "), " elapsed time")
+		case strings.HasPrefix(line, "Java frames:"):
+			inJavaFrames = true
+		case inJavaFrames && strings.TrimSpace(line) == "":
+			inJavaFrames = false
+		case inJavaFrames:
+			// "j  com.example.Foo.bar(I)V+12" or "J 123 c2 com.example.Foo.bar()V (10 bytes) @ ..."
+			for _, field := range strings.Fields(line)[1:] {
+				if i := strings.Index(field, "("); i > 0 && strings.Contains(field[:i], ".") {
+					r.Frames = append(r.Frames, field[:i])
+					break
+				}
+			}
+		}
+	}
+}
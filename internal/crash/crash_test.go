@@ -0,0 +1,334 @@
+package crash
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const forgeReport = `---- Minecraft Crash Report ----
+// Who set us up the TNT?
+
+Time: 2026-10-18 12:00:00
+Description: Ticking entity
+
+java.lang.NullPointerException: Cannot invoke "net.minecraft.world.entity.Entity.getX()"
+because "target" is null
+	at com.simibubi.create.content.Thing.tick(Thing.java:42) ~[create-1.20.1-0.5.1.f.jar%23123!/:0.5.1.f] {re:classloading}
+	at TRANSFORMER/create@0.5.1.f/com.simibubi.create.content.Other.run(Other.java:7) ~[?:?] {}
+	at net.minecraft.world.level.Level.handler$zza000$flywheel$onTick(Level.java:100) ~[client-1.20.1.jar:?] {}
+	at TRANSFORMER/minecraft@1.20.1/net.minecraft.server.MinecraftServer.tick(MinecraftServer.java:900) ~[?:?] {}
+	at shared.util.Helper.call(Helper.java:1) ~[?:?] {}
+	... 12 more
+Caused by: java.lang.IllegalStateException: inner
+	at me.jellysquid.mods.sodium.Render.draw(Render.java:3) ~[?:?] {}
+
+
+A detailed walkthrough of the error, its code path and all known details is as follows:
+---------------------------------------------------------------------------------------
+
+-- Head --
+Thread: Server thread
+Suspected Mods:
+	Create (create), Version: 0.5.1.f
+		Issue tracker URL: https://github.com/Creators-of-Create/Create/issues
+	Minecraft (minecraft), Version: 1.20.1
+Stacktrace:
+	at com.simibubi.create.content.Thing.tick(Thing.java:42)
+
+-- Entity being ticked --
+Suspected Mods:
+	Create (create), Version: 0.5.1.f
+
+-- System Details --
+Details:
+	Minecraft Version: 1.20.1
+	Mod List:
+		create-1.20.1-0.5.1.f.jar                         |Create                        |create                        |0.5.1.f             |DONE      |Manifest: NOSIGNATURE
+		flywheel-forge-1.20.1-0.6.10.jar                  |Flywheel                      |flywheel                      |0.6.10-7            |DONE      |Manifest: NOSIGNATURE
+	Crash Report UUID: 1234
+`
+
+const fabricReport = `---- Minecraft Crash Report ----
+Time: 2026-10-18 12:00:00
+Description: Rendering overlay
+
+java.lang.NoSuchMethodError: 'void net.minecraft.class_1.method_2()'
+	at net.minecraft.class_310.method_1523(class_310.java:1) ~[client-intermediary.jar:?]
+
+-- System Details --
+	Minecraft Version: 1.20.1
+	Fabric Mods:
+		fabric-api: Fabric API 0.92.0+1.20.1
+			fabric-api-base: Fabric API Base 0.4.31+1802ada577
+		sodium: Sodium 0.5.3+mc1.20.1
+`
+
+const jvmReport = `#
+# A fatal error has been detected by the Java Runtime Environment:
+#
+#  EXCEPTION_ACCESS_VIOLATION (0xc0000005) at pc=0x00007ffb, pid=1234, tid=5678
+#
+# JRE version: OpenJDK Runtime Environment (17.0.8+7) (build 17.0.8+7)
+# Problematic frame:
+# C  [atio6axx.dll+0x1a2b3]
+#
+
+Time: Sat Oct 18 12:00:00 2026 CEST elapsed time: 12.5 seconds (0d 0h 0m 12s)
+
+Java frames: (J=compiled Java code, j=interpreted, Vv=VM code)
+j  org.lwjgl.opengl.GL11C.nglDrawElements(IIIJ)V+0
+J 4567 c2 me.jellysquid.mods.sodium.Render.draw()V (10 bytes) @ 0x1 [0x2+0x3]
+v  ~StubRoutines::call_stub
+
+---------------  P R O C E S S  ---------------
+`
+
+// writeReport writes a crash report to the crash-reports folder of dir, or
+// to dir itself for the Java VM's.
+func writeReport(t *testing.T, dir string, kind Kind, name, content string) File {
+	t.Helper()
+	if kind == KindGame {
+		dir = filepath.Join(dir, ReportsDirName)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(content, "\n", "\r\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	return File{Name: name, Path: path, Kind: kind, ModTime: info.ModTime()}
+}
+
+func TestParseForge(t *testing.T) {
+	r, err := Parse(writeReport(t, t.TempDir(), KindGame, "crash-forge.txt", forgeReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Time != "2026-10-18 12:00:00" || r.Description != "Ticking entity" || r.MinecraftVersion != "1.20.1" {
+		t.Errorf("Time, Description, MinecraftVersion = %q, %q, %q", r.Time, r.Description, r.MinecraftVersion)
+	}
+	if len(r.Exceptions) != 2 {
+		t.Fatalf("Exceptions = %+v, want two", r.Exceptions)
+	}
+	first := r.Exceptions[0]
+	if first.Class != "java.lang.NullPointerException" || !strings.HasSuffix(first.Message, "\nbecause \"target\" is null") {
+		t.Errorf("first exception = %q: %q", first.Class, first.Message)
+	}
+	if len(first.Frames) != 5 || !strings.HasPrefix(first.Frames[0], "com.simibubi.create.content.Thing.tick(") {
+		t.Errorf("first exception frames = %q", first.Frames)
+	}
+	if got := r.Exceptions[1].String(); got != "java.lang.IllegalStateException: inner" {
+		t.Errorf("cause = %q", got)
+	}
+
+	wantSuspected := []SuspectedMod{{"Create", "create", "0.5.1.f"}, {"Minecraft", "minecraft", "1.20.1"}}
+	if !reflect.DeepEqual(r.SuspectedMods, wantSuspected) {
+		t.Errorf("SuspectedMods = %+v, want %+v", r.SuspectedMods, wantSuspected)
+	}
+	wantMods := []ListedMod{
+		{ID: "create", Name: "Create", Version: "0.5.1.f", File: "create-1.20.1-0.5.1.f.jar"},
+		{ID: "flywheel", Name: "Flywheel", Version: "0.6.10-7", File: "flywheel-forge-1.20.1-0.6.10.jar"},
+	}
+	if !reflect.DeepEqual(r.Mods, wantMods) {
+		t.Errorf("Mods = %+v, want %+v", r.Mods, wantMods)
+	}
+}
+
+func TestParseFabric(t *testing.T) {
+	r, err := Parse(writeReport(t, t.TempDir(), KindGame, "crash-fabric.txt", fabricReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMods := []ListedMod{
+		{ID: "fabric-api", Name: "Fabric API", Version: "0.92.0+1.20.1"},
+		{ID: "sodium", Name: "Sodium", Version: "0.5.3+mc1.20.1"},
+	}
+	if !reflect.DeepEqual(r.Mods, wantMods) {
+		t.Errorf("Mods = %+v, want %+v", r.Mods, wantMods)
+	}
+	if len(r.Exceptions) != 1 || r.Exceptions[0].Class != "java.lang.NoSuchMethodError" {
+		t.Errorf("Exceptions = %+v", r.Exceptions)
+	}
+}
+
+func TestParseJVM(t *testing.T) {
+	r, err := Parse(writeReport(t, t.TempDir(), KindJVM, "hs_err_pid1234.log", jvmReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(r.Signal, "EXCEPTION_ACCESS_VIOLATION") {
+		t.Errorf("Signal = %q", r.Signal)
+	}
+	if r.ProblematicFrame != "C [atio6axx.dll+0x1a2b3]" {
+		t.Errorf("ProblematicFrame = %q", r.ProblematicFrame)
+	}
+	if r.Time != "Sat Oct 18 12:00:00 2026 CEST" {
+		t.Errorf("Time = %q", r.Time)
+	}
+	want := []string{"org.lwjgl.opengl.GL11C.nglDrawElements", "me.jellysquid.mods.sodium.Render.draw"}
+	if !reflect.DeepEqual(r.Frames, want) {
+		t.Errorf("Frames = %q, want %q", r.Frames, want)
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		in   string
+		want frame
+	}{
+		{"com.example.Foo.bar(Foo.java:1)", frame{class: "com.example.Foo", method: "bar"}},
+		{"com.example.Foo.bar(Foo.java:1) ~[mymod-1.0.jar%23100!/:1.0] {re:mixin}",
+			frame{class: "com.example.Foo", method: "bar", jar: "mymod-1.0.jar"}},
+		{"TRANSFORMER/create@0.5.1.f/com.simibubi.create.Thing.tick(Thing.java:42) ~[?:?]",
+			frame{class: "com.simibubi.create.Thing", method: "tick", modID: "create"}},
+		{"MC-BOOTSTRAP/cpw.mods.modlauncher@10.0.9/cpw.mods.modlauncher.Launcher.run(Launcher.java:1)",
+			frame{class: "cpw.mods.modlauncher.Launcher", method: "run", modID: "cpw.mods.modlauncher"}},
+		{"java.base/java.lang.Thread.run(Thread.java:833)", frame{class: "java.lang.Thread", method: "run", modID: "java.base"}},
+		{"me.jellysquid.mods.sodium.Render.draw", frame{class: "me.jellysquid.mods.sodium.Render", method: "draw"}},
+	}
+	for _, tt := range tests {
+		if got := parseFrame(tt.in); got != tt.want {
+			t.Errorf("parseFrame(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// writeJar writes a mod jar with a mods.toml declaring id and the given
+// class files.
+func writeJar(t *testing.T, dir, name, id string, classes ...string) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	files := append([]string{"META-INF/mods.toml"}, classes...)
+	for _, file := range files {
+		w, err := zw.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file == "META-INF/mods.toml" {
+			w.Write([]byte("[[mods]]\nmodId = \"" + id + "\"\nversion = \"1.0\"\n"))
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyzeMapsFramesToJars(t *testing.T) {
+	instance := t.TempDir()
+	modsDir := filepath.Join(instance, "mods")
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Named by the jar suffix Forge adds, and by the module prefix
+	writeJar(t, modsDir, "create-1.20.1-0.5.1.f.jar", "create")
+	// Named by the mod id Mixin puts into the injected method
+	writeJar(t, modsDir, "flywheel-forge-1.20.1-0.6.10.jar", "flywheel")
+	// Found by its package
+	writeJar(t, modsDir, "sodium.jar", "sodium", "me/jellysquid/mods/sodium/Render.class")
+	// A package two jars share says nothing
+	writeJar(t, modsDir, "a.jar", "a", "shared/util/Helper.class")
+	writeJar(t, modsDir, "b.jar", "b", "shared/util/Helper.class")
+	// Installed but not in the trace
+	writeJar(t, modsDir, "unrelated.jar", "unrelated", "org/unrelated/X.class")
+
+	r, err := Parse(writeReport(t, instance, KindGame, "crash-forge.txt", forgeReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Analyze(r, modsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type culprit struct {
+		jar       string
+		frames    int
+		suspected bool
+	}
+	var got []culprit
+	for _, c := range a.Culprits {
+		got = append(got, culprit{c.Jar, c.Frames, c.Suspected})
+	}
+	// Suspected first, then by frames
+	want := []culprit{
+		{"create-1.20.1-0.5.1.f.jar", 2, true},
+		{"flywheel-forge-1.20.1-0.6.10.jar", 1, false},
+		{"sodium.jar", 1, false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Culprits = %+v, want %+v", got, want)
+	}
+	if len(a.Diagnosis) == 0 || !strings.Contains(strings.Join(a.Diagnosis, "\n"), "suspects create 1.0 [create-1.20.1-0.5.1.f.jar]") {
+		t.Errorf("Diagnosis = %q", a.Diagnosis)
+	}
+}
+
+func TestAnalyzeJVMCrash(t *testing.T) {
+	instance := t.TempDir()
+	modsDir := filepath.Join(instance, "mods")
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeJar(t, modsDir, "sodium.jar", "sodium", "me/jellysquid/mods/sodium/Render.class")
+
+	r, err := Parse(writeReport(t, instance, KindJVM, "hs_err_pid1234.log", jvmReport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Analyze(r, modsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Culprits) != 1 || a.Culprits[0].Jar != "sodium.jar" {
+		t.Errorf("Culprits = %+v, want sodium.jar", a.Culprits)
+	}
+	if d := strings.Join(a.Diagnosis, "\n"); !strings.Contains(d, "graphics driver") {
+		t.Errorf("Diagnosis = %q, want the graphics driver named", a.Diagnosis)
+	}
+}
+
+func TestFilesAndSeen(t *testing.T) {
+	instance := t.TempDir()
+	old := writeReport(t, instance, KindGame, "crash-old.txt", fabricReport)
+	jvm := writeReport(t, instance, KindJVM, "hs_err_pid1.log", jvmReport)
+	os.Chtimes(old.Path, time.Now(), time.Now().Add(-time.Hour))
+	os.WriteFile(filepath.Join(instance, "hs_err_other.txt"), nil, 0644)
+
+	files, err := Files(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != jvm.Name || files[1].Kind != KindGame {
+		t.Fatalf("Files = %+v, want the JVM report, then the older game report", files)
+	}
+
+	seenPath := filepath.Join(t.TempDir(), SeenFileName)
+	seen := LoadSeen(seenPath)
+	if !seen.Unseen(instance) {
+		t.Error("Unseen = false before anything was marked")
+	}
+	if err := seen.Mark(instance, files[0]); err != nil {
+		t.Fatal(err)
+	}
+	if LoadSeen(seenPath).Unseen(instance) {
+		t.Error("Unseen = true after marking the newest report")
+	}
+
+	newer := writeReport(t, instance, KindGame, "crash-new.txt", forgeReport)
+	os.Chtimes(newer.Path, time.Now(), time.Now().Add(time.Hour))
+	if !LoadSeen(seenPath).Unseen(instance) {
+		t.Error("Unseen = false after a newer report")
+	}
+}
@@ -0,0 +1,69 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SeenFileName is the file inside AppDir that remembers, per instance
+// folder, the newest crash report that was looked at.
+const SeenFileName = "crash-seen.json"
+
+// Seen remembers which crash reports were looked at.
+type Seen struct {
+	path  string
+	times map[string]time.Time // newest seen report by instance folder
+}
+
+// LoadSeen reads the seen reports from path. A missing or damaged file
+// counts as nothing seen.
+func LoadSeen(path string) *Seen {
+	s := &Seen{path: path, times: make(map[string]time.Time)}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &s.times)
+	}
+	return s
+}
+
+// Unseen reports whether the instance at instanceDir has a crash report
+// newer than the last one looked at.
+func (s *Seen) Unseen(instanceDir string) bool {
+	newest, err := Newest(instanceDir)
+	if err != nil || newest == nil {
+		return false
+	}
+	return newest.ModTime.After(s.times[filepath.Clean(instanceDir)])
+}
+
+// Mark records that the reports of the instance at instanceDir up to f
+// were looked at, and saves the file.
+func (s *Seen) Mark(instanceDir string, f File) error {
+	key := filepath.Clean(instanceDir)
+	if !f.ModTime.After(s.times[key]) {
+		return nil
+	}
+	s.times[key] = f.ModTime
+	data, err := json.Marshal(s.times)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to save seen crash reports: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save seen crash reports: %w", err)
+	}
+	return nil
+}
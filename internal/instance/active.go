@@ -0,0 +1,105 @@
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ActiveKind classifies what MinecraftPath currently is.
+type ActiveKind string
+
+const (
+	// ActiveDefault means MinecraftPath is a real directory.
+	ActiveDefault ActiveKind = "default"
+	// ActiveInstance means MinecraftPath links to an instance directory.
+	ActiveInstance ActiveKind = "instance"
+	// ActiveForeign means MinecraftPath links somewhere that is not an
+	// instance, e.g. a folder outside InstancesPath or inside an instance.
+	ActiveForeign ActiveKind = "foreign"
+	// ActiveMissing means MinecraftPath does not exist or is a dangling link.
+	ActiveMissing ActiveKind = "missing"
+)
+
+// ActiveState describes what the launcher will use as its game directory.
+type ActiveState struct {
+	Kind ActiveKind `json:"kind" yaml:"kind"`
+	// Instance is set for ActiveInstance.
+	Instance string `json:"instance,omitempty" yaml:"instance,omitempty"`
+	// Target is the absolute link target for every kind of symlink.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+	// Relative is set if the symlink stores a relative path.
+	Relative bool `json:"relative,omitempty" yaml:"relative,omitempty"`
+}
+
+func (s ActiveState) String() string {
+	switch s.Kind {
+	case ActiveInstance:
+		return "instance " + s.Instance
+	case ActiveForeign:
+		return "foreign symlink " + s.Target
+	case ActiveMissing:
+		if s.Target != "" {
+			return "missing (dangling symlink " + s.Target + ")"
+		}
+		return "missing"
+	}
+	return string(ActiveDefault)
+}
+
+// ActiveState resolves MinecraftPath and InstancesPath canonically and
+// classifies the result. Only a link to a direct child directory of
+// InstancesPath counts as an instance.
+func (m *Manager) ActiveState() ActiveState {
+	info, err := os.Lstat(m.MinecraftPath)
+	if err != nil {
+		return ActiveState{Kind: ActiveMissing}
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return ActiveState{Kind: ActiveDefault}
+	}
+
+	// Relative and absolute links both resolve to an absolute target
+	target, err := m.readLink()
+	if err != nil {
+		return ActiveState{Kind: ActiveMissing}
+	}
+	raw, _ := os.Readlink(m.MinecraftPath)
+	relative := !filepath.IsAbs(raw)
+
+	resolved, err := filepath.EvalSymlinks(m.MinecraftPath)
+	if err != nil {
+		return ActiveState{Kind: ActiveMissing, Target: target, Relative: relative}
+	}
+	if fi, err := os.Stat(resolved); err != nil || !fi.IsDir() {
+		return ActiveState{Kind: ActiveForeign, Target: target, Relative: relative}
+	}
+
+	rel, err := filepath.Rel(canonicalPath(m.InstancesPath), resolved)
+	if err != nil || rel == "." || rel == ".." || strings.ContainsRune(rel, filepath.Separator) {
+		return ActiveState{Kind: ActiveForeign, Target: target, Relative: relative}
+	}
+	return ActiveState{Kind: ActiveInstance, Instance: rel, Target: target, Relative: relative}
+}
+
+// GetActiveInstance returns the name of the linked instance,
+// DefaultInstanceName if MinecraftPath is a real directory, or "" if it is
+// a foreign or dangling symlink or does not exist.
+func (m *Manager) GetActiveInstance() string {
+	switch s := m.ActiveState(); s.Kind {
+	case ActiveInstance:
+		return s.Instance
+	case ActiveDefault:
+		return DefaultInstanceName
+	}
+	return ""
+}
+
+// checkForeignLink refuses to replace a symlink this tool did not create.
+func (m *Manager) checkForeignLink() error {
+	if s := m.ActiveState(); s.Kind == ActiveForeign {
+		return errorOf(ErrForeignSymlink,
+			"%s is a foreign symlink to %s, not an instance; use --force to replace it", m.MinecraftPath, s.Target)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultContextName is the context formed by the top-level paths of the
+// config file. It always exists and cannot be created or removed.
+const DefaultContextName = "default"
+
+// ContextEnv selects the context like the --context flag.
+const ContextEnv = EnvPrefix + "CONTEXT"
+
+// ContextsDirName is the folder inside AppDir that holds the default
+// instances, backup and history of each named context.
+const ContextsDirName = "contexts"
+
+// ContextConfig holds the paths of a named context, e.g. for a second
+// launcher or a server directory. Empty paths fall back to defaults below
+// the context's own state directory.
+type ContextConfig struct {
+	MinecraftPath string `json:"minecraft_path" yaml:"minecraft_path"`
+	InstancesPath string `json:"instances_path,omitempty" yaml:"instances_path,omitempty"`
+	BackupPath    string `json:"backup_path,omitempty" yaml:"backup_path,omitempty"`
+}
+
+// Context describes a context with its effective paths.
+type Context struct {
+	Name          string `json:"name" yaml:"name"`
+	Current       bool   `json:"current" yaml:"current"`
+	MinecraftPath string `json:"minecraft_path" yaml:"minecraft_path"`
+	InstancesPath string `json:"instances_path" yaml:"instances_path"`
+	BackupPath    string `json:"backup_path" yaml:"backup_path"`
+}
+
+// stateDir is where the active context keeps its history, pending
+// migration and default paths. The default context uses AppDir itself.
+func (m *Manager) stateDir() string {
+	return m.contextStateDir(m.Context)
+}
+
+func (m *Manager) contextStateDir(name string) string {
+	if name == "" || name == DefaultContextName {
+		return m.AppDir
+	}
+	return filepath.Join(m.AppDir, ContextsDirName, name)
+}
+
+// contextPaths returns the file layer paths of the active context.
+func (m *Manager) contextPaths() ContextConfig {
+	if m.Context == DefaultContextName {
+		return ContextConfig{
+			MinecraftPath: m.file.MinecraftPath,
+			InstancesPath: m.file.InstancesPath,
+			BackupPath:    m.file.BackupPath,
+		}
+	}
+	return m.file.Contexts[m.Context]
+}
+
+// setContextPaths stores p as the file layer paths of the active context.
+func (m *Manager) setContextPaths(p ContextConfig) {
+	if m.Context == DefaultContextName {
+		m.file.MinecraftPath = p.MinecraftPath
+		m.file.InstancesPath = p.InstancesPath
+		m.file.BackupPath = p.BackupPath
+		return
+	}
+	// Copy the map so probe copies of the Manager do not write through
+	contexts := make(map[string]ContextConfig, len(m.file.Contexts))
+	for name, c := range m.file.Contexts {
+		contexts[name] = c
+	}
+	contexts[m.Context] = p
+	m.file.Contexts = contexts
+}
+
+// applyContext selects the context named by m.Context, or else the file's
+// current context, and takes the paths from it, filling in defaults.
+func (m *Manager) applyContext() error {
+	if m.Context == "" {
+		m.Context = m.file.CurrentContext
+		if m.Context != "" {
+			m.contextSource = ConfigSource{Layer: SourceFile, Detail: m.ConfigFile}
+		}
+	}
+	if m.Context == "" {
+		m.Context = DefaultContextName
+	}
+	if !m.hasContext(m.Context) {
+		return errorOf(ErrContextNotFound, "context '%s' does not exist", m.Context)
+	}
+
+	p := m.contextPaths()
+	m.MinecraftPath = expandPath(p.MinecraftPath)
+	m.InstancesPath = expandPath(p.InstancesPath)
+	m.BackupPath = expandPath(p.BackupPath)
+
+	// if any are empty, set defaults relative to the state dir / platform-specific paths
+	if m.InstancesPath == "" {
+		m.InstancesPath = filepath.Join(m.stateDir(), "instances")
+	}
+	if m.MinecraftPath == "" {
+		if defaultPath, err := getDefaultMinecraftPath(); err == nil {
+			m.MinecraftPath = defaultPath
+		} else {
+			// Fallback to the old behavior if detection fails
+			m.MinecraftPath = filepath.Join(m.HomeDir, MinecraftDir)
+		}
+	}
+	if m.BackupPath == "" {
+		m.BackupPath = filepath.Join(m.stateDir(), "backup")
+	}
+	return nil
+}
+
+func (m *Manager) hasContext(name string) bool {
+	if name == DefaultContextName {
+		return true
+	}
+	_, ok := m.file.Contexts[name]
+	return ok
+}
+
+// ListContexts returns the default context followed by the named contexts
+// in alphabetical order, each with its effective paths.
+func (m *Manager) ListContexts() []Context {
+	names := make([]string, 0, len(m.file.Contexts))
+	for name := range m.file.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append([]string{DefaultContextName}, names...)
+
+	contexts := make([]Context, 0, len(names))
+	for _, name := range names {
+		if name == m.Context {
+			contexts = append(contexts, Context{
+				Name:          name,
+				Current:       true,
+				MinecraftPath: m.MinecraftPath,
+				InstancesPath: m.InstancesPath,
+				BackupPath:    m.BackupPath,
+			})
+			continue
+		}
+		// Resolve the other contexts' defaults without touching m
+		probe := *m
+		probe.Context = name
+		probe.applyContext()
+		contexts = append(contexts, Context{
+			Name:          name,
+			MinecraftPath: probe.MinecraftPath,
+			InstancesPath: probe.InstancesPath,
+			BackupPath:    probe.BackupPath,
+		})
+	}
+	return contexts
+}
+
+// ContextSource reports where the active context was selected.
+func (m *Manager) ContextSource() ConfigSource {
+	return m.contextSource
+}
+
+// validateContextName rejects names that cannot be used as a directory name.
+func validateContextName(name string) error {
+	if name == "" {
+		return errorOf(ErrEmptyName, "context name cannot be empty")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid context name '%s'", name)
+	}
+	return nil
+}
+
+// newContextProbe returns a copy of m switched to a context named name with
+// the paths c, for validation and planning.
+func (m *Manager) newContextProbe(name string, c ContextConfig) (*Manager, error) {
+	if err := validateContextName(name); err != nil {
+		return nil, err
+	}
+	if m.hasContext(name) {
+		return nil, errorOf(ErrContextExists, "context '%s' already exists", name)
+	}
+	c.MinecraftPath = expandPath(c.MinecraftPath)
+	c.InstancesPath = expandPath(c.InstancesPath)
+	c.BackupPath = expandPath(c.BackupPath)
+	if c.MinecraftPath == "" {
+		return nil, fmt.Errorf("a new context needs a minecraft-path")
+	}
+
+	probe := *m
+	probe.Context = name
+	probe.setContextPaths(c)
+	probe.applyContext()
+	if problems := probe.ValidateConfig(); len(problems) > 0 {
+		return nil, problemsError(problems)
+	}
+	return &probe, nil
+}
+
+// PlanCreateContext returns the steps CreateContext would perform.
+func (m *Manager) PlanCreateContext(name string, c ContextConfig) (*Plan, error) {
+	probe, err := m.newContextProbe(name, c)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Action: "create-context"}
+	plan.add(OpMkdir, probe.InstancesPath, "")
+	plan.Steps = append(plan.Steps, Step{
+		Op:     OpWrite,
+		Path:   m.ConfigFile,
+		Detail: fmt.Sprintf("add context %s (minecraft-path = %s)", name, probe.MinecraftPath),
+	})
+	return plan, nil
+}
+
+// CreateContext adds a named context with its own paths. It does not make
+// it the current context; see UseContext.
+func (m *Manager) CreateContext(name string, c ContextConfig) error {
+	probe, err := m.newContextProbe(name, c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(probe.InstancesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create instances directory: %w", err)
+	}
+	m.file.Contexts = probe.file.Contexts
+	return m.writeConfigFile()
+}
+
+// PlanUseContext returns the steps UseContext would perform.
+func (m *Manager) PlanUseContext(name string) (*Plan, error) {
+	if !m.hasContext(name) {
+		return nil, errorOf(ErrContextNotFound, "context '%s' does not exist", name)
+	}
+	plan := &Plan{Action: "use-context"}
+	plan.Steps = append(plan.Steps, Step{
+		Op:     OpWrite,
+		Path:   m.ConfigFile,
+		Detail: "current_context = " + name,
+	})
+	return plan, nil
+}
+
+// UseContext makes name the current context in the config file. It applies
+// to Managers created afterwards; --context and MCIM_CONTEXT still win.
+func (m *Manager) UseContext(name string) error {
+	if !m.hasContext(name) {
+		return errorOf(ErrContextNotFound, "context '%s' does not exist", name)
+	}
+	m.file.CurrentContext = name
+	if name == DefaultContextName {
+		m.file.CurrentContext = ""
+	}
+	return m.writeConfigFile()
+}
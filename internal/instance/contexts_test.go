@@ -0,0 +1,149 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func contextNames(contexts []Context) []string {
+	var names []string
+	for _, c := range contexts {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestCreateContext(t *testing.T) {
+	m := newTestManager(t)
+	server := filepath.Join(m.HomeDir, "server")
+	before, _ := os.ReadFile(m.ConfigFile)
+
+	for _, tt := range []struct {
+		name string
+		c    ContextConfig
+	}{
+		{"", ContextConfig{MinecraftPath: server}},
+		{"..", ContextConfig{MinecraftPath: server}},
+		{"a/b", ContextConfig{MinecraftPath: server}},
+		{DefaultContextName, ContextConfig{MinecraftPath: server}},
+		{"server", ContextConfig{}},
+		{"server", ContextConfig{MinecraftPath: "relative"}},
+	} {
+		if err := m.CreateContext(tt.name, tt.c); err == nil {
+			t.Errorf("CreateContext(%q, %+v) succeeded", tt.name, tt.c)
+		}
+	}
+	if after, _ := os.ReadFile(m.ConfigFile); string(after) != string(before) {
+		t.Fatalf("refused contexts changed the config file to %s", after)
+	}
+
+	plan, err := m.PlanCreateContext("server", ContextConfig{MinecraftPath: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDir := filepath.Join(m.AppDir, ContextsDirName, "server")
+	if len(plan.Steps) != 2 || plan.Steps[0].Path != filepath.Join(stateDir, "instances") || plan.Steps[1].Path != m.ConfigFile {
+		t.Errorf("PlanCreateContext steps = %+v", plan.Steps)
+	}
+
+	if err := m.CreateContext("server", ContextConfig{MinecraftPath: server}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(stateDir, "instances")); err != nil || !info.IsDir() {
+		t.Errorf("instances directory of the new context: %v", err)
+	}
+	if err := m.CreateContext("server", ContextConfig{MinecraftPath: server}); !errors.Is(err, ErrContextExists) {
+		t.Errorf("creating it again = %v, want ErrContextExists", err)
+	}
+	if m.Context != DefaultContextName {
+		t.Errorf("CreateContext switched to %q", m.Context)
+	}
+
+	// Named contexts follow the default one in alphabetical order
+	custom := ContextConfig{MinecraftPath: server, InstancesPath: filepath.Join(m.HomeDir, "elsewhere")}
+	if err := m.CreateContext("alpha", custom); err != nil {
+		t.Fatal(err)
+	}
+	contexts := m.ListContexts()
+	if got := contextNames(contexts); !reflect.DeepEqual(got, []string{DefaultContextName, "alpha", "server"}) {
+		t.Fatalf("ListContexts names = %v", got)
+	}
+	if !contexts[0].Current || contexts[0].InstancesPath != m.InstancesPath {
+		t.Errorf("default context = %+v", contexts[0])
+	}
+	want := Context{
+		Name:          "server",
+		MinecraftPath: server,
+		InstancesPath: filepath.Join(stateDir, "instances"),
+		BackupPath:    filepath.Join(stateDir, "backup"),
+	}
+	if contexts[2] != want {
+		t.Errorf("server context = %+v, want %+v", contexts[2], want)
+	}
+	if contexts[1].InstancesPath != custom.InstancesPath {
+		t.Errorf("alpha instances path = %q, want %q", contexts[1].InstancesPath, custom.InstancesPath)
+	}
+}
+
+func TestUseContext(t *testing.T) {
+	m := newTestManager(t)
+	server := filepath.Join(m.HomeDir, "server")
+	if err := m.CreateContext("server", ContextConfig{MinecraftPath: server}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UseContext("missing"); !errors.Is(err, ErrContextNotFound) {
+		t.Errorf("UseContext(missing) = %v, want ErrContextNotFound", err)
+	}
+	if _, err := NewManagerWithOptions(Options{LookupEnv: noEnv, Context: "missing"}); !errors.Is(err, ErrContextNotFound) {
+		t.Errorf("a manager for a missing context = %v, want ErrContextNotFound", err)
+	}
+
+	if err := m.UseContext("server"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := m.readConfigFile()
+	if err != nil || cfg.CurrentContext != "server" {
+		t.Fatalf("current_context = %q, %v", cfg.CurrentContext, err)
+	}
+
+	// Managers created afterwards work in the context and keep their state
+	// apart from the default context
+	sm, err := NewManagerWithOptions(Options{LookupEnv: noEnv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDir := filepath.Join(m.AppDir, ContextsDirName, "server")
+	if sm.Context != "server" || sm.MinecraftPath != server || sm.InstancesPath != filepath.Join(stateDir, "instances") {
+		t.Errorf("manager in the server context = %q, %q, %q", sm.Context, sm.MinecraftPath, sm.InstancesPath)
+	}
+	if sm.historyFile() != filepath.Join(stateDir, HistoryFileName) || sm.migrationFile() != filepath.Join(stateDir, MigrationFileName) {
+		t.Errorf("server context state = %s, %s, want them in %s", sm.historyFile(), sm.migrationFile(), stateDir)
+	}
+	if m.historyFile() != filepath.Join(m.AppDir, HistoryFileName) {
+		t.Errorf("default context history = %s, want it in %s", m.historyFile(), m.AppDir)
+	}
+
+	// An explicit context wins over the file's current one
+	dm, err := NewManagerWithOptions(Options{LookupEnv: noEnv, Context: DefaultContextName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dm.InstancesPath != m.InstancesPath {
+		t.Errorf("default context instances path = %q, want %q", dm.InstancesPath, m.InstancesPath)
+	}
+	env := func(key string) (string, bool) { return DefaultContextName, key == ContextEnv }
+	if em, err := NewManagerWithOptions(Options{LookupEnv: env}); err != nil || em.Context != DefaultContextName {
+		t.Errorf("%s=default selected %v, %v", ContextEnv, em, err)
+	}
+
+	if err := sm.UseContext(DefaultContextName); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := m.readConfigFile(); cfg.CurrentContext != "" {
+		t.Errorf("current_context = %q after switching back to the default", cfg.CurrentContext)
+	}
+}
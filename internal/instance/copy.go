@@ -0,0 +1,365 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultCopyExcludes are the entries skipped when an instance is created from
+// an existing .minecraft directory. Entries are matched by exact name.
+var DefaultCopyExcludes = []string{".git", ".DS_Store"}
+
+// copyBufferSize is the chunk size used when streaming file contents. Progress
+// is reported and cancellation is checked once per chunk.
+const copyBufferSize = 256 * 1024
+
+// CopyProgress describes how far a CopyTree call has come.
+type CopyProgress struct {
+	FilesDone  int
+	FilesTotal int
+	BytesDone  int64
+	BytesTotal int64
+	Current    string // path of the entry being copied, relative to the source
+	Started    time.Time
+}
+
+// ETA estimates the remaining time based on the throughput so far.
+// It returns 0 when no estimate is possible yet.
+func (p CopyProgress) ETA() time.Duration {
+	if p.BytesDone <= 0 || p.BytesTotal <= p.BytesDone || p.Started.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(p.Started)
+	rate := float64(p.BytesDone) / elapsed.Seconds()
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(p.BytesTotal-p.BytesDone) / rate * float64(time.Second))
+}
+
+// Percent returns the completed fraction in the range [0, 1].
+func (p CopyProgress) Percent() float64 {
+	if p.BytesTotal > 0 {
+		return float64(p.BytesDone) / float64(p.BytesTotal)
+	}
+	if p.FilesTotal > 0 {
+		return float64(p.FilesDone) / float64(p.FilesTotal)
+	}
+	return 0
+}
+
+// ProgressFunc receives progress updates during long-running operations.
+type ProgressFunc func(CopyProgress)
+
+// CopyOptions controls the behaviour of CopyTree.
+type CopyOptions struct {
+	// Exclude lists entries to skip. A rule without a slash matches any entry
+	// with exactly that base name; a rule containing a slash matches exactly
+	// that path relative to the source (always written with forward slashes).
+	Exclude []string
+	// Progress, if set, is called after every file and every streamed chunk.
+	Progress ProgressFunc
+}
+
+func (o CopyOptions) excluded(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(rel)
+	for _, rule := range o.Exclude {
+		if strings.Contains(rule, "/") {
+			if strings.Trim(rule, "/") == rel {
+				return true
+			}
+		} else if rule == base {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyTree copies the directory tree at src into dst. File contents are
+// streamed, and permissions, modification times and symlinks are preserved.
+// Other special files (sockets, devices, pipes) are skipped. The copy stops
+// with ctx.Err() as soon as ctx is cancelled.
+func CopyTree(ctx context.Context, src, dst string, opts CopyOptions) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !srcInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+
+	progress := CopyProgress{Started: time.Now()}
+
+	// First pass: count what needs to be copied so progress has totals
+	err = walkCopyable(src, opts, func(rel string, d fs.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			progress.FilesTotal++
+			progress.BytesTotal += info.Size()
+		} else if d.Type()&fs.ModeSymlink != 0 {
+			progress.FilesTotal++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report := func() {
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	report()
+
+	// Directory modes and times are applied last: writing children changes
+	// the mtime, and a read-only mode would prevent writing them at all
+	type dirAttrs struct {
+		path  string
+		mode  fs.FileMode
+		mtime time.Time
+	}
+	var dirs []dirAttrs
+	buf := make([]byte, copyBufferSize)
+
+	err = walkCopyable(src, opts, func(rel string, d fs.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		srcPath := filepath.Join(src, rel)
+		dstPath := filepath.Join(dst, rel)
+		progress.Current = rel
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(dstPath, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirAttrs{dstPath, info.Mode().Perm(), info.ModTime()})
+			return nil
+
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dstPath); err != nil {
+				return err
+			}
+			progress.FilesDone++
+			report()
+			return nil
+
+		case d.Type().IsRegular():
+			if err := copyFileContents(ctx, srcPath, dstPath, info, buf, func(n int64) {
+				progress.BytesDone += n
+				report()
+			}); err != nil {
+				return err
+			}
+			progress.FilesDone++
+			report()
+			return nil
+		}
+
+		// Sockets, devices and named pipes are not copied
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(d.path, d.mtime, d.mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkCopyable walks src and calls fn for every entry that is not excluded,
+// passing the path relative to src. The root itself is reported as ".".
+func walkCopyable(src string, opts CopyOptions, fn func(rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && opts.excluded(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
+
+// copyFileContents streams src into a newly created dst with the same mode and
+// modification time, calling onChunk with the number of bytes read.
+func copyFileContents(ctx context.Context, src, dst string, info fs.FileInfo, buf []byte, onChunk func(int64)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyBuffer(out, &contextReader{ctx: ctx, r: in, onRead: onChunk}, buf)
+	if err == nil {
+		// OpenFile honours the umask, so apply the exact mode explicitly
+		err = out.Chmod(info.Mode().Perm())
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// contextReader aborts reads once ctx is cancelled and reports every chunk read.
+type contextReader struct {
+	ctx    context.Context
+	r      io.Reader
+	onRead func(int64)
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if n > 0 && c.onRead != nil {
+		c.onRead(int64(n))
+	}
+	return n, err
+}
+
+// RemoveTree removes path and everything below it like os.RemoveAll, but
+// reports progress per removed file and stops when ctx is cancelled.
+func RemoveTree(ctx context.Context, path string, progress ProgressFunc) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	p := CopyProgress{Started: time.Now()}
+	var files, dirs []string
+	err := filepath.WalkDir(path, func(sub string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, sub)
+			return nil
+		}
+		if info, err := d.Info(); err == nil && d.Type().IsRegular() {
+			p.BytesTotal += info.Size()
+		}
+		files = append(files, sub)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.FilesTotal = len(files)
+
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	report()
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var size int64
+		if info, err := os.Lstat(f); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+		}
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		p.Current, _ = filepath.Rel(path, f)
+		p.FilesDone++
+		p.BytesDone += size
+		report()
+	}
+
+	// Children were appended after their parents, so remove in reverse
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rename is os.Rename; tests replace it to take the cross-filesystem path
+// without a second filesystem.
+var rename = os.Rename
+
+// MoveTree moves src to dst. It uses a rename when both are on the same
+// filesystem and falls back to CopyTree followed by RemoveTree otherwise.
+func MoveTree(ctx context.Context, src, dst string, progress ProgressFunc) error {
+	err := rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+
+	if err := CopyTree(ctx, src, dst, CopyOptions{Progress: progress}); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	// The copy is complete; removing the source is no longer cancellable so
+	// the data never ends up half in both places
+	return RemoveTree(context.Background(), src, nil)
+}
+
+// isCrossDevice reports whether err is a rename failure caused by the source
+// and target living on different filesystems.
+func isCrossDevice(err error) bool {
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) {
+		return false
+	}
+	return errors.Is(linkErr.Err, syscall.EXDEV)
+}
@@ -0,0 +1,150 @@
+//go:build unix
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyTreeKeepsModesTimesAndLinks(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"options.txt":        "fov:90",
+		"private/token.json": "secret",
+		"bin/launch.sh":      "#!/bin/sh",
+	})
+	modes := map[string]fs.FileMode{
+		"private/token.json": 0600,
+		"private":            0700,
+		"bin/launch.sh":      0755,
+		"options.txt":        0640,
+	}
+	for name, mode := range modes {
+		if err := os.Chmod(filepath.Join(src, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"current":       "options.txt",
+		"bin/dangling":  "../missing",
+		"bin/absolute":  "/etc/hostname",
+		"private/up.sh": "../bin/launch.sh",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	for _, name := range []string{"options.txt", "private/token.json", "private", "bin"} {
+		if err := os.Chtimes(filepath.Join(src, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyTree(context.Background(), src, dst, CopyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"options.txt", "private/token.json", "bin/launch.sh"} {
+		want, _ := os.ReadFile(filepath.Join(src, name))
+		if got, err := os.ReadFile(filepath.Join(dst, name)); err != nil || string(got) != string(want) {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for name, mode := range modes {
+		info, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode().Perm(), mode)
+		}
+	}
+	for _, name := range []string{"options.txt", "private/token.json", "private", "bin"} {
+		info, err := os.Stat(filepath.Join(dst, name))
+		if err != nil || !info.ModTime().Equal(mtime) {
+			t.Errorf("%s mtime = %v, want %v", name, info.ModTime(), mtime)
+		}
+	}
+	for name, target := range links {
+		got, err := os.Readlink(filepath.Join(dst, name))
+		if err != nil || got != target {
+			t.Errorf("%s links to %q, %v, want %q", name, got, err, target)
+		}
+	}
+}
+
+func TestCopyTreeExcludes(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"options.txt":                "fov:90",
+		".git/HEAD":                  "ref",
+		"mods/.git/HEAD":             "ref",
+		"logs/latest.log":            "log",
+		"config/logs/keep.cfg":       "cfg",
+		"saves/World/session.lock":   "lock",
+		"saves/Other/session.lock.1": "keep",
+	})
+	opts := CopyOptions{Exclude: []string{".git", "/logs/", "saves/World/session.lock"}}
+
+	var last CopyProgress
+	opts.Progress = func(p CopyProgress) { last = p }
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyTree(context.Background(), src, dst, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"options.txt":                "fov:90",
+		"config/logs/keep.cfg":       "cfg",
+		"saves/Other/session.lock.1": "keep",
+	}
+	if got := readTree(t, dst); !reflect.DeepEqual(got, want) {
+		t.Errorf("copied files = %v, want %v", got, want)
+	}
+	if last.FilesDone != 3 || last.FilesTotal != 3 || last.BytesDone != last.BytesTotal {
+		t.Errorf("final progress = %+v, want the excluded files left out of the totals", last)
+	}
+}
+
+func TestCopyTreeCancelled(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"a/small.txt": "small",
+		"b/large.bin": strings.Repeat("x", 4*copyBufferSize),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancel halfway through the large file, once its first chunk is written
+	opts := CopyOptions{Progress: func(p CopyProgress) {
+		if p.Current == filepath.Join("b", "large.bin") && p.BytesDone > int64(len("small")) {
+			cancel()
+		}
+	}}
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyTree(ctx, src, dst, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CopyTree = %v, want context.Canceled", err)
+	}
+
+	// Whatever was copied is complete; the interrupted file is gone
+	srcFiles := readTree(t, src)
+	for name, content := range readTree(t, dst) {
+		if content != srcFiles[name] {
+			t.Errorf("%s was left half copied (%d of %d bytes)", name, len(content), len(srcFiles[name]))
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "b", "large.bin")); !os.IsNotExist(err) {
+		t.Errorf("the interrupted file exists: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Severity of a doctor finding.
+type Severity string
+
+const (
+	SeverityOK      Severity = "ok"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Stable identifiers of the doctor checks.
+const (
+	CheckDanglingSymlink  = "dangling_symlink"
+	CheckForeignSymlink   = "foreign_symlink"
+	CheckBackupConflict   = "backup_conflict"
+	CheckNestedInstances  = "instances_inside_minecraft"
+	CheckNestedBackup     = "backup_inside_minecraft"
+	CheckUnexpandedPaths  = "unexpanded_config_paths"
+	CheckMissingInstances = "instances_path_missing"
+	CheckPendingMigration = "interrupted_migration"
+	CheckInvalidConfig    = "invalid_config_paths"
+)
+
+// Finding is the result of a single doctor check.
+type Finding struct {
+	Check       string   `json:"check" yaml:"check"`
+	Severity    Severity `json:"severity" yaml:"severity"`
+	Message     string   `json:"message" yaml:"message"`
+	Explanation string   `json:"explanation,omitempty" yaml:"explanation,omitempty"`
+	// Fix describes the repair Repair would apply; nil if there is no
+	// safe automatic repair.
+	Fix   *Plan `json:"fix,omitempty" yaml:"fix,omitempty"`
+	Fixed bool  `json:"fixed" yaml:"fixed"`
+}
+
+// DoctorReport is the structured result of Diagnose.
+type DoctorReport struct {
+	Healthy  bool      `json:"healthy" yaml:"healthy"`
+	Findings []Finding `json:"findings" yaml:"findings"`
+}
+
+// Diagnose runs every check against the current setup. Passing checks are
+// included with SeverityOK so the report is complete.
+func (m *Manager) Diagnose() *DoctorReport {
+	report := &DoctorReport{Healthy: true}
+	checks := []func() Finding{
+		m.checkMissingInstances,
+		m.checkSymlink,
+		m.checkBackupConflict,
+		m.checkNestedInstances,
+		m.checkNestedBackup,
+		m.checkUnexpandedPaths,
+		m.checkPendingMigration,
+		m.checkConfigPaths,
+	}
+	for _, check := range checks {
+		f := check()
+		if f.Severity == SeverityError {
+			report.Healthy = false
+		}
+		report.Findings = append(report.Findings, f)
+	}
+	return report
+}
+
+// Repair applies the fix of a finding returned by Diagnose.
+func (m *Manager) Repair(f Finding) error {
+	if f.Fix == nil {
+		return fmt.Errorf("%s has no automatic repair", f.Check)
+	}
+	switch f.Check {
+	case CheckUnexpandedPaths:
+		// loadConfig already expanded the paths held in memory
+		return m.saveConfig()
+	case CheckDanglingSymlink:
+		return m.RestoreDefault()
+	case CheckPendingMigration:
+		mig, err := m.PendingMigration()
+		if err != nil || mig == nil {
+			return err
+		}
+		return m.MoveInstancesPath(context.Background(), mig.To, nil)
+	}
+	return m.execute(context.Background(), f.Fix, nil)
+}
+
+func (m *Manager) checkMissingInstances() Finding {
+	f := Finding{Check: CheckMissingInstances, Severity: SeverityOK, Message: "instances directory exists"}
+	if info, err := os.Stat(m.InstancesPath); err == nil && info.IsDir() {
+		return f
+	}
+	f.Severity = SeverityWarning
+	f.Message = fmt.Sprintf("instances directory %s does not exist", m.InstancesPath)
+	f.Explanation = "No instances can be listed or created until the directory exists."
+	f.Fix = &Plan{Action: "doctor"}
+	f.Fix.add(OpMkdir, m.InstancesPath, "")
+	return f
+}
+
+func (m *Manager) checkSymlink() Finding {
+	f := Finding{Check: CheckDanglingSymlink, Severity: SeverityOK}
+
+	switch state := m.ActiveState(); state.Kind {
+	case ActiveDefault:
+		f.Message = "minecraft directory is the default directory"
+	case ActiveInstance:
+		f.Message = fmt.Sprintf("minecraft directory links to instance %s", state.Instance)
+	case ActiveMissing:
+		if state.Target == "" {
+			f.Severity = SeverityWarning
+			f.Message = fmt.Sprintf("%s does not exist", m.MinecraftPath)
+			f.Explanation = "The launcher will create a fresh game directory on its next start."
+			return f
+		}
+		f.Severity = SeverityError
+		f.Message = fmt.Sprintf("%s points to %s, which does not exist", m.MinecraftPath, state.Target)
+		f.Explanation = "The launcher will fail to start or create an empty game directory. " +
+			"The instance was probably deleted or moved while it was active."
+		f.Fix, _ = m.PlanRestore()
+	case ActiveForeign:
+		f.Check = CheckForeignSymlink
+		f.Severity = SeverityWarning
+		f.Message = fmt.Sprintf("%s points to %s, which is not an instance in %s", m.MinecraftPath, state.Target, m.InstancesPath)
+		f.Explanation = "The link was not created by this tool, points inside an instance, or instances-path changed since. " +
+			"Switching refuses to replace it without --force. Neither switching nor 'restore' keeps the link or its target " +
+			"as the default; to keep that directory, replace the link with it before switching, otherwise use 'switch --force'."
+	}
+	return f
+}
+
+func (m *Manager) checkBackupConflict() Finding {
+	f := Finding{Check: CheckBackupConflict, Severity: SeverityOK, Message: "no conflicting backup"}
+
+	info, err := os.Lstat(m.MinecraftPath)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 || !info.IsDir() {
+		return f
+	}
+	if _, err := os.Lstat(m.BackupPath); err != nil {
+		return f
+	}
+
+	recovered := filepath.Join(m.InstancesPath, "recovered-backup-"+time.Now().Format("20060102-150405"))
+	f.Severity = SeverityError
+	f.Message = fmt.Sprintf("backup %s exists while %s is a real directory", m.BackupPath, m.MinecraftPath)
+	f.Explanation = "The next switch would replace the old backup with the current directory and lose it. " +
+		"The repair keeps the old backup as a new instance."
+	// The backup may live on another filesystem than the instances, so it
+	// is moved the way restore moves it rather than renamed
+	f.Fix = &Plan{Action: "doctor"}
+	f.Fix.add(OpMkdir, m.InstancesPath, "")
+	f.Fix.add(OpMove, m.BackupPath, recovered)
+	return f
+}
+
+func (m *Manager) checkNestedInstances() Finding {
+	f := Finding{Check: CheckNestedInstances, Severity: SeverityOK, Message: "instances directory is outside the minecraft directory"}
+	if isWithin(canonicalPath(m.InstancesPath), canonicalLinkPath(m.MinecraftPath)) {
+		f.Severity = SeverityError
+		f.Message = fmt.Sprintf("instances directory %s is inside %s", m.InstancesPath, m.MinecraftPath)
+		f.Explanation = "Switching moves the minecraft directory to the backup location, taking every instance with it. " +
+			"Move the instances elsewhere and update instances-path."
+	}
+	return f
+}
+
+func (m *Manager) checkNestedBackup() Finding {
+	f := Finding{Check: CheckNestedBackup, Severity: SeverityOK, Message: "backup path is outside the minecraft directory"}
+	if isWithin(canonicalPath(m.BackupPath), canonicalLinkPath(m.MinecraftPath)) {
+		f.Severity = SeverityError
+		f.Message = fmt.Sprintf("backup path %s is inside %s", m.BackupPath, m.MinecraftPath)
+		f.Explanation = "A directory cannot be renamed into itself, so switching will fail. Update backup-path."
+	}
+	return f
+}
+
+func (m *Manager) checkUnexpandedPaths() Finding {
+	f := Finding{Check: CheckUnexpandedPaths, Severity: SeverityOK, Message: "config paths are absolute"}
+
+	file, err := m.readConfigFile()
+	if err != nil {
+		return f
+	}
+	// Only the active context's paths are repaired by saving the config
+	probe := *m
+	probe.file = file
+	raw := probe.contextPaths()
+
+	var tilde []string
+	fixable := true
+	for _, p := range []string{raw.InstancesPath, raw.MinecraftPath, raw.BackupPath} {
+		if !strings.HasPrefix(p, "~") {
+			continue
+		}
+		tilde = append(tilde, p)
+		if expandPath(p) == p {
+			fixable = false
+		}
+	}
+	if len(tilde) == 0 {
+		return f
+	}
+
+	f.Severity = SeverityWarning
+	f.Message = fmt.Sprintf("config file contains unexpanded paths: %s", strings.Join(tilde, ", "))
+	f.Explanation = "Paths starting with ~ depend on who runs the tool; ~user paths for unknown users are used literally."
+	if !fixable {
+		f.Severity = SeverityError
+		f.Explanation += " At least one refers to a user that does not exist on this system; edit it by hand."
+		return f
+	}
+	f.Fix = &Plan{Action: "doctor"}
+	f.Fix.Steps = append(f.Fix.Steps, Step{Op: OpWrite, Path: m.ConfigFile, Detail: "store expanded absolute paths"})
+	return f
+}
+
+func (m *Manager) checkPendingMigration() Finding {
+	f := Finding{Check: CheckPendingMigration, Severity: SeverityOK, Message: "no interrupted instance move"}
+	mig, err := m.PendingMigration()
+	if err != nil || mig == nil {
+		return f
+	}
+	f.Severity = SeverityError
+	f.Message = fmt.Sprintf("moving instances from %s to %s was interrupted (%d of %d done)",
+		mig.From, mig.To, len(mig.Done), len(mig.Instances))
+	f.Explanation = "Instances are split between both directories until the move is finished."
+	f.Fix, _ = m.PlanMoveInstancesPath(mig.To)
+	return f
+}
+
+// checkConfigPaths reports config paths that are relative or not writable;
+// nesting is covered by the dedicated checks above.
+func (m *Manager) checkConfigPaths() Finding {
+	f := Finding{Check: CheckInvalidConfig, Severity: SeverityOK, Message: "config paths are absolute and writable"}
+	problems := m.validatePaths()
+	if len(problems) == 0 {
+		return f
+	}
+	var msgs []string
+	for _, p := range problems {
+		msgs = append(msgs, p.String())
+	}
+	f.Severity = SeverityError
+	f.Message = "invalid config paths: " + strings.Join(msgs, "; ")
+	f.Explanation = "Fix them with 'config <key> <absolute path>'; 'config validate' runs the full set of checks."
+	return f
+}
+
+// canonicalPath resolves symlinks as far as the path exists, so that paths
+// reached through different links compare equal.
+func canonicalPath(p string) string {
+	p = filepath.Clean(p)
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	// Resolve the existing parent and re-attach the missing tail
+	parent, base := filepath.Split(p)
+	if parent == "" || filepath.Clean(parent) == p {
+		return p
+	}
+	return filepath.Join(canonicalPath(parent), base)
+}
+
+// canonicalLinkPath canonicalizes the directory holding p but not p itself,
+// for paths like MinecraftPath that may be a symlink to somewhere else.
+func canonicalLinkPath(p string) string {
+	return filepath.Join(canonicalPath(filepath.Dir(p)), filepath.Base(p))
+}
+
+// isWithin reports whether path equals dir or lies below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
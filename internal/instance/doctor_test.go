@@ -0,0 +1,42 @@
+//go:build unix
+
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepairBackupConflictAcrossFilesystems(t *testing.T) {
+	home := t.TempDir()
+	m := &Manager{
+		InstancesPath: filepath.Join(home, "instances"),
+		MinecraftPath: filepath.Join(home, ".minecraft"),
+		BackupPath:    filepath.Join(otherDevice(t, home), ".minecraft.backup"),
+	}
+	for _, dir := range []string{m.InstancesPath, m.MinecraftPath, m.BackupPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(m.BackupPath, "options.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := m.checkBackupConflict()
+	if f.Severity != SeverityError || f.Fix == nil {
+		t.Fatalf("checkBackupConflict = %+v, want an error with a fix", f)
+	}
+	if err := m.Repair(f); err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+
+	if _, err := os.Lstat(m.BackupPath); !os.IsNotExist(err) {
+		t.Errorf("backup still exists: %v", err)
+	}
+	recovered, _ := filepath.Glob(filepath.Join(m.InstancesPath, "recovered-backup-*", "options.txt"))
+	if len(recovered) != 1 {
+		t.Errorf("recovered instance files = %v, want one options.txt", recovered)
+	}
+}
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by Manager methods. Match them with
+// errors.Is; the error text itself is meant for humans and may change.
+var (
+	ErrEmptyName        = errors.New("empty instance name")
+	ErrReservedName     = errors.New("reserved instance name")
+	ErrInstanceExists   = errors.New("instance already exists")
+	ErrInstanceNotFound = errors.New("instance not found")
+	ErrInstanceActive   = errors.New("instance is active")
+	ErrUnknownConfigKey = errors.New("unknown config key")
+	ErrTrashNotFound    = errors.New("trash entry not found")
+	ErrForeignSymlink   = errors.New("minecraft directory is a foreign symlink")
+	ErrInvalidConfig    = errors.New("invalid configuration")
+	ErrContextNotFound  = errors.New("context not found")
+	ErrContextExists    = errors.New("context already exists")
+)
+
+// kindError carries a human-readable message and a sentinel kind that
+// errors.Is can match.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// errorOf formats a message like fmt.Errorf and tags it with kind.
+func errorOf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
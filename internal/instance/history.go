@@ -0,0 +1,230 @@
+package instance
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the switch log of each context, one JSON object per line.
+const HistoryFileName = "history.jsonl"
+
+// historyMaxBytes is the size at which the history is trimmed to its newest
+// half; an entry takes about 100 bytes.
+const (
+	historyMaxBytes  = 256 << 10
+	historyChunkSize = 8 << 10 // bytes read at a time, from the end
+)
+
+// DefaultInstanceName is what GetActiveInstance reports when MinecraftPath is
+// a real directory, and what the history records for RestoreDefault.
+const DefaultInstanceName = "default"
+
+// HistoryEntry records a single switch between instances.
+type HistoryEntry struct {
+	Time time.Time `json:"time" yaml:"time"`
+	From string    `json:"from" yaml:"from"`
+	To   string    `json:"to" yaml:"to"`
+	User string    `json:"user" yaml:"user"`
+}
+
+func (m *Manager) historyFile() string {
+	return filepath.Join(m.stateDir(), HistoryFileName)
+}
+
+// recordSwitch appends an entry to the history log. Logging is best effort:
+// the switch itself has already happened, so failures are ignored.
+func (m *Manager) recordSwitch(from, to string) {
+	if from == to {
+		return
+	}
+	entry := HistoryEntry{
+		Time: time.Now().UTC(),
+		From: from,
+		To:   to,
+		User: currentUsername(),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(m.historyFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	_, err = f.Write(append(data, '\n'))
+	info, statErr := f.Stat()
+	f.Close()
+	if err == nil && statErr == nil && info.Size() > historyMaxBytes {
+		m.trimHistory()
+	}
+}
+
+// trimHistory rewrites the history with only its newest entries, about
+// half of historyMaxBytes, through a temporary file so a failed trim leaves
+// it intact.
+func (m *Manager) trimHistory() {
+	f, err := os.Open(m.historyFile())
+	if err != nil {
+		return
+	}
+	var lines [][]byte
+	size := 0
+	err = readLinesBackward(f, func(line []byte) bool {
+		size += len(line) + 1
+		if size > historyMaxBytes/2 {
+			return false
+		}
+		lines = append(lines, line)
+		return true
+	})
+	f.Close()
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.historyFile()), "."+HistoryFileName+"-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for i := len(lines) - 1; i >= 0; i-- {
+		w.Write(lines[i])
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), m.historyFile())
+}
+
+// History returns up to limit switch entries, newest first. A limit of 0 or
+// less returns the whole log. The log is read from its end, so asking for
+// the last few entries does not read all of it. Malformed lines are skipped.
+func (m *Manager) History(limit int) ([]HistoryEntry, error) {
+	f, err := os.Open(m.historyFile())
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	entries := []HistoryEntry{}
+	err = readLinesBackward(f, func(line []byte) bool {
+		var e HistoryEntry
+		if err := json.Unmarshal(line, &e); err == nil {
+			entries = append(entries, e)
+		}
+		return limit <= 0 || len(entries) < limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// readLinesBackward calls fn with each non-empty line of f, last line
+// first, until fn returns false.
+func readLinesBackward(f *os.File, fn func(line []byte) bool) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	pos := info.Size()
+	var partial []byte // the start of a line that began before pos
+	for pos > 0 {
+		n := min(pos, historyChunkSize)
+		pos -= n
+		chunk := make([]byte, n, int(n)+len(partial))
+		if _, err := f.ReadAt(chunk, pos); err != nil {
+			return err
+		}
+		data := append(chunk, partial...)
+		for {
+			i := bytes.LastIndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if line := bytes.TrimSpace(data[i+1:]); len(line) > 0 && !fn(line) {
+				return nil
+			}
+			data = data[:i]
+		}
+		partial = data
+	}
+	if line := bytes.TrimSpace(partial); len(line) > 0 {
+		fn(line)
+	}
+	return nil
+}
+
+// PreviousInstance returns the instance that was active before the most
+// recent switch, like `cd -`. It returns DefaultInstanceName if the default
+// directory was active then.
+func (m *Manager) PreviousInstance() (string, error) {
+	entries, err := m.History(1)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no previous instance in history")
+	}
+	if entries[0].From == "" {
+		return "", fmt.Errorf("no instance was active before the last switch")
+	}
+	return entries[0].From, nil
+}
+
+// SwitchToPrevious switches back to the previously active instance, or
+// restores the default directory if that was active, and returns its name.
+func (m *Manager) SwitchToPrevious() (string, error) {
+	prev, err := m.PreviousInstance()
+	if err != nil {
+		return "", err
+	}
+	if prev == DefaultInstanceName {
+		return prev, m.RestoreDefault()
+	}
+	return prev, m.SwitchInstance(prev)
+}
+
+// LastSwitchTimes returns, per instance, the last time it was switched to
+// or away from. Instances that never appear in the history are absent.
+func (m *Manager) LastSwitchTimes() map[string]time.Time {
+	times := make(map[string]time.Time)
+	entries, err := m.History(0)
+	if err != nil {
+		return times
+	}
+	// Entries are newest first, so the first sighting wins
+	for _, e := range entries {
+		for _, name := range []string{e.From, e.To} {
+			if _, ok := times[name]; !ok {
+				times[name] = e.Time
+			}
+		}
+	}
+	return times
+}
+
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,106 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryNewestFirst(t *testing.T) {
+	m := &Manager{AppDir: t.TempDir()}
+	// Enough entries that lines cross the chunks History reads
+	for i := range 300 {
+		m.recordSwitch(fmt.Sprint("i", i), fmt.Sprint("i", i+1))
+	}
+
+	entries, err := m.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 300 {
+		t.Fatalf("History(0) returned %d entries, want 300", len(entries))
+	}
+	for i, e := range entries {
+		if want := fmt.Sprint("i", 300-i); e.To != want {
+			t.Fatalf("entry %d goes to %s, want %s", i, e.To, want)
+		}
+	}
+
+	entries, err = m.History(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].To != "i300" || entries[2].To != "i298" {
+		t.Errorf("History(3) = %+v", entries)
+	}
+	if prev, err := m.PreviousInstance(); err != nil || prev != "i299" {
+		t.Errorf("PreviousInstance = %q, %v, want i299", prev, err)
+	}
+}
+
+func TestHistorySkipsMalformedLines(t *testing.T) {
+	m := &Manager{AppDir: t.TempDir()}
+	log := `{"time":"2026-01-01T00:00:00Z","from":"a","to":"b"}` + "\n" +
+		"not json\n\n" +
+		`{"time":"2026-01-02T00:00:00Z","from":"b","to":"c"}` + "\n" +
+		`{"time":"2026-01-03T00:00:00Z","from":"c",` // cut off by a crash
+	if err := os.WriteFile(m.historyFile(), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := m.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].To != "c" || entries[1].To != "b" {
+		t.Errorf("History(0) = %+v, want the switches to c and b", entries)
+	}
+}
+
+func TestHistoryIsTrimmed(t *testing.T) {
+	m := &Manager{AppDir: t.TempDir()}
+	long := strings.Repeat("x", 200)
+	for i := range 2 * historyMaxBytes / 250 {
+		m.recordSwitch(fmt.Sprint(long, i), fmt.Sprint(long, i+1))
+	}
+
+	info, err := os.Stat(m.historyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > historyMaxBytes {
+		t.Errorf("history is %d bytes, want at most %d", info.Size(), historyMaxBytes)
+	}
+	entries, err := m.History(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("trimming removed every entry")
+	}
+	if want := fmt.Sprint(long, 2*historyMaxBytes/250); entries[0].To != want {
+		t.Errorf("newest entry goes to %.20s..., want the last switch", entries[0].To)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(m.AppDir, ".*")); len(leftovers) > 0 {
+		t.Errorf("trimming left %v behind", leftovers)
+	}
+}
+
+func TestCreateInstanceReservesDefault(t *testing.T) {
+	home := t.TempDir()
+	m := &Manager{
+		InstancesPath: filepath.Join(home, "instances"),
+		MinecraftPath: filepath.Join(home, ".minecraft"),
+	}
+	if err := m.CreateInstance(DefaultInstanceName); !errors.Is(err, ErrReservedName) {
+		t.Errorf("CreateInstance(%q) = %v, want ErrReservedName", DefaultInstanceName, err)
+	}
+	if _, err := m.PlanCreate(DefaultInstanceName); !errors.Is(err, ErrReservedName) {
+		t.Errorf("PlanCreate(%q) = %v, want ErrReservedName", DefaultInstanceName, err)
+	}
+	if _, err := os.Stat(m.InstancePath(DefaultInstanceName)); !os.IsNotExist(err) {
+		t.Errorf("instance folder was created: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// EnvPrefix prefixes the environment variables that override config keys,
+// e.g. MCIM_INSTANCES_PATH for instances-path.
+const EnvPrefix = "MCIM_"
+
+// ConfigFileEnv relocates the config file like the --config flag.
+const ConfigFileEnv = EnvPrefix + "CONFIG"
+
+// Layers a config value can come from, highest precedence first.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// SettingKeys are the config keys that every layer can set.
+var SettingKeys = []string{"minecraft-path", "instances-path", "backup-path", "trash-retention-days", "relative-symlinks"}
+
+var configKeyAliases = map[string]string{
+	"minecraft":       "minecraft-path",
+	"minecraft-dir":   "minecraft-path",
+	"instances":       "instances-path",
+	"instances-dir":   "instances-path",
+	"backup":          "backup-path",
+	"backup-dir":      "backup-path",
+	"trash-retention": "trash-retention-days",
+}
+
+// CanonicalConfigKey resolves the short aliases accepted by the config
+// command, e.g. "instances" for "instances-path".
+func CanonicalConfigKey(key string) string {
+	if canonical, ok := configKeyAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(CanonicalConfigKey(key), "-", "_"))
+}
+
+// Options adjusts how NewManagerWithOptions builds the configuration.
+type Options struct {
+	// ConfigFile replaces the default config.json. A .yaml or .yml
+	// extension selects YAML.
+	ConfigFile string
+	// Context selects a named context instead of the file's current one.
+	Context string
+	// Flags holds command-line values by config key. They take precedence
+	// over everything else and are never written to the config file.
+	Flags map[string]string
+	// AllowInvalid returns a Manager even if ValidateConfig reports
+	// problems, so that doctor and config can show and repair them. The
+	// instances directory is not created then.
+	AllowInvalid bool
+	// LookupEnv reads the environment; nil means os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+}
+
+func (o Options) lookupEnv(name string) (string, bool) {
+	if o.LookupEnv != nil {
+		return o.LookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// ConfigSource tells where an effective config value came from.
+type ConfigSource struct {
+	Layer  string `json:"layer" yaml:"layer"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"` // flag, variable or file name
+}
+
+func (s ConfigSource) String() string {
+	if s.Detail == "" {
+		return s.Layer
+	}
+	return s.Layer + " " + s.Detail
+}
+
+// ConfigSource reports which layer the effective value of key comes from.
+func (m *Manager) ConfigSource(key string) ConfigSource {
+	key = CanonicalConfigKey(key)
+	switch key {
+	case "config-file":
+		return m.configFileSource
+	case "context":
+		return m.contextSource
+	case "app-dir":
+		return ConfigSource{Layer: SourceDefault}
+	}
+	if src, ok := m.overrides[key]; ok {
+		return src
+	}
+	if m.fileValue(key) != "" {
+		if m.Context != DefaultContextName && isPathKey(key) {
+			return ConfigSource{Layer: SourceFile, Detail: m.ConfigFile + ", context " + m.Context}
+		}
+		return ConfigSource{Layer: SourceFile, Detail: m.ConfigFile}
+	}
+	return ConfigSource{Layer: SourceDefault}
+}
+
+// applyOverrides layers the environment and then the flags over the values
+// loaded from the config file.
+func (m *Manager) applyOverrides(opts Options) error {
+	for _, key := range SettingKeys {
+		name := EnvName(key)
+		value, ok := opts.lookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := m.applyConfigValue(key, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		m.overrides[key] = ConfigSource{Layer: SourceEnv, Detail: name}
+	}
+	for key, value := range opts.Flags {
+		key = CanonicalConfigKey(key)
+		if err := m.applyConfigValue(key, value); err != nil {
+			return fmt.Errorf("invalid --%s: %w", key, err)
+		}
+		m.overrides[key] = ConfigSource{Layer: SourceFlag, Detail: "--" + key}
+	}
+	return nil
+}
+
+// fileValue returns the value of key in the file layer, or "" if the file
+// does not set it.
+func (m *Manager) fileValue(key string) string {
+	switch key {
+	case "minecraft-path":
+		return m.contextPaths().MinecraftPath
+	case "instances-path":
+		return m.contextPaths().InstancesPath
+	case "backup-path":
+		return m.contextPaths().BackupPath
+	case "trash-retention-days":
+		if m.file.TrashRetentionDays > 0 {
+			return strconv.Itoa(m.file.TrashRetentionDays)
+		}
+	case "relative-symlinks":
+		if m.file.RelativeSymlinks {
+			return "true"
+		}
+	}
+	return ""
+}
+
+// syncFileValue copies the effective value of key into the file layer. Paths
+// go to the active context.
+func (m *Manager) syncFileValue(key string) {
+	p := m.contextPaths()
+	switch key {
+	case "minecraft-path":
+		p.MinecraftPath = m.MinecraftPath
+		m.setContextPaths(p)
+	case "instances-path":
+		p.InstancesPath = m.InstancesPath
+		m.setContextPaths(p)
+	case "backup-path":
+		p.BackupPath = m.BackupPath
+		m.setContextPaths(p)
+	case "trash-retention-days":
+		m.file.TrashRetentionDays = m.cfg.TrashRetentionDays
+	case "relative-symlinks":
+		m.file.RelativeSymlinks = m.cfg.RelativeSymlinks
+	}
+}
+
+// isPathKey reports whether key is one of the paths each context has.
+func isPathKey(key string) bool {
+	return key == "minecraft-path" || key == "instances-path" || key == "backup-path"
+}
+
+func (m *Manager) configIsYAML() bool {
+	ext := strings.ToLower(filepath.Ext(m.ConfigFile))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// decodeConfig parses the config file into a generic object, the form the
+// schema migrations work on.
+func (m *Manager) decodeConfig(data []byte) (map[string]any, error) {
+	raw := map[string]any{}
+	var err error
+	if m.configIsYAML() {
+		err = yaml.Unmarshal(data, &raw)
+	} else {
+		err = json.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return raw, nil
+}
+
+func (m *Manager) encodeConfig(v any) ([]byte, error) {
+	if m.configIsYAML() {
+		return yaml.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// configFromMap converts a decoded config file into a Config. Going through
+// JSON makes YAML and JSON files behave the same.
+func configFromMap(raw map[string]any) (Config, error) {
+	var cfg Config
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return cfg, nil
+}
+
+// readConfigFile returns the file layer exactly as stored, without
+// migrations, expansion or defaults.
+func (m *Manager) readConfigFile() (Config, error) {
+	data, err := os.ReadFile(m.ConfigFile)
+	if err != nil {
+		return Config{}, err
+	}
+	raw, err := m.decodeConfig(data)
+	if err != nil {
+		return Config{}, err
+	}
+	return configFromMap(raw)
+}
+
+// writeConfigFile persists the file layer as it is.
+func (m *Manager) writeConfigFile() error {
+	m.file.Version = ConfigVersion
+	data, err := m.encodeConfig(m.file)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(m.ConfigFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
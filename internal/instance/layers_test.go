@@ -0,0 +1,89 @@
+package instance
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	home, configFile := testHome(t)
+	path := func(layer, key string) string { return filepath.Join(home, layer, key) }
+
+	writeConfig(t, configFile, fmt.Sprintf(`{"version": %d, "instances_path": %q, "backup_path": %q, "trash_retention_days": 14}`,
+		ConfigVersion, path("file", "instances"), path("file", "backup")))
+	env := map[string]string{
+		"MCIM_INSTANCES_PATH": path("env", "instances"),
+		"MCIM_BACKUP_PATH":    path("env", "backup"),
+		"MCIM_MINECRAFT_PATH": "", // empty variables do not count
+	}
+	opts := Options{
+		LookupEnv: func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		},
+		Flags: map[string]string{
+			"instances":      path("flag", "instances"), // aliases work for flags too
+			"minecraft-path": path("flag", ".minecraft"),
+		},
+	}
+	m, err := NewManagerWithOptions(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, value, layer, detail string
+	}{
+		{"instances-path", path("flag", "instances"), SourceFlag, "--instances-path"},
+		{"minecraft-path", path("flag", ".minecraft"), SourceFlag, "--minecraft-path"},
+		{"backup-path", path("env", "backup"), SourceEnv, "MCIM_BACKUP_PATH"},
+		{"trash-retention-days", "14", SourceFile, configFile},
+		{"relative-symlinks", "false", SourceDefault, ""},
+	}
+	config := m.GetConfig()
+	for _, tt := range tests {
+		if config[tt.key] != tt.value {
+			t.Errorf("%s = %q, want %q", tt.key, config[tt.key], tt.value)
+		}
+		if src := m.ConfigSource(tt.key); src != (ConfigSource{Layer: tt.layer, Detail: tt.detail}) {
+			t.Errorf("%s comes from %s, want %s %s", tt.key, src, tt.layer, tt.detail)
+		}
+	}
+
+	// Overrides apply to this run only
+	cfg, err := m.readConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.InstancesPath != path("file", "instances") || cfg.BackupPath != path("file", "backup") || cfg.MinecraftPath != "" {
+		t.Errorf("config file = %+v, want the overrides left out", cfg)
+	}
+
+	// Without flags the environment wins, and without both the file
+	opts.Flags = nil
+	if m, err = NewManagerWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if m.InstancesPath != path("env", "instances") {
+		t.Errorf("instances-path without the flag = %q", m.InstancesPath)
+	}
+	opts.LookupEnv = noEnv
+	if m, err = NewManagerWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if m.InstancesPath != path("file", "instances") || m.ConfigSource("instances-path").Layer != SourceFile {
+		t.Errorf("instances-path from the file = %q (%s)", m.InstancesPath, m.ConfigSource("instances-path"))
+	}
+
+	// Invalid overrides are refused instead of falling through to a lower layer
+	opts.Flags = map[string]string{"trash-retention-days": "soon"}
+	if _, err := NewManagerWithOptions(opts); err == nil {
+		t.Error("an invalid flag was accepted")
+	}
+	opts.Flags = nil
+	opts.LookupEnv = func(name string) (string, bool) { return "maybe", name == "MCIM_RELATIVE_SYMLINKS" }
+	if _, err := NewManagerWithOptions(opts); err == nil {
+		t.Error("an invalid environment variable was accepted")
+	}
+}
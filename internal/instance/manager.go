@@ -1,14 +1,18 @@
 package instance
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
+	"os/user"
+	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -19,9 +23,16 @@ const (
 )
 
 type Config struct {
-	InstancesPath string `json:"instances_path"`
-	MinecraftPath string `json:"minecraft_path"`
-	BackupPath    string `json:"backup_path"`
+	Version            int    `json:"version" yaml:"version"`
+	InstancesPath      string `json:"instances_path" yaml:"instances_path"`
+	MinecraftPath      string `json:"minecraft_path" yaml:"minecraft_path"`
+	BackupPath         string `json:"backup_path" yaml:"backup_path"`
+	TrashRetentionDays int    `json:"trash_retention_days,omitempty" yaml:"trash_retention_days,omitempty"`
+	RelativeSymlinks   bool   `json:"relative_symlinks,omitempty" yaml:"relative_symlinks,omitempty"`
+	// CurrentContext selects one of Contexts; empty means DefaultContextName,
+	// whose paths are the three above
+	CurrentContext string                   `json:"current_context,omitempty" yaml:"current_context,omitempty"`
+	Contexts       map[string]ContextConfig `json:"contexts,omitempty" yaml:"contexts,omitempty"`
 }
 
 type Manager struct {
@@ -31,26 +42,76 @@ type Manager struct {
 	InstancesPath string
 	MinecraftPath string
 	BackupPath    string
-	cfg           Config
+	// Context is the name of the active context; the paths above are its own
+	Context string
+	// cfg holds the effective settings, file only what the config file
+	// stores; they differ where a flag or environment variable overrides
+	cfg              Config
+	file             Config
+	overrides        map[string]ConfigSource
+	configFileSource ConfigSource
+	contextSource    ConfigSource
 }
 
 type Instance struct {
-	Name        string
-	Path        string
-	ModCount    int
-	ConfigCount int
-	SaveCount   int
-	IsActive    bool
+	Name              string
+	Path              string
+	ModCount          int
+	ConfigCount       int
+	SaveCount         int
+	ResourcePackCount int
+	ShaderPackCount   int
+	IsActive          bool
 }
 
 type InstanceInfo struct {
-	ModsDir    []string
-	ConfigsDir []string
-	SavesDir   []string
-	OtherFiles []string
+	ModsDir          []string
+	ConfigsDir       []string     // config files, including those in subfolders
+	ConfigFiles      []ConfigFile // the config folder's tree, depth first
+	SavesDir         []string
+	ResourcePacksDir []string
+	ShaderPacksDir   []string
+	OtherFiles       []string
 }
 
+// ConfigFile is a file or folder below an instance's config folder.
+type ConfigFile struct {
+	Path    string // relative to the config folder, with forward slashes
+	IsDir   bool
+	Size    int64 // for folders, the size of everything below them
+	ModTime time.Time
+}
+
+// Name returns the last element of the path.
+func (f ConfigFile) Name() string {
+	return path.Base(f.Path)
+}
+
+// Depth returns how many folders deep below config/ the entry is.
+func (f ConfigFile) Depth() int {
+	return strings.Count(f.Path, "/")
+}
+
+// InstanceStats holds the more expensive facts about an instance that
+// require walking its whole tree.
+type InstanceStats struct {
+	Size     int64            // total size in bytes
+	Sizes    map[string]int64 // size per entry of InstanceSections
+	LastUsed time.Time        // zero if the instance was never launched
+}
+
+// InstanceSections are the per-instance folders reported by GetInstanceInfo.
+var InstanceSections = []string{"mods", "config", "saves", "resourcepacks", "shaderpacks"}
+
+// NewManager builds a Manager from the default config file, overridden by
+// MCIM_* environment variables.
 func NewManager() (*Manager, error) {
+	return NewManagerWithOptions(Options{})
+}
+
+// NewManagerWithOptions builds a Manager whose settings are layered as
+// flags > environment > config file > defaults.
+func NewManagerWithOptions(opts Options) (*Manager, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
@@ -67,6 +128,20 @@ func NewManager() (*Manager, error) {
 	}
 
 	configFile := filepath.Join(appDir, "config.json")
+	configFileSource := ConfigSource{Layer: SourceDefault}
+	if opts.ConfigFile != "" {
+		configFile = opts.ConfigFile
+		configFileSource = ConfigSource{Layer: SourceFlag, Detail: "--config"}
+	} else if v, ok := opts.lookupEnv(ConfigFileEnv); ok && v != "" {
+		configFile = v
+		configFileSource = ConfigSource{Layer: SourceEnv, Detail: ConfigFileEnv}
+	}
+	if configFile, err = filepath.Abs(expandPath(configFile)); err != nil {
+		return nil, fmt.Errorf("failed to resolve config file path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config file directory: %w", err)
+	}
 
 	// Get platform-specific default Minecraft path
 	defaultMinecraftPath, err := getDefaultMinecraftPath()
@@ -90,31 +165,50 @@ func NewManager() (*Manager, error) {
 			MinecraftPath: defaultMinecraftPath,
 			BackupPath:    defaultBackupPath,
 		},
+		overrides:        make(map[string]ConfigSource),
+		configFileSource: configFileSource,
+		contextSource:    ConfigSource{Layer: SourceDefault},
+	}
+	if opts.Context != "" {
+		m.Context = opts.Context
+		m.contextSource = ConfigSource{Layer: SourceFlag, Detail: "--context"}
+	} else if v, ok := opts.lookupEnv(ContextEnv); ok && v != "" {
+		m.Context = v
+		m.contextSource = ConfigSource{Layer: SourceEnv, Detail: ContextEnv}
 	}
 
 	// Load config if exists, otherwise create it with defaults
 	if err := m.loadConfig(); err != nil {
-		// if load failed because file doesn't exist, save defaults
-		if os.IsNotExist(err) {
-			if err := os.MkdirAll(m.InstancesPath, 0755); err != nil {
-				return nil, fmt.Errorf("failed to create default instances dir: %w", err)
-			}
-			if err := m.saveConfig(); err != nil {
-				return nil, fmt.Errorf("failed to write default config: %w", err)
-			}
-		} else {
+		if !os.IsNotExist(err) {
 			return nil, err
 		}
-	} else {
-		// apply loaded config
-		m.InstancesPath = m.cfg.InstancesPath
-		m.MinecraftPath = m.cfg.MinecraftPath
-		m.BackupPath = m.cfg.BackupPath
-		// ensure instances dir exists
-		if err := os.MkdirAll(m.InstancesPath, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create instances dir from config: %w", err)
+		if err := m.applyContext(); err != nil {
+			return nil, err
+		}
+		if err := m.saveConfig(); err != nil {
+			return nil, fmt.Errorf("failed to write default config: %w", err)
 		}
 	}
+	if err := os.MkdirAll(m.stateDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create context state dir: %w", err)
+	}
+
+	if err := m.applyOverrides(opts); err != nil {
+		return nil, err
+	}
+
+	// Refuse relative or conflicting paths before anything is created there
+	if problems := m.ValidateConfig(); len(problems) > 0 {
+		if opts.AllowInvalid {
+			return m, nil
+		}
+		return nil, fmt.Errorf("%w (run 'config validate' or 'doctor' for details)", problemsError(problems))
+	}
+
+	// ensure instances dir exists
+	if err := os.MkdirAll(m.InstancesPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create instances dir: %w", err)
+	}
 
 	return m, nil
 }
@@ -124,59 +218,62 @@ func (m *Manager) loadConfig() error {
 	if err != nil {
 		return err
 	}
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+	raw, err := m.decodeConfig(data)
+	if err != nil {
+		return err
 	}
-
-	// expand ~ in paths
-	cfg.InstancesPath = expandPath(cfg.InstancesPath)
-	cfg.MinecraftPath = expandPath(cfg.MinecraftPath)
-	cfg.BackupPath = expandPath(cfg.BackupPath)
-
-	// if any are empty, set defaults relative to app dir / platform-specific paths
-	if cfg.InstancesPath == "" {
-		cfg.InstancesPath = filepath.Join(m.AppDir, "instances")
+	changed, err := migrateConfig(raw)
+	if err != nil {
+		return err
 	}
-	if cfg.MinecraftPath == "" {
-		if defaultPath, err := getDefaultMinecraftPath(); err == nil {
-			cfg.MinecraftPath = defaultPath
-		} else {
-			// Fallback to the old behavior if detection fails
-			cfg.MinecraftPath = filepath.Join(m.HomeDir, MinecraftDir)
+	if changed {
+		migrated, err := m.encodeConfig(raw)
+		if err != nil {
+			return fmt.Errorf("failed to encode migrated config: %w", err)
+		}
+		// Keep the original next to the upgraded file in case of a downgrade
+		if err := os.WriteFile(m.ConfigFile+".bak", data, 0644); err != nil {
+			return fmt.Errorf("failed to back up config before migrating: %w", err)
+		}
+		if err := os.WriteFile(m.ConfigFile, migrated, 0644); err != nil {
+			return fmt.Errorf("failed to write migrated config: %w", err)
 		}
 	}
-	if cfg.BackupPath == "" {
-		cfg.BackupPath = filepath.Join(m.AppDir, "backup")
+	cfg, err := configFromMap(raw)
+	if err != nil {
+		return err
 	}
-
+	m.file = cfg
 	m.cfg = cfg
-	return nil
+	return m.applyContext()
 }
 
+// saveConfig writes the effective settings to the config file, except those
+// overridden by a flag or environment variable, which keep their file value.
 func (m *Manager) saveConfig() error {
-	m.cfg.InstancesPath = m.InstancesPath
-	m.cfg.MinecraftPath = m.MinecraftPath
-	m.cfg.BackupPath = m.BackupPath
-
-	data, err := json.MarshalIndent(m.cfg, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to encode config: %w", err)
-	}
-	if err := os.WriteFile(m.ConfigFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	for _, key := range SettingKeys {
+		if _, overridden := m.overrides[key]; !overridden {
+			m.syncFileValue(key)
+		}
 	}
-	return nil
+	return m.writeConfigFile()
 }
 
+// expandPath expands a leading "~" or "~user". Paths for unknown users are
+// returned unchanged; doctor reports them.
 func expandPath(p string) string {
-	if p == "" {
+	if !strings.HasPrefix(p, "~") {
 		return p
 	}
-	if strings.HasPrefix(p, "~") {
+	name, rest, _ := strings.Cut(p[1:], "/")
+	if name == "" {
 		if home, err := os.UserHomeDir(); err == nil {
-			return filepath.Join(home, strings.TrimPrefix(p, "~"))
+			return filepath.Join(home, rest)
 		}
+		return p
+	}
+	if u, err := user.Lookup(name); err == nil {
+		return filepath.Join(u.HomeDir, rest)
 	}
 	return p
 }
@@ -217,23 +314,31 @@ func getDefaultMinecraftPath() (string, error) {
 	}
 }
 
-// UpdateConfig updates one of the supported config keys and persists the file.
-// Supported keys: "minecraft-path", "instances-path", "backup-path"
+// UpdateConfig updates one of the SettingKeys and persists the file. If a
+// flag or environment variable overrides the key, only the file changes and
+// the override stays in effect; ConfigSource tells which applies.
 func (m *Manager) UpdateConfig(key, value string) error {
-	value = expandPath(value)
-	switch key {
-	case "minecraft-path", "minecraft-dir", "minecraft":
-		m.MinecraftPath = value
-	case "instances-path", "instances-dir", "instances":
-		m.InstancesPath = value
+	key = CanonicalConfigKey(key)
+	if err := m.ValidateConfigValue(key, value); err != nil {
+		return err
+	}
+	if _, overridden := m.overrides[key]; overridden {
+		probe := *m
+		if err := probe.applyConfigValue(key, value); err != nil {
+			return err
+		}
+		probe.syncFileValue(key)
+		m.file = probe.file
+		return m.writeConfigFile()
+	}
+	if err := m.applyConfigValue(key, value); err != nil {
+		return err
+	}
+	if isInstancesKey(key) {
 		// ensure instances dir exists
 		if err := os.MkdirAll(m.InstancesPath, 0755); err != nil {
 			return fmt.Errorf("failed to create instances dir: %w", err)
 		}
-	case "backup-path", "backup-dir", "backup":
-		m.BackupPath = value
-	default:
-		return fmt.Errorf("unknown config key: %s", key)
 	}
 	if err := m.saveConfig(); err != nil {
 		return err
@@ -241,27 +346,104 @@ func (m *Manager) UpdateConfig(key, value string) error {
 	return nil
 }
 
+// applyConfigValue validates and sets a config key in memory only.
+func (m *Manager) applyConfigValue(key, value string) error {
+	value = expandPath(value)
+	switch CanonicalConfigKey(key) {
+	case "minecraft-path":
+		m.MinecraftPath = value
+	case "instances-path":
+		m.InstancesPath = value
+	case "backup-path":
+		m.BackupPath = value
+	case "trash-retention-days":
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			return fmt.Errorf("trash-retention-days must be a positive number of days")
+		}
+		m.cfg.TrashRetentionDays = days
+	case "relative-symlinks":
+		relative, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("relative-symlinks must be true or false")
+		}
+		m.cfg.RelativeSymlinks = relative
+	default:
+		return errorOf(ErrUnknownConfigKey, "unknown config key: %s", key)
+	}
+	return nil
+}
+
+func isInstancesKey(key string) bool {
+	return CanonicalConfigKey(key) == "instances-path"
+}
+
+// ConfigKeys lists the keys returned by GetConfig in display order.
+var ConfigKeys = []string{"context", "minecraft-path", "instances-path", "backup-path", "trash-retention-days", "relative-symlinks", "app-dir", "config-file"}
+
 // GetConfig returns current configuration as a map
 func (m *Manager) GetConfig() map[string]string {
 	return map[string]string{
-		"minecraft-path": m.MinecraftPath,
-		"instances-path": m.InstancesPath,
-		"backup-path":    m.BackupPath,
-		"app-dir":        m.AppDir,
-		"config-file":    m.ConfigFile,
+		"context":              m.Context,
+		"minecraft-path":       m.MinecraftPath,
+		"instances-path":       m.InstancesPath,
+		"backup-path":          m.BackupPath,
+		"trash-retention-days": strconv.Itoa(int(m.TrashRetention().Hours() / 24)),
+		"relative-symlinks":    strconv.FormatBool(m.cfg.RelativeSymlinks),
+		"app-dir":              m.AppDir,
+		"config-file":          m.ConfigFile,
 	}
 }
 
+// InstancePath returns the directory of the named instance.
+func (m *Manager) InstancePath(name string) string {
+	return filepath.Join(m.InstancesPath, name)
+}
+
+// SnapshotsDirName is the folder inside the context's state directory that
+// holds world snapshots, one subfolder per instance.
+const SnapshotsDirName = "snapshots"
+
+// SnapshotPath returns the snapshot store of the named instance. It lives
+// outside the instance so that deleting or switching instances leaves the
+// snapshots alone.
+func (m *Manager) SnapshotPath(name string) string {
+	return filepath.Join(m.stateDir(), SnapshotsDirName, name)
+}
+
+// LookupInstance returns the directory of an existing instance.
+func (m *Manager) LookupInstance(name string) (string, error) {
+	if name == "" {
+		return "", errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
+	path := m.InstancePath(name)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
+	}
+	return path, nil
+}
+
 func (m *Manager) CreateInstance(name string) error {
+	return m.CreateInstanceContext(context.Background(), name, nil)
+}
+
+// CreateInstanceContext creates an instance like CreateInstance, reporting copy
+// progress to progress (which may be nil) and stopping when ctx is cancelled.
+// A partially copied instance is removed again on failure.
+func (m *Manager) CreateInstanceContext(ctx context.Context, name string, progress ProgressFunc) (err error) {
 	if name == "" {
-		return fmt.Errorf("instance name cannot be empty")
+		return errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
+	if name == DefaultInstanceName {
+		// The history and `switch -` use this name for the default directory
+		return errorOf(ErrReservedName, "'%s' is reserved for the default minecraft directory", name)
 	}
 
 	instancePath := filepath.Join(m.InstancesPath, name)
 
 	// Check if instance already exists
 	if _, err := os.Stat(instancePath); err == nil {
-		return fmt.Errorf("instance '%s' already exists", name)
+		return errorOf(ErrInstanceExists, "instance '%s' already exists", name)
 	}
 
 	// Create instances directory if it doesn't exist
@@ -273,19 +455,26 @@ func (m *Manager) CreateInstance(name string) error {
 	if err := os.MkdirAll(instancePath, 0755); err != nil {
 		return fmt.Errorf("failed to create instance directory: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(instancePath)
+		}
+	}()
+
+	opts := CopyOptions{Exclude: DefaultCopyExcludes, Progress: progress}
 
 	// Copy base minecraft structure if it exists and is not a symlink
 	if info, err := os.Lstat(m.MinecraftPath); err == nil {
 		// If it's a symlink, resolve it and copy from the actual directory
 		if info.Mode()&os.ModeSymlink != 0 {
-			if target, err := os.Readlink(m.MinecraftPath); err == nil {
-				if err := copyDir(target, instancePath); err != nil {
+			if target, err := m.readLink(); err == nil {
+				if err := CopyTree(ctx, target, instancePath, opts); err != nil {
 					return fmt.Errorf("failed to copy minecraft directory: %w", err)
 				}
 			}
 		} else {
 			// It's a regular directory
-			if err := copyDir(m.MinecraftPath, instancePath); err != nil {
+			if err := CopyTree(ctx, m.MinecraftPath, instancePath, opts); err != nil {
 				return fmt.Errorf("failed to copy minecraft directory: %w", err)
 			}
 		}
@@ -304,59 +493,62 @@ func (m *Manager) CreateInstance(name string) error {
 }
 
 func (m *Manager) SwitchInstance(name string) error {
-	if name == "" {
-		return fmt.Errorf("instance name cannot be empty")
-	}
+	return m.switchInstance(name, false)
+}
 
-	instancePath := filepath.Join(m.InstancesPath, name)
+// ForceSwitchInstance switches like SwitchInstance but also replaces a
+// foreign symlink. The link's target itself is left untouched.
+func (m *Manager) ForceSwitchInstance(name string) error {
+	return m.switchInstance(name, true)
+}
 
-	// Check if instance exists
-	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
-		return fmt.Errorf("instance '%s' does not exist", name)
+func (m *Manager) switchInstance(name string, force bool) error {
+	plan, err := m.PlanSwitch(name, force)
+	if err != nil {
+		return err
 	}
 
-	// Backup current minecraft directory if it exists and is not a symlink
-	if info, err := os.Lstat(m.MinecraftPath); err == nil {
-		if info.Mode()&os.ModeSymlink == 0 {
-			// It's a regular directory, back it up
-			if err := os.RemoveAll(m.BackupPath); err != nil {
-				return fmt.Errorf("failed to remove old backup: %w", err)
-			}
-			if err := os.Rename(m.MinecraftPath, m.BackupPath); err != nil {
-				return fmt.Errorf("failed to backup minecraft directory: %w", err)
-			}
-		} else {
-			// It's already a symlink, just remove it
-			if err := os.Remove(m.MinecraftPath); err != nil {
-				return fmt.Errorf("failed to remove existing symlink: %w", err)
-			}
-		}
-	}
+	previous := m.GetActiveInstance()
 
-	// Create symlink to instance
-	if err := os.Symlink(instancePath, m.MinecraftPath); err != nil {
-		return fmt.Errorf("failed to create symlink: %w", err)
+	// Back up a real minecraft directory (or drop the old symlink) and link
+	// the instance in its place
+	if err := m.execute(context.Background(), plan, nil); err != nil {
+		return err
 	}
 
+	m.recordSwitch(previous, name)
 	return nil
 }
 
 func (m *Manager) RestoreDefault() error {
-	// Check if minecraft path is a symlink
-	if info, err := os.Lstat(m.MinecraftPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
-		// Remove the symlink
-		if err := os.Remove(m.MinecraftPath); err != nil {
-			return fmt.Errorf("failed to remove symlink: %w", err)
-		}
+	return m.RestoreDefaultContext(context.Background(), nil)
+}
+
+// RestoreDefaultContext restores the default directory like RestoreDefault.
+// Progress is only reported when the backup has to be copied because it lives
+// on a different filesystem than MinecraftPath.
+func (m *Manager) RestoreDefaultContext(ctx context.Context, progress ProgressFunc) error {
+	plan, err := m.PlanRestore()
+	if err != nil {
+		return err
 	}
 
-	// Restore backup if it exists
-	if _, err := os.Stat(m.BackupPath); err == nil {
-		if err := os.Rename(m.BackupPath, m.MinecraftPath); err != nil {
-			return fmt.Errorf("failed to restore backup: %w", err)
+	previous := m.GetActiveInstance()
+	link, _ := os.Readlink(m.MinecraftPath)
+
+	// Remove the symlink and move the backup back into place. If the move
+	// fails or is cancelled, the symlink is put back so the game still finds
+	// a .minecraft; the backup stays where it was.
+	if err := m.execute(ctx, plan, progress); err != nil {
+		if _, statErr := os.Lstat(m.MinecraftPath); link != "" && os.IsNotExist(statErr) {
+			if linkErr := os.Symlink(link, m.MinecraftPath); linkErr != nil {
+				return fmt.Errorf("%w; restoring the symlink to %s also failed: %v", err, link, linkErr)
+			}
 		}
+		return err
 	}
 
+	m.recordSwitch(previous, DefaultInstanceName)
 	return nil
 }
 
@@ -403,6 +595,10 @@ func (m *Manager) ListInstances() ([]Instance, error) {
 		savesPath := filepath.Join(instancePath, "saves")
 		instance.SaveCount = countDirectories(savesPath)
 
+		// Count resource and shader packs, which are zips or folders
+		instance.ResourcePackCount = countEntries(filepath.Join(instancePath, "resourcepacks"))
+		instance.ShaderPackCount = countEntries(filepath.Join(instancePath, "shaderpacks"))
+
 		instances = append(instances, instance)
 	}
 
@@ -414,20 +610,11 @@ func (m *Manager) ListInstances() ([]Instance, error) {
 	return instances, nil
 }
 
-func (m *Manager) GetActiveInstance() string {
-	if info, err := os.Lstat(m.MinecraftPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
-		if target, err := os.Readlink(m.MinecraftPath); err == nil {
-			return filepath.Base(target)
-		}
-	}
-	return "default"
-}
-
 func (m *Manager) GetInstanceInfo(name string) (*InstanceInfo, error) {
 	instancePath := filepath.Join(m.InstancesPath, name)
 
 	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("instance '%s' does not exist", name)
+		return nil, errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
 	}
 
 	info := &InstanceInfo{}
@@ -438,73 +625,88 @@ func (m *Manager) GetInstanceInfo(name string) (*InstanceInfo, error) {
 
 	// Get configs
 	configPath := filepath.Join(instancePath, "config")
-	info.ConfigsDir = getConfigFiles(configPath)
+	info.ConfigFiles = getConfigFiles(configPath)
+	for _, f := range info.ConfigFiles {
+		if !f.IsDir {
+			info.ConfigsDir = append(info.ConfigsDir, f.Path)
+		}
+	}
 
 	// Get saves
 	savesPath := filepath.Join(instancePath, "saves")
 	info.SavesDir = getDirectoryNames(savesPath)
 
+	// Resource and shader packs may be zip files or folders
+	info.ResourcePacksDir = getEntryNames(filepath.Join(instancePath, "resourcepacks"))
+	info.ShaderPacksDir = getEntryNames(filepath.Join(instancePath, "shaderpacks"))
+
 	return info, nil
 }
 
-func (m *Manager) DeleteInstance(name string) error {
-	if name == "" {
-		return fmt.Errorf("instance name cannot be empty")
-	}
-
+// GetInstanceStats walks the instance and reports its sizes and when it was
+// last used. The last-used time is the later of the last switch involving the
+// instance and the mtime of logs/latest.log, which the game rewrites on every
+// launch.
+func (m *Manager) GetInstanceStats(name string) (*InstanceStats, error) {
 	instancePath := filepath.Join(m.InstancesPath, name)
 
-	// Check if instance exists
 	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
-		return fmt.Errorf("instance '%s' does not exist", name)
+		return nil, errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
 	}
 
-	// Check if it's the active instance
-	if m.GetActiveInstance() == name {
-		return fmt.Errorf("cannot delete active instance '%s'. Switch to another instance first", name)
+	stats := &InstanceStats{Sizes: make(map[string]int64)}
+	size, err := DirSize(instancePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure instance: %w", err)
 	}
+	stats.Size = size
 
-	// Remove the instance directory
-	return os.RemoveAll(instancePath)
-}
-
-// Helper functions
+	for _, section := range InstanceSections {
+		stats.Sizes[section], _ = DirSize(filepath.Join(instancePath, section))
+	}
 
-func copyDir(src, dst string) error {
-	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	if fi, err := os.Stat(filepath.Join(instancePath, "logs", "latest.log")); err == nil {
+		stats.LastUsed = fi.ModTime()
+	}
+	if t, ok := m.LastSwitchTimes()[name]; ok && t.After(stats.LastUsed) {
+		stats.LastUsed = t
+	}
 
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
+	return stats, nil
+}
 
-		dstPath := filepath.Join(dst, relPath)
+// DeleteInstance moves an instance to the trash, from where it can be
+// restored with RestoreTrash until the retention period expires.
+func (m *Manager) DeleteInstance(name string) (*TrashEntry, error) {
+	return m.DeleteInstanceContext(context.Background(), name, nil)
+}
 
-		if d.IsDir() {
-			return os.MkdirAll(dstPath, 0755)
-		}
+// DeleteInstanceContext deletes an instance like DeleteInstance. Progress is
+// only reported when the trash is on a different filesystem and the instance
+// has to be copied there.
+func (m *Manager) DeleteInstanceContext(ctx context.Context, name string, progress ProgressFunc) (*TrashEntry, error) {
+	if name == "" {
+		return nil, errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
 
-		// Skip copying certain files/directories
-		if strings.Contains(relPath, ".git") || strings.Contains(relPath, ".DS_Store") {
-			return nil
-		}
+	instancePath := filepath.Join(m.InstancesPath, name)
 
-		return copyFile(path, dstPath)
-	})
-}
+	// Check if instance exists
+	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
+		return nil, errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
+	}
 
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return err
+	// Check if it's the active instance
+	if m.GetActiveInstance() == name {
+		return nil, errorOf(ErrInstanceActive, "cannot delete active instance '%s'. Switch to another instance first", name)
 	}
 
-	return os.WriteFile(dst, data, 0644)
+	// Move the instance directory to the trash
+	return m.moveToTrash(ctx, instancePath, "instance", name, progress)
 }
 
+// Helper functions
+
 func countJarFiles(dir string) int {
 	count := 0
 	if entries, err := os.ReadDir(dir); err == nil {
@@ -517,15 +719,15 @@ func countJarFiles(dir string) int {
 	return count
 }
 
+// countFiles counts the files below dir, including those in subfolders.
 func countFiles(dir string) int {
 	count := 0
-	if entries, err := os.ReadDir(dir); err == nil {
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				count++
-			}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
 		}
-	}
+		return nil
+	})
 	return count
 }
 
@@ -541,6 +743,11 @@ func countDirectories(dir string) int {
 	return count
 }
 
+// countEntries counts the entries getEntryNames lists.
+func countEntries(dir string) int {
+	return len(getEntryNames(dir))
+}
+
 func getJarFiles(dir string) []string {
 	var files []string
 	if entries, err := os.ReadDir(dir); err == nil {
@@ -554,17 +761,77 @@ func getJarFiles(dir string) []string {
 	return files
 }
 
-func getConfigFiles(dir string) []string {
-	var files []string
+// getConfigFiles walks the config folder. Folders come before their
+// contents, and each folder's size is the sum of the files below it.
+func getConfigFiles(dir string) []ConfigFile {
+	var files []ConfigFile
+	var open []int // indexes of the folders enclosing the current entry
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return nil
+		}
+		rel, relErr := filepath.Rel(dir, p)
+		if relErr != nil {
+			return nil
+		}
+		f := ConfigFile{Path: filepath.ToSlash(rel), IsDir: d.IsDir()}
+		if info, infoErr := d.Info(); infoErr == nil {
+			f.ModTime = info.ModTime()
+			if !f.IsDir {
+				f.Size = info.Size()
+			}
+		}
+
+		// Leave the folders this entry is not in
+		for len(open) > 0 && !strings.HasPrefix(f.Path, files[open[len(open)-1]].Path+"/") {
+			open = open[:len(open)-1]
+		}
+		for _, i := range open {
+			files[i].Size += f.Size
+		}
+		if f.IsDir {
+			open = append(open, len(files))
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files
+}
+
+// getEntryNames lists the names in dir, leaving out hidden entries such as
+// .DS_Store like the packs package does.
+func getEntryNames(dir string) []string {
+	var names []string
 	if entries, err := os.ReadDir(dir); err == nil {
 		for _, entry := range entries {
-			if !entry.IsDir() {
-				files = append(files, entry.Name())
+			if !strings.HasPrefix(entry.Name(), ".") {
+				names = append(names, entry.Name())
 			}
 		}
 	}
-	sort.Strings(files)
-	return files
+	sort.Strings(names)
+	return names
+}
+
+// DirSize returns the total size of the regular files below dir. Symlinks are
+// not followed. A missing dir has size 0.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
 }
 
 func getDirectoryNames(dir string) []string {
@@ -0,0 +1,89 @@
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"a.toml":               10,
+		"jei/jei.cfg":          100,
+		"jei/world/server.cfg": 1000,
+		"jeib.json":            1,
+		"z/empty/.keep":        0,
+	}
+	for name, size := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type entry struct {
+		path string
+		dir  bool
+		size int64
+	}
+	var got []entry
+	for _, f := range getConfigFiles(dir) {
+		got = append(got, entry{f.Path, f.IsDir, f.Size})
+	}
+	// Depth first, folders summing everything below them; jeib.json sorts
+	// after the jei folder's contents, which must not count it
+	want := []entry{
+		{"a.toml", false, 10},
+		{"jei", true, 1100},
+		{"jei/jei.cfg", false, 100},
+		{"jei/world", true, 1000},
+		{"jei/world/server.cfg", false, 1000},
+		{"jeib.json", false, 1},
+		{"z", true, 0},
+		{"z/empty", true, 0},
+		{"z/empty/.keep", false, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getConfigFiles =\n%v\nwant\n%v", got, want)
+	}
+
+	if files := getConfigFiles(filepath.Join(dir, "missing")); len(files) != 0 {
+		t.Errorf("getConfigFiles of a missing folder = %v", files)
+	}
+}
+
+func TestPackCountsMatchLists(t *testing.T) {
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, map[string]string{
+		"survival/resourcepacks/Faithful.zip":       "zip",
+		"survival/resourcepacks/Folder/pack.mcmeta": "{}",
+		"survival/resourcepacks/.DS_Store":          "",
+		"survival/shaderpacks/.hidden/shaders.txt":  "",
+		"survival/shaderpacks/Complementary.zip":    "zip",
+	})
+
+	instances, err := m.ListInstances()
+	if err != nil || len(instances) != 1 {
+		t.Fatalf("ListInstances = %+v, %v", instances, err)
+	}
+	info, err := m.GetInstanceInfo("survival")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Faithful.zip", "Folder"}; !reflect.DeepEqual(info.ResourcePacksDir, want) {
+		t.Errorf("ResourcePacksDir = %v, want %v", info.ResourcePacksDir, want)
+	}
+	if want := []string{"Complementary.zip"}; !reflect.DeepEqual(info.ShaderPacksDir, want) {
+		t.Errorf("ShaderPacksDir = %v, want %v", info.ShaderPacksDir, want)
+	}
+	inst := instances[0]
+	if inst.ResourcePackCount != len(info.ResourcePacksDir) || inst.ShaderPackCount != len(info.ShaderPacksDir) {
+		t.Errorf("counts = %d, %d, want the lengths of the lists", inst.ResourcePackCount, inst.ShaderPackCount)
+	}
+}
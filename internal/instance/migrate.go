@@ -0,0 +1,358 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// MigrationFileName records an instances-path move of a context until it
+// has finished, so an interrupted move can be resumed.
+const MigrationFileName = "migration.json"
+
+// migrationSuffix marks a cross-device copy that has not been verified yet.
+const migrationSuffix = ".migrating"
+
+// Migration is the persisted state of an instances-path move.
+type Migration struct {
+	From      string    `json:"from" yaml:"from"`
+	To        string    `json:"to" yaml:"to"`
+	Instances []string  `json:"instances" yaml:"instances"`
+	Done      []string  `json:"done" yaml:"done"`
+	Active    string    `json:"active,omitempty" yaml:"active,omitempty"`
+	Started   time.Time `json:"started" yaml:"started"`
+}
+
+func (m *Manager) migrationFile() string {
+	return filepath.Join(m.stateDir(), MigrationFileName)
+}
+
+// PendingMigration returns the interrupted instances-path move, or nil if
+// there is none.
+func (m *Manager) PendingMigration() (*Migration, error) {
+	data, err := os.ReadFile(m.migrationFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration state: %w", err)
+	}
+	var mig Migration
+	if err := json.Unmarshal(data, &mig); err != nil {
+		return nil, fmt.Errorf("failed to parse migration state: %w", err)
+	}
+	return &mig, nil
+}
+
+func (m *Manager) saveMigration(mig *Migration) error {
+	data, err := json.MarshalIndent(mig, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode migration state: %w", err)
+	}
+	// Replace the file in one step; a torn write would lose track of which
+	// instances already moved
+	tmp, err := os.CreateTemp(filepath.Dir(m.migrationFile()), "."+MigrationFileName+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write migration state: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), m.migrationFile())
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write migration state: %w", err)
+	}
+	return nil
+}
+
+// startMigration returns the pending migration to newPath, or records a new
+// one. A pending migration to a different path must be finished first.
+func (m *Manager) startMigration(newPath string) (*Migration, error) {
+	pending, err := m.PendingMigration()
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		if filepath.Clean(pending.To) != filepath.Clean(newPath) {
+			return nil, fmt.Errorf("a move from %s to %s was interrupted; finish it with 'config instances-path %s --move' first",
+				pending.From, pending.To, pending.To)
+		}
+		return pending, nil
+	}
+
+	if src, ok := m.overrides["instances-path"]; ok {
+		return nil, fmt.Errorf("instances-path is set by %s; unset it before moving instances", src)
+	}
+	if filepath.Clean(newPath) == filepath.Clean(m.InstancesPath) {
+		return nil, fmt.Errorf("instances are already in %s", newPath)
+	}
+	if isWithin(canonicalPath(newPath), canonicalPath(m.InstancesPath)) {
+		return nil, fmt.Errorf("cannot move instances into a subdirectory of %s", m.InstancesPath)
+	}
+	if err := m.ValidateConfigValue("instances-path", newPath); err != nil {
+		return nil, err
+	}
+
+	mig := &Migration{
+		From:    m.InstancesPath,
+		To:      newPath,
+		Started: time.Now().UTC(),
+	}
+	if s := m.ActiveState(); s.Kind == ActiveInstance {
+		mig.Active = s.Instance
+	}
+	entries, err := os.ReadDir(m.InstancesPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read instances directory: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(newPath, e.Name())); err == nil {
+			return nil, fmt.Errorf("cannot move instance '%s': %s already exists", e.Name(), filepath.Join(newPath, e.Name()))
+		}
+		mig.Instances = append(mig.Instances, e.Name())
+	}
+	sort.Strings(mig.Instances)
+	return mig, nil
+}
+
+// PlanMoveInstancesPath returns the steps MoveInstancesPath would perform.
+func (m *Manager) PlanMoveInstancesPath(newPath string) (*Plan, error) {
+	newPath = expandPath(newPath)
+	mig, err := m.startMigration(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	for _, name := range mig.Done {
+		done[name] = true
+	}
+
+	plan := &Plan{Action: "move-instances"}
+	plan.add(OpMkdir, mig.To, "")
+	for _, name := range mig.Instances {
+		if done[name] {
+			continue
+		}
+		plan.Steps = append(plan.Steps, Step{
+			Op:     OpMove,
+			Path:   filepath.Join(mig.From, name),
+			Target: filepath.Join(mig.To, name),
+			Detail: "rename, or copy and verify across filesystems",
+		})
+		if name == mig.Active {
+			plan.add(OpRemove, m.MinecraftPath, "")
+			plan.add(OpSymlink, m.MinecraftPath, m.linkTargetFor(filepath.Join(mig.To, name)))
+		}
+	}
+	plan.Steps = append(plan.Steps, Step{
+		Op:     OpWrite,
+		Path:   m.ConfigFile,
+		Detail: "instances-path = " + mig.To,
+	})
+	return plan, nil
+}
+
+// MoveInstancesPath moves every instance to newPath, retargets the active
+// symlink and then stores newPath in the config. Instances are renamed when
+// both paths are on the same filesystem and copied, verified and removed
+// otherwise. Progress is recorded in MigrationFileName after each instance,
+// so calling it again with the same path resumes an interrupted move.
+func (m *Manager) MoveInstancesPath(ctx context.Context, newPath string, progress ProgressFunc) error {
+	newPath = expandPath(newPath)
+	mig, err := m.startMigration(newPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(mig.To, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", mig.To, err)
+	}
+	if err := m.saveMigration(mig); err != nil {
+		return err
+	}
+
+	done := make(map[string]bool)
+	for _, name := range mig.Done {
+		done[name] = true
+	}
+	for _, name := range mig.Instances {
+		if done[name] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		src, dst := filepath.Join(mig.From, name), filepath.Join(mig.To, name)
+		if err := moveVerified(ctx, src, dst, progress); err != nil {
+			return fmt.Errorf("failed to move instance '%s': %w", name, err)
+		}
+		if name == mig.Active {
+			if err := m.relink(dst); err != nil {
+				return err
+			}
+		}
+
+		mig.Done = append(mig.Done, name)
+		if err := m.saveMigration(mig); err != nil {
+			return err
+		}
+	}
+
+	m.InstancesPath = mig.To
+	if err := m.saveConfig(); err != nil {
+		return err
+	}
+	m.retargetTrash(mig.From, mig.To)
+	os.Remove(m.migrationFile())
+	// Leave the old directory behind only if something else is still in it
+	os.Remove(mig.From)
+	return nil
+}
+
+// moveVerified moves src to dst. A rename is tried first; across
+// filesystems the tree is copied next to dst, compared with src and only
+// then renamed into place and src removed. It is safe to call again after an
+// interruption at any point.
+func moveVerified(ctx context.Context, src, dst string, progress ProgressFunc) error {
+	if _, err := os.Lstat(dst); err == nil {
+		// A previous run got as far as placing dst; only src is left over
+		return RemoveTree(context.Background(), src, nil)
+	}
+
+	err := rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+
+	tmp := dst + migrationSuffix
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := CopyTree(ctx, src, tmp, CopyOptions{Progress: progress}); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := verifyTree(ctx, src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("copy verification failed: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return RemoveTree(context.Background(), src, nil)
+}
+
+// verifyTree checks that dst holds the same entries as src, with identical
+// symlink targets and file contents.
+func verifyTree(ctx context.Context, src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		other := filepath.Join(dst, rel)
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		otherInfo, err := os.Lstat(other)
+		if err != nil {
+			return fmt.Errorf("%s is missing", rel)
+		}
+		if info.Mode().Type() != otherInfo.Mode().Type() {
+			return fmt.Errorf("%s has a different type", rel)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			a, _ := os.Readlink(path)
+			b, _ := os.Readlink(other)
+			if a != b {
+				return fmt.Errorf("%s points elsewhere", rel)
+			}
+		case info.Mode().IsRegular():
+			if info.Size() != otherInfo.Size() {
+				return fmt.Errorf("%s has a different size", rel)
+			}
+			a, err := fileDigest(path)
+			if err != nil {
+				return err
+			}
+			b, err := fileDigest(other)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(a, b) {
+				return fmt.Errorf("%s has different contents", rel)
+			}
+		}
+		return nil
+	})
+}
+
+func fileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// relink points MinecraftPath at instancePath, replacing the current link.
+// A real directory in its place is never touched.
+func (m *Manager) relink(instancePath string) error {
+	info, err := os.Lstat(m.MinecraftPath)
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s is no longer a symlink; switch to the instance manually", m.MinecraftPath)
+	}
+	if err := os.Remove(m.MinecraftPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove old symlink: %w", err)
+	}
+	if err := os.Symlink(m.linkTargetFor(instancePath), m.MinecraftPath); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+	return nil
+}
+
+// retargetTrash rewrites trash origins below from so that restores go to the
+// moved instances. Failures only affect where an entry would be restored.
+func (m *Manager) retargetTrash(from, to string) {
+	entries, err := m.ListTrash()
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		rel, err := filepath.Rel(from, e.Origin)
+		if err != nil || !isWithin(e.Origin, from) {
+			continue
+		}
+		e.Origin = filepath.Join(to, rel)
+		writeTrashManifest(filepath.Join(m.TrashPath(), e.ID), &e)
+	}
+}
@@ -0,0 +1,232 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// forceCrossDevice makes every rename of a tree fail as it does between
+// filesystems, for the rest of the test.
+func forceCrossDevice(t *testing.T) {
+	t.Helper()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+// writeTree creates files under dir, with their parent folders.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the contents of every file below dir by slash path.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+var migrationInstances = map[string]string{
+	"survival/options.txt":       "fov:90",
+	"survival/mods/sodium.jar":   "jar",
+	"survival/saves/w/level.dat": "level",
+	"creative/options.txt":       "fov:70",
+}
+
+// setupMigration creates the instances above, makes survival active and
+// returns the manager with the path to move the instances to.
+func setupMigration(t *testing.T) (*Manager, string) {
+	t.Helper()
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, migrationInstances)
+	if err := os.Symlink(m.InstancePath("survival"), m.MinecraftPath); err != nil {
+		t.Fatal(err)
+	}
+	return m, filepath.Join(m.HomeDir, "games", "instances")
+}
+
+// checkMigrated verifies that every instance is in newPath, the old
+// directory and the migration state are gone, and the link follows along.
+func checkMigrated(t *testing.T, m *Manager, oldPath, newPath string) {
+	t.Helper()
+	got := readTree(t, newPath)
+	if len(got) != len(migrationInstances) {
+		t.Errorf("moved files = %v, want %v", got, migrationInstances)
+	}
+	for name, content := range migrationInstances {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+	if _, err := os.Lstat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("old instances directory still exists: %v", err)
+	}
+	if _, err := os.Lstat(m.migrationFile()); !os.IsNotExist(err) {
+		t.Errorf("migration state was not removed: %v", err)
+	}
+	if target, _ := os.Readlink(m.MinecraftPath); target != filepath.Join(newPath, "survival") {
+		t.Errorf(".minecraft links to %q, want the moved survival instance", target)
+	}
+	if m.InstancesPath != newPath {
+		t.Errorf("InstancesPath = %q, want %q", m.InstancesPath, newPath)
+	}
+	cfg, err := m.readConfigFile()
+	if err != nil || cfg.InstancesPath != newPath {
+		t.Errorf("config file instances_path = %q, %v, want %q", cfg.InstancesPath, err, newPath)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(newPath, "*"+migrationSuffix))
+	if len(leftovers) > 0 {
+		t.Errorf("unverified copies left behind: %v", leftovers)
+	}
+}
+
+func TestMoveInstancesPathRename(t *testing.T) {
+	m, newPath := setupMigration(t)
+	oldPath := m.InstancesPath
+	if err := m.MoveInstancesPath(context.Background(), newPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkMigrated(t, m, oldPath, newPath)
+}
+
+func TestMoveInstancesPathCopyAndVerify(t *testing.T) {
+	m, newPath := setupMigration(t)
+	oldPath := m.InstancesPath
+	forceCrossDevice(t)
+
+	var copied int
+	err := m.MoveInstancesPath(context.Background(), newPath, func(p CopyProgress) { copied = p.FilesDone })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied == 0 {
+		t.Error("the copy reported no progress")
+	}
+	checkMigrated(t, m, oldPath, newPath)
+}
+
+func TestMoveInstancesPathResumes(t *testing.T) {
+	m, newPath := setupMigration(t)
+	oldPath := m.InstancesPath
+	forceCrossDevice(t)
+
+	// A previous run moved creative and was interrupted while copying
+	// survival, before the state file recorded creative as done
+	writeTree(t, filepath.Join(newPath, "creative"), map[string]string{"options.txt": "fov:70"})
+	os.RemoveAll(m.InstancePath("creative"))
+	writeTree(t, filepath.Join(newPath, "survival"+migrationSuffix), map[string]string{"options.txt": "fov:9"})
+	mig := Migration{From: oldPath, To: newPath, Instances: []string{"creative", "survival"}, Active: "survival"}
+	if err := m.saveMigration(&mig); err != nil {
+		t.Fatal(err)
+	}
+
+	// The state is pending, and a move elsewhere must wait for it
+	if pending, err := m.PendingMigration(); err != nil || pending == nil || len(pending.Done) != 0 {
+		t.Fatalf("PendingMigration = %+v, %v", pending, err)
+	}
+	if err := m.MoveInstancesPath(context.Background(), filepath.Join(m.HomeDir, "elsewhere"), nil); err == nil {
+		t.Error("starting a second move succeeded while one is pending")
+	}
+
+	if err := m.MoveInstancesPath(context.Background(), newPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkMigrated(t, m, oldPath, newPath)
+}
+
+func TestMoveInstancesPathCorruptState(t *testing.T) {
+	m, newPath := setupMigration(t)
+	mig := Migration{From: m.InstancesPath, To: newPath, Instances: []string{"creative", "survival"}}
+	data, _ := json.Marshal(mig)
+	if err := os.WriteFile(m.migrationFile(), data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := m.MoveInstancesPath(context.Background(), newPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "migration state") {
+		t.Fatalf("MoveInstancesPath = %v, want a migration state error", err)
+	}
+	if got := readTree(t, m.InstancesPath); len(got) != len(migrationInstances) {
+		t.Errorf("instances changed to %v", got)
+	}
+	if _, err := os.Lstat(newPath); !os.IsNotExist(err) {
+		t.Errorf("destination was created: %v", err)
+	}
+}
+
+func TestMoveInstancesPathRefusesNesting(t *testing.T) {
+	m, _ := setupMigration(t)
+	for _, newPath := range []string{
+		filepath.Join(m.InstancesPath, "nested"),
+		filepath.Join(m.InstancesPath, "survival", "instances"),
+		m.InstancesPath,
+	} {
+		if err := m.MoveInstancesPath(context.Background(), newPath, nil); err == nil {
+			t.Errorf("MoveInstancesPath(%s) succeeded", newPath)
+		}
+		if _, err := os.Lstat(m.migrationFile()); !os.IsNotExist(err) {
+			t.Errorf("MoveInstancesPath(%s) recorded a migration", newPath)
+		}
+	}
+	if got := readTree(t, m.InstancesPath); len(got) != len(migrationInstances) {
+		t.Errorf("instances changed to %v", got)
+	}
+}
+
+func TestVerifyTree(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "same", "sub/b.txt": "1234"})
+	tests := []struct {
+		name   string
+		change func(dst string)
+	}{
+		{"missing file", func(dst string) { os.Remove(filepath.Join(dst, "a.txt")) }},
+		{"other size", func(dst string) { os.WriteFile(filepath.Join(dst, "a.txt"), []byte("longer"), 0644) }},
+		{"same size, other contents", func(dst string) { os.WriteFile(filepath.Join(dst, "sub", "b.txt"), []byte("4321"), 0644) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "copy")
+			if err := CopyTree(context.Background(), src, dst, CopyOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := verifyTree(context.Background(), src, dst); err != nil {
+				t.Fatalf("verifyTree of an exact copy: %v", err)
+			}
+			tt.change(dst)
+			if err := verifyTree(context.Background(), src, dst); err == nil {
+				t.Error("verifyTree succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StepOp identifies the filesystem change a plan step makes.
+type StepOp string
+
+const (
+	OpMkdir   StepOp = "mkdir"   // create directory Path
+	OpCopy    StepOp = "copy"    // copy tree Path to Target
+	OpRename  StepOp = "rename"  // rename Path to Target (same filesystem)
+	OpMove    StepOp = "move"    // move Path to Target, copying across filesystems
+	OpRemove  StepOp = "remove"  // remove Path and everything below it
+	OpSymlink StepOp = "symlink" // create symlink Path pointing to Target
+	OpTrash   StepOp = "trash"   // move Path into the trash entry Target
+	OpWrite   StepOp = "write"   // write file Path; Detail describes the change
+)
+
+// Step is a single filesystem change of a Plan.
+type Step struct {
+	Op     StepOp `json:"op" yaml:"op"`
+	Path   string `json:"path" yaml:"path"`
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+func (s Step) String() string {
+	switch s.Op {
+	case OpSymlink:
+		if s.Detail != "" {
+			return fmt.Sprintf("symlink %s -> %s (%s)", s.Path, s.Target, s.Detail)
+		}
+		return fmt.Sprintf("symlink %s -> %s", s.Path, s.Target)
+	case OpCopy, OpRename, OpMove, OpTrash:
+		if s.Detail != "" {
+			return fmt.Sprintf("%s %s -> %s (%s)", s.Op, s.Path, s.Target, s.Detail)
+		}
+		return fmt.Sprintf("%s %s -> %s", s.Op, s.Path, s.Target)
+	case OpWrite:
+		return fmt.Sprintf("write %s (%s)", s.Path, s.Detail)
+	}
+	return fmt.Sprintf("%s %s", s.Op, s.Path)
+}
+
+// Plan describes what a mutating Manager operation would do, in order,
+// without touching the disk. Plan* methods return one for --dry-run.
+type Plan struct {
+	Action string `json:"action" yaml:"action"`
+	Steps  []Step `json:"steps" yaml:"steps"`
+}
+
+func (p *Plan) add(op StepOp, path, target string) {
+	p.Steps = append(p.Steps, Step{Op: op, Path: path, Target: target})
+}
+
+// execute applies the plan. Only the operations used by executable plans
+// (switch and restore) are supported.
+func (m *Manager) execute(ctx context.Context, plan *Plan, progress ProgressFunc) error {
+	for _, s := range plan.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		switch s.Op {
+		case OpRemove:
+			err = os.RemoveAll(s.Path)
+		case OpRename:
+			err = os.Rename(s.Path, s.Target)
+		case OpMove:
+			err = MoveTree(ctx, s.Path, s.Target, progress)
+		case OpTrash:
+			// Detail holds the kind of the trash entry
+			_, err = m.moveToTrash(ctx, s.Path, s.Detail, "", progress)
+		case OpSymlink:
+			err = os.Symlink(s.Target, s.Path)
+		case OpMkdir:
+			err = os.MkdirAll(s.Path, 0755)
+		default:
+			err = fmt.Errorf("unsupported plan step %q", s.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// PlanSwitch returns the steps SwitchInstance, or ForceSwitchInstance if
+// force is set, would perform.
+func (m *Manager) PlanSwitch(name string, force bool) (*Plan, error) {
+	if name == "" {
+		return nil, errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
+
+	instancePath := filepath.Join(m.InstancesPath, name)
+
+	// Check if instance exists
+	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
+		return nil, errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
+	}
+	if !force {
+		if err := m.checkForeignLink(); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{Action: "switch"}
+
+	// Backup current minecraft directory if it exists and is not a symlink
+	if info, err := os.Lstat(m.MinecraftPath); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			// An older backup goes to the trash rather than being lost
+			if _, err := os.Lstat(m.BackupPath); err == nil {
+				plan.Steps = append(plan.Steps, Step{Op: OpTrash, Path: m.BackupPath, Target: m.TrashPath(), Detail: "backup"})
+			}
+			plan.add(OpRename, m.MinecraftPath, m.BackupPath)
+		} else {
+			// It's already a symlink, just remove it
+			plan.add(OpRemove, m.MinecraftPath, "")
+		}
+	}
+
+	link := Step{Op: OpSymlink, Path: m.MinecraftPath, Target: m.linkTargetFor(instancePath)}
+	if !filepath.IsAbs(link.Target) {
+		link.Detail = "relative"
+	}
+	plan.Steps = append(plan.Steps, link)
+	return plan, nil
+}
+
+// PlanRestore returns the steps RestoreDefault would perform.
+func (m *Manager) PlanRestore() (*Plan, error) {
+	plan := &Plan{Action: "restore"}
+
+	if info, err := os.Lstat(m.MinecraftPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		plan.add(OpRemove, m.MinecraftPath, "")
+	}
+	if _, err := os.Stat(m.BackupPath); err == nil {
+		plan.add(OpMove, m.BackupPath, m.MinecraftPath)
+	}
+	return plan, nil
+}
+
+// PlanCreate returns the steps CreateInstance would perform.
+func (m *Manager) PlanCreate(name string) (*Plan, error) {
+	if name == "" {
+		return nil, errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
+	if name == DefaultInstanceName {
+		return nil, errorOf(ErrReservedName, "'%s' is reserved for the default minecraft directory", name)
+	}
+
+	instancePath := filepath.Join(m.InstancesPath, name)
+	if _, err := os.Stat(instancePath); err == nil {
+		return nil, errorOf(ErrInstanceExists, "instance '%s' already exists", name)
+	}
+
+	plan := &Plan{Action: "create"}
+	plan.add(OpMkdir, instancePath, "")
+
+	if info, err := os.Lstat(m.MinecraftPath); err == nil {
+		src := m.MinecraftPath
+		if info.Mode()&os.ModeSymlink != 0 {
+			src, _ = m.readLink()
+		}
+		if src != "" {
+			plan.Steps = append(plan.Steps, Step{
+				Op:     OpCopy,
+				Path:   src,
+				Target: instancePath,
+				Detail: "excluding " + strings.Join(DefaultCopyExcludes, ", "),
+			})
+		}
+	}
+
+	for _, dir := range InstanceSections {
+		plan.add(OpMkdir, filepath.Join(instancePath, dir), "")
+	}
+	return plan, nil
+}
+
+// PlanDelete returns the steps DeleteInstance would perform.
+func (m *Manager) PlanDelete(name string) (*Plan, error) {
+	if name == "" {
+		return nil, errorOf(ErrEmptyName, "instance name cannot be empty")
+	}
+
+	instancePath := filepath.Join(m.InstancesPath, name)
+	if _, err := os.Stat(instancePath); os.IsNotExist(err) {
+		return nil, errorOf(ErrInstanceNotFound, "instance '%s' does not exist", name)
+	}
+	if m.GetActiveInstance() == name {
+		return nil, errorOf(ErrInstanceActive, "cannot delete active instance '%s'. Switch to another instance first", name)
+	}
+
+	plan := &Plan{Action: "delete"}
+	m.planPurgeExpiredTrash(plan)
+	plan.add(OpTrash, instancePath, m.TrashPath())
+	return plan, nil
+}
+
+// PlanTrashFile returns the steps TrashFile would perform.
+func (m *Manager) PlanTrashFile(instanceName, section, fileName string) (*Plan, error) {
+	path, _, err := m.trashFilePath(instanceName, section, fileName)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Action: "delete-file"}
+	m.planPurgeExpiredTrash(plan)
+	plan.add(OpTrash, path, m.TrashPath())
+	return plan, nil
+}
+
+// PlanUpdateConfig returns the change UpdateConfig would write.
+func (m *Manager) PlanUpdateConfig(key, value string) (*Plan, error) {
+	if err := m.ValidateConfigValue(key, value); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Action: "config"}
+	if isInstancesKey(key) {
+		plan.add(OpMkdir, expandPath(value), "")
+	}
+	plan.Steps = append(plan.Steps, Step{
+		Op:     OpWrite,
+		Path:   m.ConfigFile,
+		Detail: fmt.Sprintf("%s = %s", key, expandPath(value)),
+	})
+	return plan, nil
+}
+
+// PlanRestoreTrash returns the steps RestoreTrash would perform.
+func (m *Manager) PlanRestoreTrash(id string) (*Plan, error) {
+	if id == "" || id != filepath.Base(id) {
+		return nil, errorOf(ErrTrashNotFound, "trash entry '%s' not found", id)
+	}
+	entryDir := filepath.Join(m.TrashPath(), id)
+	entry, err := readTrashManifest(entryDir)
+	if err != nil {
+		return nil, errorOf(ErrTrashNotFound, "trash entry '%s' not found", id)
+	}
+	if _, err := os.Lstat(entry.Origin); err == nil {
+		return nil, fmt.Errorf("cannot restore %s: %s already exists", entry.Name, entry.Origin)
+	}
+
+	plan := &Plan{Action: "trash-restore"}
+	plan.add(OpMove, filepath.Join(entryDir, trashDataName), entry.Origin)
+	plan.add(OpRemove, entryDir, "")
+	return plan, nil
+}
+
+// PlanEmptyTrash returns the steps EmptyTrash would perform.
+func (m *Manager) PlanEmptyTrash(olderThan time.Duration) (*Plan, error) {
+	entries, err := m.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Action: "trash-empty"}
+	cutoff := time.Now().Add(-olderThan)
+	for _, e := range entries {
+		if olderThan > 0 && e.DeletedAt.After(cutoff) {
+			continue
+		}
+		plan.add(OpRemove, filepath.Join(m.TrashPath(), e.ID), "")
+	}
+	return plan, nil
+}
+
+// planPurgeExpiredTrash adds the removals of the automatic trash expiry.
+func (m *Manager) planPurgeExpiredTrash(plan *Plan) {
+	if purge, err := m.PlanEmptyTrash(m.TrashRetention()); err == nil {
+		plan.Steps = append(plan.Steps, purge.Steps...)
+	}
+}
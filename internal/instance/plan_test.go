@@ -0,0 +1,137 @@
+//go:build unix
+
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanSwitch(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, m *Manager)
+		relative bool
+		want     func(m *Manager) []Step
+	}{
+		{"nothing to replace", func(t *testing.T, m *Manager) {}, false,
+			func(m *Manager) []Step {
+				return []Step{{Op: OpSymlink, Path: m.MinecraftPath, Target: m.InstancePath("survival")}}
+			}},
+		{"real directory is backed up", func(t *testing.T, m *Manager) {
+			writeTree(t, m.MinecraftPath, map[string]string{"options.txt": "x"})
+		}, false, func(m *Manager) []Step {
+			return []Step{
+				{Op: OpRename, Path: m.MinecraftPath, Target: m.BackupPath},
+				{Op: OpSymlink, Path: m.MinecraftPath, Target: m.InstancePath("survival")},
+			}
+		}},
+		{"older backup goes to the trash", func(t *testing.T, m *Manager) {
+			writeTree(t, m.MinecraftPath, map[string]string{"options.txt": "x"})
+			writeTree(t, m.BackupPath, map[string]string{"options.txt": "older"})
+		}, false, func(m *Manager) []Step {
+			return []Step{
+				{Op: OpTrash, Path: m.BackupPath, Target: m.TrashPath(), Detail: "backup"},
+				{Op: OpRename, Path: m.MinecraftPath, Target: m.BackupPath},
+				{Op: OpSymlink, Path: m.MinecraftPath, Target: m.InstancePath("survival")},
+			}
+		}},
+		{"our link is replaced", func(t *testing.T, m *Manager) {
+			if err := os.Symlink(m.InstancePath("creative"), m.MinecraftPath); err != nil {
+				t.Fatal(err)
+			}
+		}, true, func(m *Manager) []Step {
+			rel, _ := filepath.Rel(filepath.Dir(m.MinecraftPath), m.InstancePath("survival"))
+			return []Step{
+				{Op: OpRemove, Path: m.MinecraftPath},
+				{Op: OpSymlink, Path: m.MinecraftPath, Target: rel, Detail: "relative"},
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			m.cfg.RelativeSymlinks = tt.relative
+			writeTree(t, m.InstancesPath, map[string]string{"survival/options.txt": "a", "creative/options.txt": "b"})
+			tt.setup(t, m)
+
+			plan, err := m.PlanSwitch("survival", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.want(m); plan.Action != "switch" || !reflect.DeepEqual(plan.Steps, want) {
+				t.Errorf("PlanSwitch = %s %+v, want %+v", plan.Action, plan.Steps, want)
+			}
+		})
+	}
+}
+
+func TestPlanSwitchRefuses(t *testing.T) {
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, map[string]string{"survival/options.txt": "a"})
+	for _, name := range []string{"", "missing"} {
+		if _, err := m.PlanSwitch(name, false); err == nil {
+			t.Errorf("PlanSwitch(%q) succeeded", name)
+		}
+	}
+
+	// A link to somewhere else is only replaced with force
+	foreign := filepath.Join(m.HomeDir, "other-launcher")
+	writeTree(t, foreign, map[string]string{"options.txt": "c"})
+	if err := os.Symlink(foreign, m.MinecraftPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.PlanSwitch("survival", false); err == nil {
+		t.Error("PlanSwitch over a foreign link succeeded")
+	}
+	plan, err := m.PlanSwitch("survival", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Op != OpRemove || plan.Steps[0].Path != m.MinecraftPath {
+		t.Errorf("forced PlanSwitch = %+v", plan.Steps)
+	}
+}
+
+func TestPlanRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, m *Manager)
+		want  func(m *Manager) []Step
+	}{
+		{"link and backup", func(t *testing.T, m *Manager) {
+			writeTree(t, m.BackupPath, map[string]string{"options.txt": "x"})
+			os.Symlink(m.InstancePath("survival"), m.MinecraftPath)
+		}, func(m *Manager) []Step {
+			return []Step{
+				{Op: OpRemove, Path: m.MinecraftPath},
+				{Op: OpMove, Path: m.BackupPath, Target: m.MinecraftPath},
+			}
+		}},
+		{"link without a backup", func(t *testing.T, m *Manager) {
+			os.Symlink(m.InstancePath("survival"), m.MinecraftPath)
+		}, func(m *Manager) []Step {
+			return []Step{{Op: OpRemove, Path: m.MinecraftPath}}
+		}},
+		{"already restored", func(t *testing.T, m *Manager) {
+			writeTree(t, m.MinecraftPath, map[string]string{"options.txt": "x"})
+		}, func(m *Manager) []Step { return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			writeTree(t, m.InstancesPath, map[string]string{"survival/options.txt": "a"})
+			tt.setup(t, m)
+
+			plan, err := m.PlanRestore()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.want(m); plan.Action != "restore" || !reflect.DeepEqual(plan.Steps, want) {
+				t.Errorf("PlanRestore = %s %+v, want %+v", plan.Action, plan.Steps, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+//go:build unix
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// otherDevice returns a scratch directory on a different filesystem than
+// dir, or skips the test when there is none.
+func otherDevice(t *testing.T, dir string) string {
+	t.Helper()
+	var a, b syscall.Stat_t
+	if syscall.Stat(dir, &a) != nil || syscall.Stat("/dev/shm", &b) != nil || a.Dev == b.Dev {
+		t.Skip("no second filesystem to move across")
+	}
+	other, err := os.MkdirTemp("/dev/shm", "mim-test-")
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(other) })
+	return other
+}
+
+func TestRestoreCancelledKeepsSymlink(t *testing.T) {
+	home := t.TempDir()
+	m := &Manager{
+		InstancesPath: filepath.Join(home, "instances"),
+		MinecraftPath: filepath.Join(home, ".minecraft"),
+		BackupPath:    filepath.Join(otherDevice(t, home), ".minecraft.backup"),
+	}
+	instancePath := filepath.Join(m.InstancesPath, "survival")
+	if err := os.MkdirAll(instancePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(instancePath, m.MinecraftPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(m.BackupPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"options.txt", "servers.dat", "launcher_profiles.json"} {
+		if err := os.WriteFile(filepath.Join(m.BackupPath, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancel as soon as the copy of the backup reports progress, as Esc does
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := m.RestoreDefaultContext(ctx, func(CopyProgress) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RestoreDefaultContext = %v, want context.Canceled", err)
+	}
+
+	if target, err := os.Readlink(m.MinecraftPath); err != nil || target != instancePath {
+		t.Errorf(".minecraft = %q (%v), want a symlink to %s", target, err, instancePath)
+	}
+	if _, err := os.Stat(filepath.Join(m.BackupPath, "options.txt")); err != nil {
+		t.Errorf("backup was not kept: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigVersion is the schema version written to config.json. Files without
+// a version are version 1, the original three-path format.
+const ConfigVersion = 3
+
+// configMigrations upgrade the raw config from version n to n+1. They work on
+// the decoded JSON object so that renamed or removed keys can be handled.
+var configMigrations = map[int]func(raw map[string]any) error{
+	1: func(raw map[string]any) error {
+		// Version 1 wrote empty strings for unset paths and did not clean them
+		for _, k := range []string{"instances_path", "minecraft_path", "backup_path"} {
+			s, _ := raw[k].(string)
+			if s == "" {
+				delete(raw, k)
+				continue
+			}
+			if !strings.HasPrefix(s, "~") {
+				raw[k] = filepath.Clean(s)
+			}
+		}
+		return nil
+	},
+	// Version 3 adds named contexts. Nothing changes for existing files, but
+	// older builds must refuse them instead of rewriting them without contexts.
+	2: func(raw map[string]any) error { return nil },
+}
+
+// migrateConfig upgrades the decoded config file in place to ConfigVersion.
+// It reports whether anything changed so the caller can write it back.
+func migrateConfig(raw map[string]any) (bool, error) {
+	version := 1
+	switch v := raw["version"].(type) {
+	case float64: // JSON
+		version = int(v)
+	case int: // YAML
+		version = v
+	}
+	if version > ConfigVersion {
+		return false, fmt.Errorf("config file has version %d, but this build only understands up to %d; please upgrade", version, ConfigVersion)
+	}
+	if version == ConfigVersion {
+		return false, nil
+	}
+
+	for ; version < ConfigVersion; version++ {
+		migrate, ok := configMigrations[version]
+		if !ok {
+			return false, fmt.Errorf("no migration for config version %d", version)
+		}
+		if err := migrate(raw); err != nil {
+			return false, fmt.Errorf("failed to migrate config from version %d: %w", version, err)
+		}
+	}
+	raw["version"] = ConfigVersion
+	return true, nil
+}
+
+// ConfigProblem is a validation failure of one config key.
+type ConfigProblem struct {
+	Key     string `json:"key" yaml:"key"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (p ConfigProblem) String() string {
+	return p.Key + ": " + p.Message
+}
+
+// problemsError joins validation problems into one ErrInvalidConfig error.
+func problemsError(problems []ConfigProblem) error {
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.String()
+	}
+	return errorOf(ErrInvalidConfig, "%s", strings.Join(msgs, "; "))
+}
+
+// ValidateConfig checks the current configuration: every path must be
+// absolute and writable, and none may contain another in a way that would
+// make a switch move or delete it.
+func (m *Manager) ValidateConfig() []ConfigProblem {
+	problems := m.validatePaths()
+	if !m.pathsAbsolute() {
+		return problems
+	}
+	return append(problems, m.validateNesting()...)
+}
+
+func (m *Manager) pathsAbsolute() bool {
+	return filepath.IsAbs(m.MinecraftPath) && filepath.IsAbs(m.InstancesPath) && filepath.IsAbs(m.BackupPath)
+}
+
+// validatePaths checks that each path is absolute and writable.
+func (m *Manager) validatePaths() []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	paths := []struct {
+		key, path string
+		// parent is set when the path itself is replaced by renames or
+		// symlinks, so its parent directory must be writable
+		parent bool
+	}{
+		{"minecraft-path", m.MinecraftPath, true},
+		{"instances-path", m.InstancesPath, false},
+		{"backup-path", m.BackupPath, true},
+	}
+	for _, p := range paths {
+		if !filepath.IsAbs(p.path) {
+			add(p.key, "%q is not an absolute path", p.path)
+			continue
+		}
+		dir := p.path
+		if p.parent {
+			dir = filepath.Dir(p.path)
+		}
+		if err := checkWritable(dir); err != nil {
+			add(p.key, "%v", err)
+		}
+	}
+	return problems
+}
+
+// validateNesting checks that no path contains another in a way that would
+// make a switch move or hide it. All paths must be absolute.
+func (m *Manager) validateNesting() []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	mc := canonicalLinkPath(m.MinecraftPath)
+	inst := canonicalPath(m.InstancesPath)
+	backup := canonicalPath(m.BackupPath)
+	switch {
+	case mc == inst:
+		add("instances-path", "is the same as minecraft-path")
+	case isWithin(inst, mc):
+		add("instances-path", "is inside minecraft-path; switching would move all instances into the backup")
+	case isWithin(mc, inst):
+		add("minecraft-path", "is inside instances-path and would be listed as an instance")
+	}
+	switch {
+	case backup == mc:
+		add("backup-path", "is the same as minecraft-path")
+	case isWithin(backup, mc):
+		add("backup-path", "is inside minecraft-path; a directory cannot be moved into itself")
+	case isWithin(backup, inst):
+		add("backup-path", "is inside instances-path and would be listed as an instance")
+	case isWithin(inst, backup):
+		add("instances-path", "is inside backup-path; restoring the backup would replace it")
+	}
+	return problems
+}
+
+// ValidateConfigValue reports whether setting key to value would introduce
+// a new validation problem. Problems the configuration already has are not
+// held against an unrelated change.
+func (m *Manager) ValidateConfigValue(key, value string) error {
+	probe := *m
+	if err := probe.applyConfigValue(key, value); err != nil {
+		return err
+	}
+
+	existing := make(map[ConfigProblem]bool)
+	for _, p := range m.ValidateConfig() {
+		existing[p] = true
+	}
+	var added []string
+	for _, p := range probe.ValidateConfig() {
+		if !existing[p] {
+			added = append(added, p.String())
+		}
+	}
+	if len(added) > 0 {
+		return errorOf(ErrInvalidConfig, "%s", strings.Join(added, "; "))
+	}
+	return nil
+}
+
+// CheckConfigValue reports whether value is well-formed for key: a number
+// or boolean where one is expected, an absolute path otherwise. Unlike
+// ValidateConfigValue it does not look at the disk, so it is cheap enough
+// to run on every keystroke.
+func (m *Manager) CheckConfigValue(key, value string) error {
+	probe := *m
+	if err := probe.applyConfigValue(key, value); err != nil {
+		return err
+	}
+	if isPathKey(CanonicalConfigKey(key)) && !filepath.IsAbs(expandPath(value)) {
+		return errorOf(ErrInvalidConfig, "%s: %q is not an absolute path", CanonicalConfigKey(key), value)
+	}
+	return nil
+}
+
+// checkWritable verifies that dir, or the closest existing ancestor that
+// would hold it, is a writable directory.
+func checkWritable(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			if !dirWritable(dir) {
+				return fmt.Errorf("%s is not writable", dir)
+			}
+			return nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing parent directory for %s", dir)
+		}
+		dir = parent
+	}
+}
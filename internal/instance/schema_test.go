@@ -0,0 +1,207 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHome points the home and config directories at a fresh temporary
+// directory and returns it with the default config file path.
+func testHome(t *testing.T) (home, configFile string) {
+	t.Helper()
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("APPDATA", filepath.Join(home, "AppData", "Roaming"))
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return home, filepath.Join(configDir, AppFolderName, "config.json")
+}
+
+// noEnv is an Options.LookupEnv that hides the real environment.
+func noEnv(string) (string, bool) { return "", false }
+
+// newTestManager returns a Manager with the default configuration of a
+// fresh home directory.
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	testHome(t)
+	m, err := NewManagerWithOptions(Options{LookupEnv: noEnv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// writeConfig writes content to the config file, creating its folder.
+func writeConfig(t *testing.T, configFile, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigMigration(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"version 1", `{"instances_path": "%s/instances/", "minecraft_path": "%s/.minecraft", "backup_path": ""}`},
+		{"version 2", `{"version": 2, "instances_path": "%s/instances", "minecraft_path": "%s/.minecraft"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, configFile := testHome(t)
+			original := strings.ReplaceAll(tt.config, "%s", home)
+			writeConfig(t, configFile, original)
+
+			m, err := NewManagerWithOptions(Options{LookupEnv: noEnv})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(home, "instances"); m.InstancesPath != want {
+				t.Errorf("InstancesPath = %q, want %q", m.InstancesPath, want)
+			}
+			if want := filepath.Join(m.AppDir, "backup"); m.BackupPath != want {
+				t.Errorf("BackupPath = %q, want the default %q", m.BackupPath, want)
+			}
+
+			data, err := os.ReadFile(configFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cfg Config
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Version != ConfigVersion {
+				t.Errorf("migrated file has version %d, want %d", cfg.Version, ConfigVersion)
+			}
+			if backup, err := os.ReadFile(configFile + ".bak"); err != nil || string(backup) != original {
+				t.Errorf("backup of the original = %q, %v", backup, err)
+			}
+		})
+	}
+}
+
+func TestConfigFromTheFutureIsRefused(t *testing.T) {
+	home, configFile := testHome(t)
+	config := `{"version": 99, "instances_path": "` + home + `/instances", "minecraft_path": "` + home + `/.minecraft"}`
+	writeConfig(t, configFile, config)
+
+	if _, err := NewManagerWithOptions(Options{LookupEnv: noEnv}); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("NewManagerWithOptions = %v, want a version error", err)
+	}
+	if data, _ := os.ReadFile(configFile); string(data) != config {
+		t.Errorf("config file was rewritten to %s", data)
+	}
+}
+
+func TestInvalidConfigIsRefused(t *testing.T) {
+	_, configFile := testHome(t)
+	writeConfig(t, configFile, `{"version": 3, "instances_path": "relative/instances"}`)
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+
+	_, err := NewManagerWithOptions(Options{LookupEnv: noEnv})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewManagerWithOptions = %v, want ErrInvalidConfig", err)
+	}
+	if entries, _ := os.ReadDir(cwd); len(entries) > 0 {
+		t.Errorf("created %v in the working directory", entries)
+	}
+
+	// Environment variables are validated the same way
+	writeConfig(t, configFile, `{"version": 3}`)
+	env := func(name string) (string, bool) {
+		if name == "MCIM_BACKUP_PATH" {
+			return "backup", true
+		}
+		return "", false
+	}
+	if _, err := NewManagerWithOptions(Options{LookupEnv: env}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewManagerWithOptions with a relative %s = %v, want ErrInvalidConfig", "MCIM_BACKUP_PATH", err)
+	}
+
+	// doctor and config still get a manager to report and fix it with
+	m, err := NewManagerWithOptions(Options{LookupEnv: env, AllowInvalid: true})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions with AllowInvalid: %v", err)
+	}
+	if problems := m.ValidateConfig(); len(problems) != 1 || problems[0].Key != "backup-path" {
+		t.Errorf("ValidateConfig = %v, want the backup-path problem", problems)
+	}
+	if entries, _ := os.ReadDir(cwd); len(entries) > 0 {
+		t.Errorf("created %v in the working directory", entries)
+	}
+}
+
+func TestCheckConfigValue(t *testing.T) {
+	home := t.TempDir()
+	m := &Manager{
+		InstancesPath: filepath.Join(home, "instances"),
+		MinecraftPath: filepath.Join(home, ".minecraft"),
+		BackupPath:    filepath.Join(home, "backup"),
+	}
+	tests := []struct {
+		key, value string
+		ok         bool
+	}{
+		{"instances-path", "/anywhere/even/missing", true},
+		{"instances", "~/instances", true},
+		{"backup-path", "backup", false},
+		{"minecraft-path", "", false},
+		{"minecraft-path", "~nosuchuser12345/.minecraft", false},
+		{"trash-retention-days", "7", true},
+		{"trash-retention-days", "0", false},
+		{"relative-symlinks", "yes", false},
+		{"no-such-key", "/x", false},
+	}
+	for _, tt := range tests {
+		if err := m.CheckConfigValue(tt.key, tt.value); (err == nil) != tt.ok {
+			t.Errorf("CheckConfigValue(%q, %q) = %v, want ok %v", tt.key, tt.value, err, tt.ok)
+		}
+	}
+	if entries, _ := os.ReadDir(home); len(entries) > 0 {
+		t.Errorf("checking created %v", entries)
+	}
+}
+
+func TestCheckWritableWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	// Creating and removing a probe file would change the folder's mtime
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(dir, old, old)
+	if err := checkWritable(filepath.Join(dir, "a", "b")); err != nil {
+		t.Errorf("checkWritable of a missing folder in a writable one: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("checkWritable wrote to %s", dir)
+	}
+
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+	if err := checkWritable(filepath.Join(file, "sub")); err == nil {
+		t.Error("checkWritable below a file succeeded")
+	}
+
+	if os.Geteuid() == 0 || runtime.GOOS == "windows" {
+		return // root may write anywhere; Windows ignores the mode
+	}
+	readOnly := filepath.Join(dir, "ro")
+	os.Mkdir(readOnly, 0555)
+	if err := checkWritable(readOnly); err == nil {
+		t.Error("checkWritable of a read-only folder succeeded")
+	}
+}
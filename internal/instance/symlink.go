@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// linkTargetFor returns what the MinecraftPath symlink should contain to
+// point at instancePath. With relative_symlinks enabled the target is
+// relative to the link's directory, so the layout survives moving or
+// remounting the shared root; otherwise, or if the paths only share the
+// filesystem root or a volume, it is absolute.
+func (m *Manager) linkTargetFor(instancePath string) string {
+	if !m.cfg.RelativeSymlinks {
+		return instancePath
+	}
+	linkDir := filepath.Dir(m.MinecraftPath)
+	if !sharesRoot(linkDir, instancePath) {
+		return instancePath
+	}
+	rel, err := filepath.Rel(linkDir, instancePath)
+	if err != nil {
+		return instancePath
+	}
+	return rel
+}
+
+// readLink returns the absolute target of the MinecraftPath symlink, for
+// relative and absolute links alike. The target may not exist.
+func (m *Manager) readLink() (string, error) {
+	target, err := os.Readlink(m.MinecraftPath)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(m.MinecraftPath), target)
+	}
+	return filepath.Clean(target), nil
+}
+
+// sharesRoot reports whether a and b have a common ancestor directory below
+// the filesystem root.
+func sharesRoot(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if filepath.VolumeName(a) != filepath.VolumeName(b) {
+		return false
+	}
+	sep := string(filepath.Separator)
+	partsA := strings.Split(strings.TrimPrefix(a[len(filepath.VolumeName(a)):], sep), sep)
+	partsB := strings.Split(strings.TrimPrefix(b[len(filepath.VolumeName(b)):], sep), sep)
+	return len(partsA) > 0 && len(partsB) > 0 && partsA[0] != "" && partsA[0] == partsB[0]
+}
@@ -0,0 +1,248 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TrashDirName is the folder inside AppDir that holds deleted data.
+	TrashDirName = "trash"
+	// DefaultTrashRetentionDays is used when the config does not set
+	// trash_retention_days.
+	DefaultTrashRetentionDays = 30
+
+	trashManifestName = "manifest.json"
+	trashDataName     = "data"
+)
+
+// TrashEntry describes one deleted instance, mod, config, save or replaced
+// backup.
+type TrashEntry struct {
+	ID        string    `json:"id" yaml:"id"`
+	Name      string    `json:"name" yaml:"name"`
+	Kind      string    `json:"kind" yaml:"kind"` // "instance", "mod", "config", "save" or "backup"
+	Instance  string    `json:"instance" yaml:"instance"`
+	Origin    string    `json:"origin" yaml:"origin"`
+	DeletedAt time.Time `json:"deleted_at" yaml:"deleted_at"`
+	SizeBytes int64     `json:"size_bytes" yaml:"size_bytes"`
+}
+
+// TrashPath returns the trash directory.
+func (m *Manager) TrashPath() string {
+	return filepath.Join(m.AppDir, TrashDirName)
+}
+
+// TrashRetention returns how long trashed data is kept before it is purged.
+func (m *Manager) TrashRetention() time.Duration {
+	days := m.cfg.TrashRetentionDays
+	if days <= 0 {
+		days = DefaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// moveToTrash moves path into a new trash entry and writes its manifest.
+// Expired entries are purged first so the trash does not grow unbounded.
+func (m *Manager) moveToTrash(ctx context.Context, path, kind, instanceName string, progress ProgressFunc) (*TrashEntry, error) {
+	m.PurgeExpiredTrash()
+
+	size, _ := DirSize(path)
+	now := time.Now().UTC()
+	entry := &TrashEntry{
+		ID:        now.Format("20060102T150405.000000000") + "-" + sanitizeTrashName(filepath.Base(path)),
+		Name:      filepath.Base(path),
+		Kind:      kind,
+		Instance:  instanceName,
+		Origin:    path,
+		DeletedAt: now,
+		SizeBytes: size,
+	}
+
+	entryDir := filepath.Join(m.TrashPath(), entry.ID)
+	if err := os.MkdirAll(entryDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trash entry: %w", err)
+	}
+
+	// Write the manifest first so an interrupted move is still recoverable
+	if err := writeTrashManifest(entryDir, entry); err != nil {
+		os.RemoveAll(entryDir)
+		return nil, err
+	}
+	dataPath := filepath.Join(entryDir, trashDataName)
+	if err := MoveTree(ctx, path, dataPath, progress); err != nil {
+		// Keep the entry if the data already made it into the trash, since
+		// the original may be partially removed by now
+		if _, statErr := os.Lstat(dataPath); os.IsNotExist(statErr) {
+			os.RemoveAll(entryDir)
+		}
+		return nil, fmt.Errorf("failed to move %s to trash: %w", entry.Name, err)
+	}
+	return entry, nil
+}
+
+// TrashFile moves a single entry of an instance section ("mods", "config" or
+// "saves") to the trash. Config entries may be paths below config/.
+func (m *Manager) TrashFile(instanceName, section, fileName string) (*TrashEntry, error) {
+	return m.TrashFileContext(context.Background(), instanceName, section, fileName, nil)
+}
+
+// TrashFileContext trashes a file like TrashFile. Progress is only reported
+// when the trash is on a different filesystem, e.g. for a large world.
+func (m *Manager) TrashFileContext(ctx context.Context, instanceName, section, fileName string, progress ProgressFunc) (*TrashEntry, error) {
+	path, kind, err := m.trashFilePath(instanceName, section, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return m.moveToTrash(ctx, path, kind, instanceName, progress)
+}
+
+// trashFilePath checks the arguments of TrashFile and returns the path to
+// trash and its kind.
+func (m *Manager) trashFilePath(instanceName, section, fileName string) (string, string, error) {
+	kinds := map[string]string{
+		"mods": "mod", "config": "config", "saves": "save",
+		"resourcepacks": "resourcepack", "shaderpacks": "shaderpack",
+	}
+	kind, ok := kinds[section]
+	if !ok {
+		return "", "", fmt.Errorf("unknown section: %s", section)
+	}
+	// Configs may sit in subfolders of config/
+	if !filepath.IsLocal(fileName) || section != "config" && fileName != filepath.Base(fileName) {
+		return "", "", fmt.Errorf("invalid file name: %q", fileName)
+	}
+
+	path := filepath.Join(m.InstancePath(instanceName), section, filepath.FromSlash(fileName))
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return "", "", fmt.Errorf("%s does not exist", fileName)
+	}
+	return path, kind, nil
+}
+
+// ListTrash returns all trash entries, newest first.
+func (m *Manager) ListTrash() ([]TrashEntry, error) {
+	dirs, err := os.ReadDir(m.TrashPath())
+	if os.IsNotExist(err) {
+		return []TrashEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	entries := []TrashEntry{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := readTrashManifest(filepath.Join(m.TrashPath(), d.Name()))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreTrash moves a trash entry back to where it was deleted from. It
+// refuses to overwrite anything that has since been created in its place.
+func (m *Manager) RestoreTrash(id string) (*TrashEntry, error) {
+	return m.RestoreTrashContext(context.Background(), id, nil)
+}
+
+// RestoreTrashContext restores a trash entry like RestoreTrash. Progress is
+// only reported when the entry has to be copied back across filesystems.
+func (m *Manager) RestoreTrashContext(ctx context.Context, id string, progress ProgressFunc) (*TrashEntry, error) {
+	if id == "" || id != filepath.Base(id) {
+		return nil, errorOf(ErrTrashNotFound, "trash entry '%s' not found", id)
+	}
+	entryDir := filepath.Join(m.TrashPath(), id)
+	entry, err := readTrashManifest(entryDir)
+	if err != nil {
+		return nil, errorOf(ErrTrashNotFound, "trash entry '%s' not found", id)
+	}
+
+	if _, err := os.Lstat(entry.Origin); err == nil {
+		return nil, fmt.Errorf("cannot restore %s: %s already exists", entry.Name, entry.Origin)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Origin), 0755); err != nil {
+		return nil, fmt.Errorf("failed to recreate parent directory: %w", err)
+	}
+	if err := MoveTree(ctx, filepath.Join(entryDir, trashDataName), entry.Origin, progress); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+	}
+	if err := os.RemoveAll(entryDir); err != nil {
+		return nil, fmt.Errorf("failed to clean up trash entry: %w", err)
+	}
+	return entry, nil
+}
+
+// EmptyTrash permanently removes trash entries deleted more than olderThan
+// ago; a zero duration removes everything. It returns the removed entries.
+func (m *Manager) EmptyTrash(olderThan time.Duration) ([]TrashEntry, error) {
+	entries, err := m.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []TrashEntry{}
+	cutoff := time.Now().Add(-olderThan)
+	for _, e := range entries {
+		if olderThan > 0 && e.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(m.TrashPath(), e.ID)); err != nil {
+			return removed, fmt.Errorf("failed to remove %s from trash: %w", e.ID, err)
+		}
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// PurgeExpiredTrash applies the retention policy. Failures are ignored; they
+// are retried on the next deletion.
+func (m *Manager) PurgeExpiredTrash() {
+	m.EmptyTrash(m.TrashRetention())
+}
+
+func writeTrashManifest(entryDir string, entry *TrashEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, trashManifestName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+	return nil
+}
+
+func readTrashManifest(entryDir string) (*TrashEntry, error) {
+	data, err := os.ReadFile(filepath.Join(entryDir, trashManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var entry TrashEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// sanitizeTrashName keeps trash IDs safe to use as a single path element.
+func sanitizeTrashName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
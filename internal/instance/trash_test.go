@@ -0,0 +1,197 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrashFileAndRestore(t *testing.T) {
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, map[string]string{
+		"survival/mods/sodium.jar":    "jar",
+		"survival/config/jei/jei.cfg": "cfg",
+	})
+
+	for _, tt := range []struct{ section, file string }{
+		{"worlds", "sodium.jar"},
+		{"mods", "../config/jei/jei.cfg"},
+		{"mods", "/etc/passwd"},
+		{"config", "jei/../../mods/sodium.jar"},
+		{"mods", "missing.jar"},
+	} {
+		if _, err := m.TrashFile("survival", tt.section, tt.file); err == nil {
+			t.Errorf("TrashFile(%s, %s) succeeded", tt.section, tt.file)
+		}
+	}
+
+	before := time.Now().UTC()
+	entry, err := m.TrashFile("survival", "config", "jei/jei.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := filepath.Join(m.InstancePath("survival"), "config", "jei", "jei.cfg")
+	if _, err := os.Lstat(origin); !os.IsNotExist(err) {
+		t.Errorf("trashed file still exists: %v", err)
+	}
+
+	// The manifest records where the data came from
+	entryDir := filepath.Join(m.TrashPath(), entry.ID)
+	data, err := os.ReadFile(filepath.Join(entryDir, trashManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest TrashEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest != *entry {
+		t.Errorf("manifest = %+v, want %+v", manifest, *entry)
+	}
+	if entry.Name != "jei.cfg" || entry.Kind != "config" || entry.Instance != "survival" ||
+		entry.Origin != origin || entry.SizeBytes != 3 || entry.DeletedAt.Before(before) {
+		t.Errorf("entry = %+v", entry)
+	}
+	if got, _ := os.ReadFile(filepath.Join(entryDir, trashDataName)); string(got) != "cfg" {
+		t.Errorf("trashed data = %q", got)
+	}
+
+	list, err := m.ListTrash()
+	if err != nil || len(list) != 1 || list[0] != *entry {
+		t.Errorf("ListTrash = %+v, %v", list, err)
+	}
+
+	// Restoring refuses to replace a file created in the meantime
+	writeTree(t, filepath.Dir(origin), map[string]string{"jei.cfg": "new"})
+	if _, err := m.RestoreTrash(entry.ID); err == nil {
+		t.Error("restoring over a new file succeeded")
+	}
+	if got, _ := os.ReadFile(origin); string(got) != "new" {
+		t.Errorf("refused restore changed the new file to %q", got)
+	}
+
+	// The parent folder is recreated when it was removed as well
+	os.RemoveAll(filepath.Dir(origin))
+	if _, err := m.RestoreTrash(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(origin); string(got) != "cfg" {
+		t.Errorf("restored file = %q, want %q", got, "cfg")
+	}
+	if _, err := os.Lstat(entryDir); !os.IsNotExist(err) {
+		t.Errorf("trash entry was not removed: %v", err)
+	}
+
+	for _, id := range []string{entry.ID, "", "../" + entry.ID, "missing"} {
+		if _, err := m.RestoreTrash(id); !errors.Is(err, ErrTrashNotFound) {
+			t.Errorf("RestoreTrash(%q) = %v, want ErrTrashNotFound", id, err)
+		}
+	}
+}
+
+// ageTrashEntry rewrites the manifest of id as if it was deleted age ago.
+func ageTrashEntry(t *testing.T, m *Manager, id string, age time.Duration) {
+	t.Helper()
+	entryDir := filepath.Join(m.TrashPath(), id)
+	entry, err := readTrashManifest(entryDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.DeletedAt = time.Now().Add(-age).UTC()
+	if err := writeTrashManifest(entryDir, entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func trashIDs(t *testing.T, m *Manager) []string {
+	t.Helper()
+	entries, err := m.ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestTrashExpiry(t *testing.T) {
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, map[string]string{
+		"survival/mods/a.jar": "a",
+		"survival/mods/b.jar": "b",
+		"survival/mods/c.jar": "c",
+	})
+	if got := m.TrashRetention(); got != DefaultTrashRetentionDays*24*time.Hour {
+		t.Errorf("default retention = %v", got)
+	}
+	m.cfg.TrashRetentionDays = 7
+
+	old, err := m.TrashFile("survival", "mods", "a.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := m.TrashFile("survival", "mods", "b.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ageTrashEntry(t, m, old.ID, 8*24*time.Hour)
+	ageTrashEntry(t, m, recent.ID, 6*24*time.Hour)
+	// Folders without a manifest are not entries and are left alone
+	stray := filepath.Join(m.TrashPath(), "stray")
+	writeTree(t, stray, map[string]string{"data": "?"})
+
+	// Trashing more purges what is past the retention period first
+	newest, err := m.TrashFile("survival", "mods", "c.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := trashIDs(t, m), []string{newest.ID, recent.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trash after purging = %v, want %v", got, want)
+	}
+
+	removed, err := m.EmptyTrash(24 * time.Hour)
+	if err != nil || len(removed) != 1 || removed[0].ID != recent.ID {
+		t.Errorf("EmptyTrash(1 day) = %+v, %v", removed, err)
+	}
+	if removed, err := m.EmptyTrash(0); err != nil || len(removed) != 1 || removed[0].ID != newest.ID {
+		t.Errorf("EmptyTrash(0) = %+v, %v", removed, err)
+	}
+	if got := trashIDs(t, m); len(got) != 0 {
+		t.Errorf("trash after emptying = %v", got)
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("the stray folder was removed: %v", err)
+	}
+}
+
+func TestSwitchTrashesOldBackup(t *testing.T) {
+	m := newTestManager(t)
+	writeTree(t, m.InstancesPath, map[string]string{"survival/options.txt": "fov:90"})
+	writeTree(t, m.MinecraftPath, map[string]string{"options.txt": "current"})
+	writeTree(t, m.BackupPath, map[string]string{"options.txt": "older"})
+
+	if err := m.SwitchInstance("survival"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTree(t, m.BackupPath); got["options.txt"] != "current" {
+		t.Errorf("backup = %v, want the replaced minecraft directory", got)
+	}
+
+	entries, err := m.ListTrash()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ListTrash = %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.Kind != "backup" || e.Origin != m.BackupPath {
+		t.Errorf("trash entry = %+v", e)
+	}
+	got := readTree(t, filepath.Join(m.TrashPath(), entries[0].ID, trashDataName))
+	if got["options.txt"] != "older" {
+		t.Errorf("trashed backup = %v, want the older backup", got)
+	}
+}
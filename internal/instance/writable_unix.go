@@ -0,0 +1,12 @@
+//go:build unix
+
+package instance
+
+import "golang.org/x/sys/unix"
+
+// dirWritable reports whether the current user may create entries in dir.
+// It asks the kernel instead of creating a file, so checking has no side
+// effects.
+func dirWritable(dir string) bool {
+	return unix.Access(dir, unix.W_OK) == nil
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Follow calls fn with the matching lines appended to the log until ctx is
+// done, like tail -F. When the game starts again and replaces the file,
+// reading starts over at the top of the new one.
+func (r *Reader) Follow(ctx context.Context, fn func([]Line)) error {
+	if r.Compressed() {
+		return fmt.Errorf("%s is an archived log and does not change", filepath.Base(r.Path))
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", filepath.Base(r.Path), err)
+	}
+	defer w.Close()
+	// Watch the folder, as the file itself is replaced on every start
+	if err := w.Add(filepath.Dir(r.Path)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(r.Path), err)
+	}
+
+	// Catch up with what was written before the watch started
+	read := func() error {
+		lines, err := r.Read(0)
+		if os.IsNotExist(err) {
+			return nil // wait for the file to be created
+		}
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			fn(lines)
+		}
+		return nil
+	}
+	if err := read(); err != nil {
+		return err
+	}
+
+	path := filepath.Clean(r.Path)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(ev.Name) != path || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+				continue
+			}
+			if ev.Has(fsnotify.Create) {
+				r.reset()
+			}
+			if err := read(); err != nil {
+				return err
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch %s: %w", filepath.Base(r.Path), err)
+		}
+	}
+}
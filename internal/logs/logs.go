@@ -0,0 +1,245 @@
+// Package logs reads the game logs of an instance: logs/latest.log, the
+// gzipped logs the game rotates it into, and the lines appended to
+// latest.log while the game runs.
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the folder of an instance that holds its logs.
+const DirName = "logs"
+
+// Latest is the log of the running or last run game.
+const Latest = "latest.log"
+
+// Dir returns the logs folder of the instance at instanceDir.
+func Dir(instanceDir string) string {
+	return filepath.Join(instanceDir, DirName)
+}
+
+// Level is the log4j level of a line.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// MarshalText writes the level by name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseLevel reads a level name such as "warn", case-insensitively.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(s)
+	if name == "WARNING" {
+		name = "WARN"
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q (want one of %s)", s, strings.Join(levelNames, ", "))
+}
+
+// levelPattern finds the "[thread/LEVEL]" of a log4j line, as written by
+// vanilla ("[12:34:56] [Render thread/INFO]: ...") and by the Forge and
+// Fabric layouts ("[18Oct2026 12:34:56.789] [main/WARN] [mixin/]: ...").
+var levelPattern = regexp.MustCompile(`^\[[^\]]*\] \[[^\]]*/(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL)\]`)
+
+// Line is one line of a log.
+type Line struct {
+	Number int    `json:"line" yaml:"line"`
+	Level  Level  `json:"level" yaml:"level"`
+	Text   string `json:"text" yaml:"text"`
+}
+
+// File is a log file of an instance.
+type File struct {
+	Name       string    `json:"name" yaml:"name"`
+	Path       string    `json:"path" yaml:"path"`
+	Size       int64     `json:"size" yaml:"size"`
+	ModTime    time.Time `json:"mod_time" yaml:"mod_time"`
+	Compressed bool      `json:"compressed" yaml:"compressed"`
+}
+
+// Files lists the logs of the instance at instanceDir: latest.log first,
+// then the others from newest to oldest. A missing logs folder has none.
+func Files(instanceDir string) ([]File, error) {
+	dir := Dir(instanceDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs directory: %w", err)
+	}
+
+	var files []File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{
+			Name:       name,
+			Path:       filepath.Join(dir, name),
+			Size:       info.Size(),
+			ModTime:    info.ModTime(),
+			Compressed: strings.HasSuffix(name, ".gz"),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if (files[i].Name == Latest) != (files[j].Name == Latest) {
+			return files[i].Name == Latest
+		}
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// Filter selects the lines of a log to show.
+type Filter struct {
+	MinLevel Level
+	Grep     *regexp.Regexp // nil matches every line
+}
+
+// Match reports whether the filter keeps line.
+func (f Filter) Match(line Line) bool {
+	return line.Level >= f.MinLevel && (f.Grep == nil || f.Grep.MatchString(line.Text))
+}
+
+// Reader reads the lines of a log file. Lines without a level of their
+// own, such as those of a stack trace, get the level of the line before.
+type Reader struct {
+	Path   string
+	Filter Filter
+
+	offset  int64  // of the plain file, up to where it has been read
+	partial []byte // unfinished last line of a plain file
+	number  int
+	level   Level
+}
+
+// NewReader returns a reader of the log at path.
+func NewReader(path string, filter Filter) *Reader {
+	return &Reader{Path: path, Filter: filter, level: LevelInfo}
+}
+
+// Compressed reports whether the log is gzipped, and so cannot grow.
+func (r *Reader) Compressed() bool {
+	return strings.HasSuffix(r.Path, ".gz")
+}
+
+// Read returns the matching lines added since the last call, or all of
+// them on the first, keeping only the last n unless n is 0. A line the game
+// is still writing is held back until it is complete.
+func (r *Reader) Read(n int) ([]Line, error) {
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var src io.Reader = f
+	if r.Compressed() {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", filepath.Base(r.Path), err)
+		}
+		defer gz.Close()
+		src = gz
+	} else {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() < r.offset {
+			// Truncated, or replaced when the game started again
+			r.reset()
+		}
+		if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	var lines []Line
+	br := bufio.NewReaderSize(src, 64<<10)
+	for {
+		chunk, err := br.ReadBytes('\n')
+		if len(chunk) > 0 {
+			r.offset += int64(len(chunk))
+			if chunk[len(chunk)-1] != '\n' && !r.Compressed() {
+				r.partial = append(r.partial, chunk...)
+			} else {
+				text := append(r.partial, chunk...)
+				r.partial = nil
+				if line := r.parse(text); r.Filter.Match(line) {
+					lines = append(lines, line)
+					if n > 0 && len(lines) > 2*n {
+						// Don't hold all of a huge log for its last lines
+						lines = append(lines[:0], lines[len(lines)-n:]...)
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(r.Path), err)
+		}
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// parse numbers a raw line and finds its level.
+func (r *Reader) parse(raw []byte) Line {
+	r.number++
+	text := string(bytes.TrimRight(raw, "\r\n"))
+	if m := levelPattern.FindStringSubmatch(text); m != nil {
+		r.level, _ = ParseLevel(m[1])
+	}
+	return Line{Number: r.number, Level: r.level, Text: text}
+}
+
+// reset starts reading the file from the beginning again.
+func (r *Reader) reset() {
+	r.offset = 0
+	r.partial = nil
+	r.number = 0
+	r.level = LevelInfo
+}
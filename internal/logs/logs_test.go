@@ -0,0 +1,169 @@
+package logs
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const sampleLog = `[12:00:00] [main/INFO]: Loading Minecraft 1.20.1 with Fabric Loader 0.14.21
+[12:00:01] [main/WARN]: Mod sodium uses a mixin that failed to apply
+[12:00:02] [Render thread/ERROR]: Unreported exception thrown!
+java.lang.NullPointerException: Cannot invoke "Object.toString()"
+	at com.example.mymod.Thing.tick(Thing.java:42)
+	at net.minecraft.client.MinecraftClient.tick(MinecraftClient.java:1800)
+Caused by: java.lang.IllegalStateException
+	... 12 more
+[12:00:03] [Render thread/INFO]: Stopping!
+plain line without a level
+[18Oct2026 12:00:04.123] [main/WARNING] [mixin/]: Forge layout warning
+	at a.b.C.d(C.java:1)
+[12:00:05] [main/DEBUG]: debug line
+[12:00:06] [main/FATAL]: fatal line
+`
+
+func writeLog(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLevelInheritance(t *testing.T) {
+	lines, err := NewReader(writeLog(t, Latest, sampleLog), Filter{}).Read(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Level{
+		LevelInfo,
+		LevelWarn,
+		LevelError, LevelError, LevelError, LevelError, LevelError, LevelError, // the stack trace
+		LevelInfo, LevelInfo, // a plain line keeps the level before it
+		LevelWarn, LevelWarn,
+		LevelDebug,
+		LevelFatal,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Read returned %d lines, want %d", len(lines), len(want))
+	}
+	for i, l := range lines {
+		if l.Number != i+1 {
+			t.Errorf("line %d numbered %d", i+1, l.Number)
+		}
+		if l.Level != want[i] {
+			t.Errorf("line %d %q: level %s, want %s", i+1, l.Text, l.Level, want[i])
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		n      int
+		want   []int // line numbers
+	}{
+		{"errors keep their stack trace", Filter{MinLevel: LevelError}, 0, []int{3, 4, 5, 6, 7, 8, 14}},
+		{"grep", Filter{Grep: regexp.MustCompile(`(?i)mixin`)}, 0, []int{2, 11}},
+		{"level and grep", Filter{MinLevel: LevelWarn, Grep: regexp.MustCompile(`\tat `)}, 0, []int{5, 6, 12}},
+		{"last n", Filter{MinLevel: LevelWarn}, 2, []int{12, 14}},
+		{"trace shows everything", Filter{MinLevel: LevelTrace}, 0, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := NewReader(writeLog(t, Latest, sampleLog), tt.filter).Read(tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, l := range lines {
+				got = append(got, l.Number)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("lines %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadIncrementally(t *testing.T) {
+	path := writeLog(t, Latest, "[12:00:00] [main/ERROR]: boom\n\tat a.b.C")
+	r := NewReader(path, Filter{MinLevel: LevelError})
+
+	lines, err := r.Read(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("first Read = %+v, want only the complete line", lines)
+	}
+
+	// The game finishes the line, then starts a new stack trace line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(".d(C.java:1)\n\tat e.F.g(F.java:2)\n")
+	f.Close()
+	lines, err = r.Read(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0].Text != "\tat a.b.C.d(C.java:1)" || lines[0].Level != LevelError || lines[1].Number != 3 {
+		t.Errorf("second Read = %+v", lines)
+	}
+
+	// A new game start replaces the file with a shorter one
+	if err := os.WriteFile(path, []byte("[12:00:00] [main/INFO]: hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r.Filter = Filter{}
+	lines, err = r.Read(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Number != 1 || lines[0].Level != LevelInfo {
+		t.Errorf("Read after truncation = %+v", lines)
+	}
+}
+
+func TestReadCompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2026-10-18-1.log.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(strings.TrimSuffix(sampleLog, "\n"))) // archived logs may lack the last newline
+	gz.Close()
+	f.Close()
+
+	lines, err := NewReader(path, Filter{MinLevel: LevelFatal}).Read(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Number != 14 {
+		t.Errorf("Read = %+v, want the fatal line 14", lines)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"trace": LevelTrace, "Warn": LevelWarn, "WARNING": LevelWarn, "error": LevelError} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %s, %v, want %s", s, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud) succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package modconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// forgeTypes maps the type prefixes of Forge .cfg entries to types.
+var forgeTypes = map[byte]Type{'B': TypeBool, 'I': TypeInt, 'D': TypeFloat, 'S': TypeString}
+
+// parseForgeCfg reads the .cfg format of Forge's Configuration class, used
+// by mods up to Minecraft 1.12:
+//
+//	general {
+//	    # [range: 1 ~ 64, default: 16]
+//	    I:stackSize=16
+//	    S:blacklist <
+//	        minecraft:stone
+//	     >
+//	}
+func parseForgeCfg(d *Document) error {
+	var (
+		category []string
+		comment  []string
+		list     *Entry // the list being read, if any
+	)
+	for pos := 0; pos < len(d.src); {
+		lineStart := pos
+		end := lineEnd(d.src, pos)
+		pos = nextLine(d.src, end)
+		raw := string(d.src[lineStart:end])
+		line := strings.TrimSpace(raw)
+		lineNo := d.line(lineStart)
+
+		if list != nil {
+			if line == ">" {
+				list.Value = "[" + list.Value + "]"
+				d.add(list)
+				list = nil
+			} else if line != "" {
+				list.Value = strings.TrimPrefix(list.Value+", "+line, ", ")
+			}
+			continue
+		}
+
+		switch {
+		case line == "":
+			comment = nil
+		case strings.HasPrefix(line, "#"):
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+		case strings.HasPrefix(line, "~"):
+			// ~CONFIG_VERSION and similar file properties
+		case line == "}":
+			if len(category) == 0 {
+				return fmt.Errorf("line %d: } without a category", lineNo)
+			}
+			category = category[:len(category)-1]
+			comment = nil
+		case strings.HasSuffix(line, "{"):
+			name := unquoteCfg(strings.TrimSpace(strings.TrimSuffix(line, "{")))
+			category = append(category, name)
+			d.add(&Entry{
+				Path:    append([]string(nil), category...),
+				Type:    TypeSection,
+				Line:    lineNo,
+				Comment: strings.Join(comment, "\n"),
+			})
+			comment = nil
+		default:
+			t, ok := forgeTypes[line[0]]
+			if len(line) < 3 || line[1] != ':' || !ok {
+				return fmt.Errorf("line %d: expected a T:name=value entry", lineNo)
+			}
+			rest := line[2:]
+			name, rest := cfgName(rest)
+			path := append(append([]string(nil), category...), name)
+			e := &Entry{Path: path, Type: t, Line: lineNo, Comment: strings.Join(comment, "\n")}
+			comment = nil
+
+			rest = strings.TrimLeft(rest, " \t")
+			switch {
+			case strings.HasPrefix(rest, "<"):
+				// Lists are shown but edited in a text editor
+				e.Type = TypeList
+				list = e
+			case strings.HasPrefix(rest, "="):
+				// The value runs to the end of the line
+				lead := len(raw) - len(strings.TrimLeft(raw, " \t"))
+				e.start = lineStart + lead + len(line) - len(rest) + 1
+				e.end = lineStart + len(strings.TrimRight(raw, " \t"))
+				e.end = max(e.end, e.start)
+				e.Value = string(d.src[e.start:e.end])
+				e.editable = true
+				d.add(e)
+			default:
+				return fmt.Errorf("line %d: expected = or < after %s", lineNo, name)
+			}
+		}
+	}
+	if list != nil {
+		return fmt.Errorf("list %s is not closed", list.Name())
+	}
+	if len(category) > 0 {
+		return fmt.Errorf("category %s is not closed", strings.Join(category, "."))
+	}
+	return nil
+}
+
+// cfgName reads a possibly quoted entry name and returns it and the rest of
+// the line.
+func cfgName(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `"`); end >= 0 {
+			return s[1 : end+1], s[end+2:]
+		}
+	}
+	end := strings.IndexAny(s, "=<")
+	if end < 0 {
+		return strings.TrimSpace(s), ""
+	}
+	return strings.TrimSpace(s[:end]), s[end:]
+}
+
+func unquoteCfg(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,327 @@
+package modconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// jsonParser reads JSON5, which covers JSON and the comments and trailing
+// commas many mods write into their .json files anyway.
+type jsonParser struct {
+	d        *Document
+	pos      int
+	comments []string // comment lines waiting for the next key
+	last     *Entry   // last scalar, which takes comments on its line
+	lastLine int
+}
+
+func parseJSON(d *Document) error {
+	p := &jsonParser{d: d}
+	if strings.HasPrefix(string(d.src), "\ufeff") {
+		p.pos = 3
+	}
+	p.skipSpace()
+	if p.pos >= len(d.src) {
+		return nil
+	}
+	if _, err := p.value(nil); err != nil {
+		return fmt.Errorf("line %d: %w", d.line(p.pos), err)
+	}
+	if p.skipSpace(); p.pos < len(d.src) {
+		return fmt.Errorf("line %d: unexpected data after the end of the document", d.line(p.pos))
+	}
+	return nil
+}
+
+func (p *jsonParser) peek() byte {
+	if p.pos < len(p.d.src) {
+		return p.d.src[p.pos]
+	}
+	return 0
+}
+
+// skipSpace skips whitespace and comments. A comment on the line of the
+// last value belongs to it; others wait for the next key.
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.d.src) {
+		switch p.d.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+			continue
+		case '/':
+		default:
+			return
+		}
+
+		start := p.pos
+		var text string
+		switch {
+		case strings.HasPrefix(string(p.d.src[p.pos:min(p.pos+2, len(p.d.src))]), "//"):
+			for p.pos < len(p.d.src) && p.d.src[p.pos] != '\n' {
+				p.pos++
+			}
+			text = strings.TrimSpace(string(p.d.src[start+2 : p.pos]))
+		case strings.HasPrefix(string(p.d.src[p.pos:min(p.pos+2, len(p.d.src))]), "/*"):
+			end := strings.Index(string(p.d.src[p.pos+2:]), "*/")
+			if end < 0 {
+				p.pos = len(p.d.src)
+				return
+			}
+			p.pos += 2 + end + 2
+			var lines []string
+			for _, l := range strings.Split(string(p.d.src[start+2:p.pos-2]), "\n") {
+				if l = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "*")); l != "" {
+					lines = append(lines, l)
+				}
+			}
+			text = strings.Join(lines, "\n")
+		default:
+			return
+		}
+		if text == "" {
+			continue
+		}
+		if p.last != nil && p.d.line(start) == p.lastLine {
+			p.last.Comment = strings.TrimPrefix(p.last.Comment+"\n"+text, "\n")
+		} else {
+			p.comments = append(p.comments, text)
+		}
+	}
+}
+
+func (p *jsonParser) takeComments() string {
+	s := strings.Join(p.comments, "\n")
+	p.comments = nil
+	return s
+}
+
+func childPath(path []string, key string) []string {
+	return append(append([]string(nil), path...), key)
+}
+
+// value reads the value at path. Objects become sections; arrays become
+// sections too if they hold objects or arrays, and are otherwise a single
+// list entry edited as a literal.
+func (p *jsonParser) value(path []string) (*Entry, error) {
+	p.skipSpace()
+	e := &Entry{Path: path, Line: p.d.line(p.pos), Comment: p.takeComments()}
+	p.last = nil
+
+	switch c := p.peek(); c {
+	case '{':
+		e.Type = TypeSection
+		if len(path) > 0 {
+			p.d.add(e)
+		}
+		p.pos++
+		for {
+			p.skipSpace()
+			if p.peek() == '}' {
+				p.pos++
+				return e, nil
+			}
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.peek() != ':' {
+				return nil, fmt.Errorf("expected : after %q", key)
+			}
+			p.pos++
+			if _, err := p.value(childPath(path, key)); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case '}':
+				p.pos++
+				return e, nil
+			default:
+				return nil, fmt.Errorf("expected , or } after %q", key)
+			}
+		}
+
+	case '[':
+		e.Type = TypeSection
+		index := len(p.d.Entries)
+		if len(path) > 0 {
+			p.d.add(e)
+			index = len(p.d.Entries)
+		}
+		e.start = p.pos
+		p.pos++
+		containers := false
+		var items []string
+		for n := 0; ; n++ {
+			p.skipSpace()
+			if p.peek() == ']' {
+				p.pos++
+				break
+			}
+			child, err := p.value(childPath(path, fmt.Sprintf("[%d]", n)))
+			if err != nil {
+				return nil, err
+			}
+			containers = containers || child.Type == TypeSection
+			if child.Type == TypeList {
+				items = append(items, child.Value)
+			} else {
+				items = append(items, string(p.d.src[child.start:child.end]))
+			}
+			p.skipSpace()
+			if p.peek() == ',' {
+				p.pos++
+			} else if p.peek() != ']' {
+				return nil, fmt.Errorf("expected , or ] in array")
+			}
+		}
+		if !containers && len(path) > 0 {
+			p.d.Entries = p.d.Entries[:index]
+			delete(p.d.sections, pathName(path))
+			e.Type = TypeList
+			e.Value = "[" + strings.Join(items, ", ") + "]"
+			e.editable, e.end = true, p.pos
+			p.last, p.lastLine = e, p.d.line(p.pos)
+		}
+		return e, nil
+
+	case '"', '\'':
+		e.start = p.pos
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		e.Type, e.Value, e.quote = TypeString, s, c
+
+	default:
+		e.start = p.pos
+		for p.pos < len(p.d.src) && (isBareKey(p.d.src[p.pos]) || p.d.src[p.pos] == '.' || p.d.src[p.pos] == '+') {
+			p.pos++
+		}
+		s := string(p.d.src[e.start:p.pos])
+		switch {
+		case s == "":
+			return nil, fmt.Errorf("expected a value")
+		case s == "true" || s == "false":
+			e.Type = TypeBool
+		case s == "null":
+			e.Type = TypeNull
+		case json5Number.MatchString(s):
+			e.Type = TypeInt
+			if strings.ContainsAny(s, ".IN") || !strings.ContainsAny(s, "xX") && strings.ContainsAny(s, "eE") {
+				e.Type = TypeFloat
+			}
+		default:
+			return nil, fmt.Errorf("invalid value %q", s)
+		}
+		e.Value = s
+	}
+
+	e.end = p.pos
+	e.editable = len(path) > 0
+	if len(path) > 0 {
+		p.d.add(e)
+	}
+	p.last, p.lastLine = e, p.d.line(p.pos)
+	return e, nil
+}
+
+// key reads an object key, which JSON5 allows to be unquoted.
+func (p *jsonParser) key() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.string()
+	}
+	start := p.pos
+	for p.pos < len(p.d.src) && !strings.ContainsRune(" \t\r\n:/", rune(p.d.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("expected a key")
+	}
+	return string(p.d.src[start:p.pos]), nil
+}
+
+// string reads a string in double or, as JSON5 allows, single quotes.
+func (p *jsonParser) string() (string, error) {
+	quote := p.d.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.d.src) {
+		c := p.d.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\n':
+			return "", fmt.Errorf("unterminated string")
+		case c == '\\':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *jsonParser) escape(sb *strings.Builder) error {
+	if p.pos+1 >= len(p.d.src) {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.d.src[p.pos+1]
+	p.pos += 2
+	simple := map[byte]string{'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '0': "\x00",
+		'"': `"`, '\'': "'", '\\': `\`, '/': "/", '\n': ""}
+	if s, ok := simple[c]; ok {
+		sb.WriteString(s)
+		return nil
+	}
+	if c == '\r' {
+		// A line continuation
+		if p.peek() == '\n' {
+			p.pos++
+		}
+		return nil
+	}
+
+	hex := func(digits int) (rune, error) {
+		if p.pos+digits > len(p.d.src) {
+			return 0, fmt.Errorf("invalid escape \\%c", c)
+		}
+		n, err := strconv.ParseUint(string(p.d.src[p.pos:p.pos+digits]), 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid escape \\%c%s", c, p.d.src[p.pos:p.pos+digits])
+		}
+		p.pos += digits
+		return rune(n), nil
+	}
+	switch c {
+	case 'x':
+		r, err := hex(2)
+		sb.WriteRune(r)
+		return err
+	case 'u':
+		r, err := hex(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) && strings.HasPrefix(string(p.d.src[p.pos:]), `\u`) {
+			p.pos += 2
+			low, err := hex(4)
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		sb.WriteRune(r)
+		return nil
+	}
+	return fmt.Errorf("invalid escape \\%c", c)
+}
@@ -0,0 +1,589 @@
+// Package modconfig parses the config files mods ship (TOML, JSON/JSON5,
+// .properties and Forge .cfg) into a tree of keys whose values can be
+// edited in place. Only the bytes of an edited value are rewritten, so the
+// layout and comments of the rest of the file are kept.
+package modconfig
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format is the syntax of a config file.
+type Format string
+
+const (
+	FormatTOML       Format = "toml"
+	FormatJSON       Format = "json"
+	FormatJSON5      Format = "json5"
+	FormatProperties Format = "properties"
+	FormatForgeCfg   Format = "cfg"
+)
+
+// FormatOf returns the format of the config file at path, judged by its
+// extension.
+func FormatOf(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return FormatTOML, true
+	case ".json":
+		return FormatJSON, true
+	case ".json5":
+		return FormatJSON5, true
+	case ".properties":
+		return FormatProperties, true
+	case ".cfg":
+		return FormatForgeCfg, true
+	}
+	return "", false
+}
+
+// Type is the type of an entry's value.
+type Type string
+
+const (
+	TypeSection  Type = "section" // a table, category or object holding other entries
+	TypeString   Type = "string"
+	TypeInt      Type = "int"
+	TypeFloat    Type = "float"
+	TypeBool     Type = "bool"
+	TypeDatetime Type = "datetime"
+	TypeList     Type = "list"  // an array, edited as a literal
+	TypeTable    Type = "table" // a TOML inline table, edited as a literal
+	TypeNull     Type = "null"
+)
+
+// Entry is one key of a config file.
+type Entry struct {
+	// Path holds the keys from the root of the file; elements of arrays of
+	// tables or objects are "[n]"
+	Path []string
+	Type Type
+	// Value is the decoded value of strings and the literal text of
+	// everything else; lists are shown on one line
+	Value   string
+	Comment string // comments above or after the key, one per line
+	Line    int    // 1-based line of the key
+
+	// Min, Max and Allowed are the constraints found in the comment, as
+	// Forge writes them ("Range: 1 ~ 64", "Allowed Values: A, B")
+	Min, Max *float64
+	Allowed  []string
+
+	editable   bool
+	start, end int  // byte span of the value in the source
+	quote      byte // quote character of a string value, if any
+}
+
+// Key returns the last element of the entry's path.
+func (e *Entry) Key() string {
+	if len(e.Path) == 0 {
+		return ""
+	}
+	return e.Path[len(e.Path)-1]
+}
+
+// Name returns the entry's path joined with dots, such as
+// "client.rendering" or "mods[0].id".
+func (e *Entry) Name() string {
+	return pathName(e.Path)
+}
+
+func pathName(path []string) string {
+	var sb strings.Builder
+	for i, key := range path {
+		if i > 0 && !strings.HasPrefix(key, "[") {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(key)
+	}
+	return sb.String()
+}
+
+// Depth returns how deep the entry is nested; top-level keys are 0.
+func (e *Entry) Depth() int {
+	return len(e.Path) - 1
+}
+
+// Editable reports whether Set can change the entry.
+func (e *Entry) Editable() bool {
+	return e.editable
+}
+
+// Constraint describes Min, Max and Allowed, or returns "".
+func (e *Entry) Constraint() string {
+	switch {
+	case len(e.Allowed) > 0:
+		return "one of " + strings.Join(e.Allowed, ", ")
+	case e.Min != nil && e.Max != nil:
+		return fmt.Sprintf("%s ~ %s", formatNumber(*e.Min), formatNumber(*e.Max))
+	case e.Min != nil:
+		return "≥ " + formatNumber(*e.Min)
+	case e.Max != nil:
+		return "≤ " + formatNumber(*e.Max)
+	}
+	return ""
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Document is a parsed config file.
+type Document struct {
+	Format  Format
+	Entries []*Entry // in file order, each section before its keys
+
+	src      []byte
+	lines    []int // offsets at which lines start
+	sections map[string]bool
+}
+
+// Parse parses data as a config file of the given format.
+func Parse(format Format, data []byte) (*Document, error) {
+	d := &Document{Format: format, src: data, sections: map[string]bool{}}
+	d.lines = append(d.lines, 0)
+	for i, c := range data {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	var err error
+	switch format {
+	case FormatTOML:
+		err = parseTOML(d)
+	case FormatJSON, FormatJSON5:
+		err = parseJSON(d)
+	case FormatProperties:
+		err = parseProperties(d)
+	case FormatForgeCfg:
+		err = parseForgeCfg(d)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range d.Entries {
+		e.parseConstraints()
+	}
+	return d, nil
+}
+
+// ReadFile reads and parses the config file at path.
+func ReadFile(path string) (*Document, error) {
+	format, ok := FormatOf(path)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a supported config format", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Parse(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return d, nil
+}
+
+// WriteFile writes d to path through a temporary file, so a failed write
+// leaves the old file intact. The file keeps its permissions.
+func WriteFile(path string, d *Document) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(d.src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Bytes returns the text of the document with all edits applied.
+func (d *Document) Bytes() []byte {
+	return d.src
+}
+
+// Find returns the entry with the given dotted name.
+func (d *Document) Find(name string) *Entry {
+	for _, e := range d.Entries {
+		if e.Name() == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Validate reports whether input is a valid new value for e.
+func (d *Document) Validate(e *Entry, input string) error {
+	_, err := d.encode(e, input)
+	return err
+}
+
+// Set replaces the value of e with input, written in the syntax of the
+// format; strings are given unquoted. Entries are re-read afterwards, so
+// entries obtained before Set must be looked up again.
+func (d *Document) Set(e *Entry, input string) error {
+	literal, err := d.encode(e, input)
+	if err != nil {
+		return err
+	}
+
+	src := make([]byte, 0, len(d.src)-(e.end-e.start)+len(literal))
+	src = append(src, d.src[:e.start]...)
+	src = append(src, literal...)
+	src = append(src, d.src[e.end:]...)
+
+	updated, err := Parse(d.Format, src)
+	if err != nil {
+		return fmt.Errorf("the new value breaks the file: %w", err)
+	}
+	if len(updated.Entries) != len(d.Entries) {
+		return fmt.Errorf("the new value changes the structure of the file")
+	}
+	*d = *updated
+	return nil
+}
+
+// line returns the 1-based line of offset.
+func (d *Document) line(offset int) int {
+	return sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset })
+}
+
+// add appends e, preceded by section entries for any of its parents that
+// were not seen yet, such as the tables implied by TOML dotted keys.
+func (d *Document) add(e *Entry) {
+	for i := 1; i < len(e.Path); i++ {
+		parent := pathName(e.Path[:i])
+		if !d.sections[parent] {
+			d.sections[parent] = true
+			d.Entries = append(d.Entries, &Entry{
+				Path: append([]string(nil), e.Path[:i]...),
+				Type: TypeSection,
+				Line: e.Line,
+			})
+		}
+	}
+	if e.Type == TypeSection {
+		if d.sections[e.Name()] {
+			// A table header for a table a dotted key already implied
+			for _, s := range d.Entries {
+				if s.Type == TypeSection && s.Name() == e.Name() && s.Comment == "" {
+					s.Comment = e.Comment
+				}
+			}
+			return
+		}
+		d.sections[e.Name()] = true
+	}
+	d.Entries = append(d.Entries, e)
+}
+
+var (
+	rangeBoth    = regexp.MustCompile(`(?i)range:\s*([-+0-9.eE]+|-?infinity)\s*~\s*([-+0-9.eE]+|infinity)`)
+	rangeAbove   = regexp.MustCompile(`(?i)range:\s*>\s*([-+0-9.eE]+)`)
+	rangeBelow   = regexp.MustCompile(`(?i)range:\s*<\s*([-+0-9.eE]+)`)
+	allowedValue = regexp.MustCompile(`(?i)allowed values:\s*(.+)`)
+)
+
+// parseConstraints reads the range and allowed values Forge puts in the
+// comments of its configs.
+func (e *Entry) parseConstraints() {
+	if e.Comment == "" {
+		return
+	}
+	bound := func(s string) *float64 {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) {
+			return nil // an unbounded side
+		}
+		return &f
+	}
+	switch e.Type {
+	case TypeInt, TypeFloat:
+		if m := rangeBoth.FindStringSubmatch(e.Comment); m != nil {
+			e.Min, e.Max = bound(m[1]), bound(m[2])
+		} else if m := rangeAbove.FindStringSubmatch(e.Comment); m != nil {
+			e.Min = bound(m[1])
+		} else if m := rangeBelow.FindStringSubmatch(e.Comment); m != nil {
+			e.Max = bound(m[1])
+		}
+	case TypeString:
+		if m := allowedValue.FindStringSubmatch(e.Comment); m != nil {
+			for _, v := range strings.Split(m[1], ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					e.Allowed = append(e.Allowed, v)
+				}
+			}
+		}
+	}
+}
+
+// encode checks input against the type and constraints of e and returns
+// the literal to write.
+func (d *Document) encode(e *Entry, input string) (string, error) {
+	if !e.editable {
+		return "", fmt.Errorf("%s values cannot be edited here", e.Type)
+	}
+	// Every .properties value is a string to Java; the inferred type only
+	// guides display, so e.g. a numeric level-seed may become a word
+	if d.Format == FormatProperties {
+		return d.quote(e, input)
+	}
+	trimmed := strings.TrimSpace(input)
+
+	switch e.Type {
+	case TypeBool:
+		switch strings.ToLower(trimmed) {
+		case "true":
+			return "true", nil
+		case "false":
+			return "false", nil
+		}
+		return "", fmt.Errorf("expected true or false")
+
+	case TypeInt:
+		n, err := d.parseInt(trimmed)
+		if err != nil {
+			return "", err
+		}
+		if err := e.checkRange(float64(n)); err != nil {
+			return "", err
+		}
+		return trimmed, nil
+
+	case TypeFloat:
+		f, literal, err := d.parseFloat(trimmed)
+		if err != nil {
+			return "", err
+		}
+		if err := e.checkRange(f); err != nil {
+			return "", err
+		}
+		return literal, nil
+
+	case TypeString:
+		if len(e.Allowed) > 0 {
+			found := false
+			for _, v := range e.Allowed {
+				if strings.EqualFold(v, input) {
+					input, found = v, true
+					break
+				}
+			}
+			if !found {
+				return "", fmt.Errorf("expected one of %s", strings.Join(e.Allowed, ", "))
+			}
+		}
+		return d.quote(e, input)
+
+	case TypeDatetime:
+		if !tomlDatetime.MatchString(trimmed) {
+			return "", fmt.Errorf("expected a date or time like 2024-05-27T07:32:00Z")
+		}
+		return trimmed, nil
+
+	case TypeList, TypeTable, TypeNull:
+		t, err := d.parseLiteral(trimmed)
+		if err != nil {
+			return "", err
+		}
+		if e.Type != TypeNull && t != e.Type {
+			return "", fmt.Errorf("expected a %s, got a %s", e.Type, t)
+		}
+		return trimmed, nil
+	}
+	return "", fmt.Errorf("%s values cannot be edited here", e.Type)
+}
+
+func (e *Entry) checkRange(f float64) error {
+	if (e.Min != nil && f < *e.Min) || (e.Max != nil && f > *e.Max) {
+		return fmt.Errorf("out of range %s", e.Constraint())
+	}
+	return nil
+}
+
+var (
+	tomlInt      = regexp.MustCompile(`^([+-]?(0|[1-9](_?[0-9])*)|0x[0-9A-Fa-f](_?[0-9A-Fa-f])*|0o[0-7](_?[0-7])*|0b[01](_?[01])*)$`)
+	tomlFloat    = regexp.MustCompile(`^([+-]?(0|[1-9](_?[0-9])*)((\.[0-9](_?[0-9])*)([eE][+-]?[0-9](_?[0-9])*)?|[eE][+-]?[0-9](_?[0-9])*)|[+-]?(inf|nan))$`)
+	tomlDatetime = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}(:\d{2}(\.\d+)?)?)$`)
+	json5Number  = regexp.MustCompile(`^[+-]?(Infinity|NaN|0[xX][0-9A-Fa-f]+|([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?)$`)
+	jsonNumber   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+	plainNumber  = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+)
+
+// parseInt parses an integer written in the syntax of the format.
+func (d *Document) parseInt(s string) (int64, error) {
+	switch d.Format {
+	case FormatTOML:
+		if !tomlInt.MatchString(s) {
+			return 0, fmt.Errorf("expected an integer")
+		}
+		n, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a 64-bit integer")
+		}
+		return n, nil
+	case FormatJSON5:
+		if json5Number.MatchString(s) && strings.HasPrefix(strings.TrimLeft(s, "+-"), "0x") {
+			n, err := strconv.ParseInt(s, 0, 64)
+			if err == nil {
+				return n, nil
+			}
+		}
+	case FormatForgeCfg:
+		// Forge reads I: values as Java ints
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("expected a 32-bit integer")
+		}
+		return n, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || (d.Format == FormatJSON && !jsonNumber.MatchString(s)) {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	return n, nil
+}
+
+// parseFloat parses a number written in the syntax of the format and
+// returns the literal to write, which for TOML must look like a float.
+func (d *Document) parseFloat(s string) (float64, string, error) {
+	var ok bool
+	switch d.Format {
+	case FormatTOML:
+		// Hex, octal and binary integers have no float form
+		if tomlInt.MatchString(s) && !(len(s) > 1 && s[0] == '0' && strings.ContainsRune("xob", rune(s[1]))) {
+			s += ".0"
+		}
+		ok = tomlFloat.MatchString(s)
+	case FormatJSON:
+		ok = jsonNumber.MatchString(s)
+	case FormatJSON5:
+		switch strings.TrimLeft(s, "+-") {
+		case "Infinity":
+			return math.Inf(1), s, nil
+		case "NaN":
+			return math.NaN(), s, nil
+		}
+		ok = json5Number.MatchString(s) && !strings.ContainsAny(s, "xX")
+	default:
+		ok = plainNumber.MatchString(s)
+	}
+	if !ok {
+		return 0, "", fmt.Errorf("expected a number")
+	}
+	f, _ := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+	return f, s, nil
+}
+
+// quote writes s as a string literal of the format.
+func (d *Document) quote(e *Entry, s string) (string, error) {
+	switch d.Format {
+	case FormatTOML:
+		if e.quote == '\'' && !strings.ContainsAny(s, "'\r\n") {
+			return "'" + s + "'", nil
+		}
+		return quoteBasic(s, false), nil
+	case FormatJSON, FormatJSON5:
+		return quoteBasic(s, true), nil
+	case FormatProperties:
+		return escapeProperty(s), nil
+	case FormatForgeCfg:
+		if strings.ContainsAny(s, "\r\n") {
+			return "", fmt.Errorf("values in .cfg files cannot span lines")
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported format %q", d.Format)
+}
+
+// quoteBasic writes s as a double-quoted string with the escapes TOML and
+// JSON share.
+func quoteBasic(s string, json bool) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f && !json {
+				fmt.Fprintf(&sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// parseLiteral parses a list, inline table or scalar literal of the format
+// and returns its type.
+func (d *Document) parseLiteral(s string) (Type, error) {
+	switch d.Format {
+	case FormatTOML:
+		p := &tomlParser{d: &Document{Format: FormatTOML, src: []byte(s)}}
+		t, _, err := p.value()
+		if err != nil {
+			return "", err
+		}
+		if p.skipSpace(); p.pos < len(p.d.src) {
+			return "", fmt.Errorf("unexpected %q after the value", p.d.src[p.pos:])
+		}
+		return t, nil
+	case FormatJSON, FormatJSON5:
+		doc := &Document{Format: d.Format, src: []byte(s), sections: map[string]bool{}}
+		p := &jsonParser{d: doc}
+		e, err := p.value([]string{"value"})
+		if err != nil {
+			return "", err
+		}
+		if p.skipSpace(); p.pos < len(p.d.src) {
+			return "", fmt.Errorf("unexpected %q after the value", p.d.src[p.pos:])
+		}
+		if e.Type == TypeSection {
+			return "", fmt.Errorf("objects cannot be edited as a value")
+		}
+		for _, child := range doc.Entries[1:] {
+			if child.Type == TypeSection {
+				return "", fmt.Errorf("lists of objects cannot be edited as a value")
+			}
+		}
+		return e.Type, nil
+	}
+	return "", fmt.Errorf("%s files have no literals", d.Format)
+}
@@ -0,0 +1,166 @@
+package modconfig
+
+import "testing"
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		src    string
+		key    string
+		input  string
+		want   string
+	}{
+		// TOML
+		{"toml int", FormatTOML,
+			"# Stack size\n# Range: 1 ~ 64\nstackSize = 16 # inline\n", "stackSize", "32",
+			"# Stack size\n# Range: 1 ~ 64\nstackSize = 32 # inline\n"},
+		{"toml float from an int", FormatTOML, "[client]\nscale = 1.5\n", "client.scale", "2", "[client]\nscale = 2.0\n"},
+		{"toml float zero", FormatTOML, "scale = 1.5\n", "scale", "0", "scale = 0.0\n"},
+		{"toml float negative zero", FormatTOML, "scale = 1.5\n", "scale", "-0", "scale = -0.0\n"},
+		{"toml float exponent", FormatTOML, "scale = 1.5\n", "scale", "1e3", "scale = 1e3\n"},
+		{"toml string keeps literal quotes", FormatTOML, "name = 'a'\n", "name", `C:\x`, "name = 'C:\\x'\n"},
+		{"toml string escapes", FormatTOML, "name = \"a\"\n", "name", "say \"hi\"\n", "name = \"say \\\"hi\\\"\\n\"\n"},
+		{"toml bool", FormatTOML, "[a.b]\nenabled = false\n", "a.b.enabled", "TRUE", "[a.b]\nenabled = true\n"},
+		{"toml dotted key", FormatTOML, "a.b = 1\n", "a.b", "0x1F", "a.b = 0x1F\n"},
+		{"toml list", FormatTOML, "ids = [1, 2]\n", "ids", "[3, 4, 5]", "ids = [3, 4, 5]\n"},
+		{"toml array of tables", FormatTOML, "[[mods]]\nid = \"a\"\n[[mods]]\nid = \"b\"\n", "mods[1].id", "c",
+			"[[mods]]\nid = \"a\"\n[[mods]]\nid = \"c\"\n"},
+
+		// JSON and JSON5
+		{"json int", FormatJSON, `{"a": {"b": 1}}`, "a.b", "-25", `{"a": {"b": -25}}`},
+		{"json float", FormatJSON, `{"f": 1.0}`, "f", "-2.5e3", `{"f": -2.5e3}`},
+		{"json string", FormatJSON, `{"s": "x"}`, "s", "tab\there", `{"s": "tab\there"}`},
+		{"json null takes any literal", FormatJSON, `{"n": null}`, "n", `[1, "a"]`, `{"n": [1, "a"]}`},
+		{"json5 comments and trailing comma", FormatJSON5, "{\n  // size\n  size: 1,\n}\n", "size", "0x10",
+			"{\n  // size\n  size: 0x10,\n}\n"},
+		{"json5 infinity", FormatJSON5, "{f: 1.0}", "f", "-Infinity", "{f: -Infinity}"},
+		{"json5 single quotes", FormatJSON5, "{s: 'a'}", "s", "b", `{s: "b"}`},
+
+		// .properties
+		{"properties string", FormatProperties, "# comment\nmotd=A Minecraft Server\n", "motd", "Hello world",
+			"# comment\nmotd=Hello world\n"},
+		{"properties separator kept", FormatProperties, "key : value\n", "key", "other", "key : other\n"},
+		{"properties escapes", FormatProperties, "k=v\n", "k", " lead\\tab\n", "k=\\ lead\\\\tab\\n\n"},
+		{"properties numeric seed becomes a word", FormatProperties, "level-seed=12345\n", "level-seed", "minecraft",
+			"level-seed=minecraft\n"},
+		{"properties bool takes any string", FormatProperties, "pvp=true\n", "pvp", "yes", "pvp=yes\n"},
+
+		// Forge .cfg
+		{"cfg int", FormatForgeCfg, "general {\n    # [range: 1 ~ 64, default: 16]\n    I:stackSize=16\n}\n",
+			"general.stackSize", "64", "general {\n    # [range: 1 ~ 64, default: 16]\n    I:stackSize=64\n}\n"},
+		{"cfg quoted name", FormatForgeCfg, "client {\n    S:\"render mode\"=fast\n}\n", "client.render mode", "fancy",
+			"client {\n    S:\"render mode\"=fancy\n}\n"},
+		{"cfg double", FormatForgeCfg, "a {\n    D:chance=0.5\n}\n", "a.chance", "1", "a {\n    D:chance=1\n}\n"},
+		{"cfg bool", FormatForgeCfg, "a {\n    B:enabled=true\n}\n", "a.enabled", "false", "a {\n    B:enabled=false\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.format, []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			e := d.Find(tt.key)
+			if e == nil {
+				t.Fatalf("no entry %q", tt.key)
+			}
+			entries := len(d.Entries)
+			if err := d.Set(e, tt.input); err != nil {
+				t.Fatalf("Set(%q): %v", tt.input, err)
+			}
+			if got := string(d.Bytes()); got != tt.want {
+				t.Errorf("Set(%q) wrote\n%q\nwant\n%q", tt.input, got, tt.want)
+			}
+
+			// The edited file must read back the same way
+			again, err := Parse(tt.format, d.Bytes())
+			if err != nil {
+				t.Fatalf("Parse after Set: %v", err)
+			}
+			if len(again.Entries) != entries {
+				t.Errorf("Parse after Set found %d entries, want %d", len(again.Entries), entries)
+			}
+			if e := again.Find(tt.key); e == nil {
+				t.Errorf("entry %q is gone after Set", tt.key)
+			}
+		})
+	}
+}
+
+func TestSetStringValueReadsBack(t *testing.T) {
+	for _, format := range []Format{FormatTOML, FormatJSON, FormatJSON5, FormatProperties} {
+		src := map[Format]string{
+			FormatTOML:       "s = \"x\"\n",
+			FormatJSON:       `{"s": "x"}`,
+			FormatJSON5:      `{s: 'x'}`,
+			FormatProperties: "s=x\n",
+		}[format]
+		for _, value := range []string{"", " padded ", `back\slash`, "quote\"s'", "line\nbreak", "ünïcödé ☃"} {
+			d, err := Parse(format, []byte(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Set(d.Find("s"), value); err != nil {
+				t.Errorf("%s: Set(%q): %v", format, value, err)
+				continue
+			}
+			d, err = Parse(format, d.Bytes())
+			if err != nil {
+				t.Fatalf("%s: Parse after Set(%q): %v", format, value, err)
+			}
+			if got := d.Find("s").Value; got != value {
+				t.Errorf("%s: Set(%q) reads back as %q", format, value, got)
+			}
+		}
+	}
+}
+
+func TestSetRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		src    string
+		key    string
+		input  string
+	}{
+		{"toml int out of range", FormatTOML, "# Range: 1 ~ 64\nn = 16\n", "n", "65"},
+		{"toml int not a number", FormatTOML, "n = 16\n", "n", "lots"},
+		{"toml float from hex", FormatTOML, "f = 1.5\n", "f", "0x10"},
+		{"toml float leading zero", FormatTOML, "f = 1.5\n", "f", "01"},
+		{"toml bool", FormatTOML, "b = true\n", "b", "yes"},
+		{"toml allowed values", FormatTOML, "# Allowed Values: FAST, FANCY\nmode = \"FAST\"\n", "mode", "slow"},
+		{"toml list type", FormatTOML, "ids = [1]\n", "ids", "{a = 1}"},
+		{"toml datetime", FormatTOML, "d = 1979-05-27\n", "d", "yesterday"},
+		{"json hex", FormatJSON, `{"n": 1}`, "n", "0x10"},
+		{"json5 float hex", FormatJSON5, "{f: 1.5}", "f", "0x10"},
+		{"cfg int is 32-bit", FormatForgeCfg, "a {\n    I:n=1\n}\n", "a.n", "4294967296"},
+		{"cfg multi-line string", FormatForgeCfg, "a {\n    S:s=x\n}\n", "a.s", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.format, []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if err := d.Set(d.Find(tt.key), tt.input); err == nil {
+				t.Errorf("Set(%q) succeeded, wrote %q", tt.input, d.Bytes())
+			}
+			if string(d.Bytes()) != tt.src {
+				t.Errorf("a rejected Set changed the file to %q", d.Bytes())
+			}
+		})
+	}
+}
+
+func TestPropertiesTypes(t *testing.T) {
+	d, err := Parse(FormatProperties, []byte("pvp=true\nmax-players=20\nratio=0.5\nlevel-seed=-123\nmotd=hi\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Type{"pvp": TypeBool, "max-players": TypeInt, "ratio": TypeFloat, "level-seed": TypeInt, "motd": TypeString}
+	for key, typ := range want {
+		if e := d.Find(key); e == nil || e.Type != typ {
+			t.Errorf("%s: type = %v, want %s", key, e, typ)
+		}
+	}
+}
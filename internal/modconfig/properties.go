@@ -0,0 +1,197 @@
+package modconfig
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseProperties reads a Java .properties file, such as
+// server.properties or the configs of Fabric mods that use them.
+func parseProperties(d *Document) error {
+	var comment []string
+	pos := 0
+	for pos < len(d.src) {
+		lineStart := pos
+		end := lineEnd(d.src, pos)
+		line := strings.TrimLeft(string(d.src[pos:end]), " \t\f")
+		pos = nextLine(d.src, end)
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			comment = nil
+			continue
+		case line[0] == '#' || line[0] == '!':
+			comment = append(comment, strings.TrimSpace(line[1:]))
+			continue
+		}
+
+		// A value continues on the next line after an odd number of
+		// trailing backslashes
+		valueEnd := end
+		for trailingBackslashes(d.src[lineStart:valueEnd])%2 == 1 && pos < len(d.src) {
+			valueEnd = lineEnd(d.src, pos)
+			pos = nextLine(d.src, valueEnd)
+		}
+
+		keyStart := lineStart + (end - lineStart - len(line))
+		i := keyStart
+		for i < valueEnd {
+			c := d.src[i]
+			if c == '\\' {
+				i += 2
+				continue
+			}
+			if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+				break
+			}
+			i++
+		}
+		i = min(i, valueEnd)
+		key := unescapeProperty(string(d.src[keyStart:i]))
+
+		// The separator is whitespace, = or :, with whitespace around it
+		for i < valueEnd && (d.src[i] == ' ' || d.src[i] == '\t' || d.src[i] == '\f') {
+			i++
+		}
+		if i < valueEnd && (d.src[i] == '=' || d.src[i] == ':') {
+			i++
+		}
+		for i < valueEnd && (d.src[i] == ' ' || d.src[i] == '\t' || d.src[i] == '\f') {
+			i++
+		}
+
+		value := unescapeProperty(string(d.src[i:valueEnd]))
+		d.add(&Entry{
+			Path:     []string{key},
+			Type:     inferType(value),
+			Value:    value,
+			Comment:  strings.Join(comment, "\n"),
+			Line:     d.line(lineStart),
+			editable: true,
+			start:    i,
+			end:      valueEnd,
+		})
+		comment = nil
+	}
+	return nil
+}
+
+// lineEnd returns the end of the line starting at pos, before its line
+// break.
+func lineEnd(src []byte, pos int) int {
+	for pos < len(src) && src[pos] != '\n' {
+		pos++
+	}
+	if pos > 0 && src[pos-1] == '\r' {
+		return pos - 1
+	}
+	return pos
+}
+
+// nextLine returns the start of the line after the one ending at end.
+func nextLine(src []byte, end int) int {
+	if end < len(src) && src[end] == '\r' {
+		end++
+	}
+	if end < len(src) && src[end] == '\n' {
+		end++
+	}
+	return end
+}
+
+func trailingBackslashes(b []byte) int {
+	n := 0
+	for i := len(b) - 1; i >= 0 && b[i] == '\\'; i-- {
+		n++
+	}
+	return n
+}
+
+// inferType guesses the type of a .properties value, which are all
+// strings to Java.
+func inferType(value string) Type {
+	switch {
+	case value == "true" || value == "false":
+		return TypeBool
+	case plainNumber.MatchString(value) && !strings.ContainsAny(value, ".eE"):
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return TypeInt
+		}
+	case plainNumber.MatchString(value) && strings.Contains(value, "."):
+		return TypeFloat
+	}
+	return TypeString
+}
+
+// unescapeProperty decodes the escapes and line continuations of a
+// .properties key or value.
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if n, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					sb.WriteRune(rune(n))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteByte('u')
+		case '\r', '\n':
+			// A line continuation: skip the break and the next line's
+			// leading whitespace
+			for i+1 < len(s) && strings.IndexByte("\r\n \t\f", s[i+1]) >= 0 {
+				i++
+			}
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// escapeProperty writes s as a .properties value on one line.
+func escapeProperty(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case ' ':
+			if i == 0 {
+				sb.WriteString(`\ `) // leading spaces would be read as part of the separator
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,526 @@
+package modconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser reads TOML 1.0, with the TOML 1.1 additions mods already use
+// (newlines in inline tables, \e and \x escapes).
+type tomlParser struct {
+	d       *Document
+	pos     int
+	table   []string       // path of the current table
+	arrays  map[string]int // number of elements of each array of tables
+	comment []string       // comment lines waiting for the next key
+	quote   byte           // quote of the last string value
+}
+
+func parseTOML(d *Document) error {
+	p := &tomlParser{d: d, arrays: map[string]int{}}
+	for p.pos < len(d.src) {
+		if err := p.line(); err != nil {
+			return fmt.Errorf("line %d: %w", d.line(p.pos), err)
+		}
+	}
+	return nil
+}
+
+func (p *tomlParser) peek() byte {
+	if p.pos < len(p.d.src) {
+		return p.d.src[p.pos]
+	}
+	return 0
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.d.src[p.pos:min(p.pos+len(s), len(p.d.src))]), s)
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.d.src) && (p.d.src[p.pos] == ' ' || p.d.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments inside arrays and
+// inline tables.
+func (p *tomlParser) skipBlank() {
+	for p.pos < len(p.d.src) {
+		switch p.d.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment reads a comment up to the end of the line and returns its
+// text.
+func (p *tomlParser) readComment() string {
+	start := p.pos + 1
+	for p.pos < len(p.d.src) && p.d.src[p.pos] != '\n' {
+		p.pos++
+	}
+	return strings.TrimSpace(strings.TrimSuffix(string(p.d.src[start:p.pos]), "\r"))
+}
+
+// newline consumes a line ending and reports whether there was one.
+func (p *tomlParser) newline() bool {
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		return true
+	}
+	if p.peek() == '\n' {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// endLine consumes the rest of a line after a key or header and returns
+// its comment, if any.
+func (p *tomlParser) endLine() (string, error) {
+	p.skipSpace()
+	comment := ""
+	if p.peek() == '#' {
+		comment = p.readComment()
+	}
+	if p.pos < len(p.d.src) && !p.newline() {
+		return "", fmt.Errorf("unexpected %q after the value", p.peek())
+	}
+	return comment, nil
+}
+
+func (p *tomlParser) line() error {
+	p.skipSpace()
+	if p.pos >= len(p.d.src) {
+		return nil
+	}
+	switch c := p.peek(); {
+	case c == '\r' || c == '\n':
+		if !p.newline() {
+			return fmt.Errorf("stray carriage return")
+		}
+		p.comment = nil // a blank line ends the comment of the next key
+		return nil
+	case c == '#':
+		p.comment = append(p.comment, p.readComment())
+		p.newline()
+		return nil
+	case c == '[':
+		return p.header()
+	}
+	return p.keyValue()
+}
+
+// takeComment returns the pending comment lines and inline, joined.
+func (p *tomlParser) takeComment(inline string) string {
+	lines := p.comment
+	if inline != "" {
+		lines = append(lines, inline)
+	}
+	p.comment = nil
+	return strings.Join(lines, "\n")
+}
+
+func (p *tomlParser) header() error {
+	line := p.d.line(p.pos)
+	array := p.hasPrefix("[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !p.hasPrefix(closing) {
+		return fmt.Errorf("expected %s after the table name", closing)
+	}
+	p.pos += len(closing)
+	inline, err := p.endLine()
+	if err != nil {
+		return err
+	}
+
+	p.table = p.resolve(keys, array)
+	p.d.add(&Entry{
+		Path:    append([]string(nil), p.table...),
+		Type:    TypeSection,
+		Line:    line,
+		Comment: p.takeComment(inline),
+	})
+	return nil
+}
+
+// resolve turns the keys of a table header into a path, pointing keys that
+// name an array of tables at its last element. For an [[array]] header it
+// adds the new element.
+func (p *tomlParser) resolve(keys []string, array bool) []string {
+	var path []string
+	for i, key := range keys {
+		path = append(path, key)
+		if n, ok := p.arrays[pathName(path)]; ok && !(array && i == len(keys)-1) {
+			path = append(path, fmt.Sprintf("[%d]", n-1))
+		}
+	}
+	if array {
+		n := p.arrays[pathName(path)]
+		p.arrays[pathName(path)] = n + 1
+		path = append(path, fmt.Sprintf("[%d]", n))
+	}
+	return path
+}
+
+func (p *tomlParser) keyValue() error {
+	line := p.d.line(p.pos)
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return fmt.Errorf("expected = after %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	start := p.pos
+	p.quote = 0
+	t, value, err := p.value()
+	if err != nil {
+		return err
+	}
+	end, quote := p.pos, p.quote
+	inline, err := p.endLine()
+	if err != nil {
+		return err
+	}
+
+	path := append(append([]string(nil), p.table...), keys...)
+	p.d.add(&Entry{
+		Path:     path,
+		Type:     t,
+		Value:    value,
+		Comment:  p.takeComment(inline),
+		Line:     line,
+		editable: true,
+		start:    start,
+		end:      end,
+		quote:    quote,
+	})
+	return nil
+}
+
+func isBareKey(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// keys reads a dotted key.
+func (p *tomlParser) keys() ([]string, error) {
+	var keys []string
+	for {
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case c == '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case isBareKey(c):
+			start := p.pos
+			for p.pos < len(p.d.src) && isBareKey(p.d.src[p.pos]) {
+				p.pos++
+			}
+			key = string(p.d.src[start:p.pos])
+		default:
+			return nil, fmt.Errorf("expected a key")
+		}
+		keys = append(keys, key)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+// value reads a value and returns its type and its decoded text.
+func (p *tomlParser) value() (Type, string, error) {
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		s, err := p.multilineString('"')
+		return TypeString, s, err
+	case p.hasPrefix(`'''`):
+		s, err := p.multilineString('\'')
+		return TypeString, s, err
+	case c == '"':
+		s, err := p.basicString()
+		return TypeString, s, err
+	case c == '\'':
+		s, err := p.literalString()
+		return TypeString, s, err
+	case c == '[':
+		s, err := p.array()
+		return TypeList, s, err
+	case c == '{':
+		s, err := p.inlineTable()
+		return TypeTable, s, err
+	}
+	return p.scalar()
+}
+
+var tomlDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// scalar reads a boolean, number or date.
+func (p *tomlParser) scalar() (Type, string, error) {
+	start := p.pos
+	token := func() {
+		for p.pos < len(p.d.src) && !strings.ContainsRune(" \t\r\n#,]}", rune(p.d.src[p.pos])) {
+			p.pos++
+		}
+	}
+	token()
+	// A date and a time may be separated by a space
+	if tomlDate.Match(p.d.src[start:p.pos]) && p.hasPrefix(" ") &&
+		len(p.d.src) > p.pos+3 && p.d.src[p.pos+3] == ':' {
+		p.pos++
+		token()
+	}
+	s := string(p.d.src[start:p.pos])
+
+	switch {
+	case s == "":
+		return "", "", fmt.Errorf("expected a value")
+	case s == "true" || s == "false":
+		return TypeBool, s, nil
+	case tomlInt.MatchString(s):
+		return TypeInt, s, nil
+	case tomlFloat.MatchString(s):
+		return TypeFloat, s, nil
+	case tomlDatetime.MatchString(s):
+		return TypeDatetime, s, nil
+	}
+	return "", "", fmt.Errorf("invalid value %q", s)
+}
+
+// array reads an array and returns it on one line, without the comments
+// and line breaks it may have.
+func (p *tomlParser) array() (string, error) {
+	p.pos++ // [
+	var items []string
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return "[" + strings.Join(items, ", ") + "]", nil
+		}
+		start := p.pos
+		if _, _, err := p.value(); err != nil {
+			return "", err
+		}
+		items = append(items, p.literal(start))
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return "[" + strings.Join(items, ", ") + "]", nil
+		default:
+			return "", fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+// inlineTable reads an inline table and returns it on one line.
+func (p *tomlParser) inlineTable() (string, error) {
+	p.pos++ // {
+	var items []string
+	for {
+		p.skipBlank()
+		if p.peek() == '}' {
+			p.pos++
+			return "{ " + strings.Join(items, ", ") + " }", nil
+		}
+		start := p.pos
+		if _, err := p.keys(); err != nil {
+			return "", err
+		}
+		key := strings.TrimSpace(string(p.d.src[start:p.pos]))
+		p.skipSpace()
+		if p.peek() != '=' {
+			return "", fmt.Errorf("expected = in inline table")
+		}
+		p.pos++
+		p.skipSpace()
+		start = p.pos
+		if _, _, err := p.value(); err != nil {
+			return "", err
+		}
+		items = append(items, key+" = "+p.literal(start))
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return "{ " + strings.Join(items, ", ") + " }", nil
+		default:
+			return "", fmt.Errorf("expected , or } in inline table")
+		}
+	}
+}
+
+// literal returns the source text of the value read since start, with
+// nested arrays and tables on one line.
+func (p *tomlParser) literal(start int) string {
+	switch p.d.src[start] {
+	case '[', '{':
+		saved := p.pos
+		p.pos = start
+		var s string
+		if p.d.src[start] == '[' {
+			s, _ = p.array()
+		} else {
+			s, _ = p.inlineTable()
+		}
+		p.pos = saved
+		return s
+	}
+	return string(p.d.src[start:p.pos])
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	p.quote = '\''
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.d.src) {
+		switch p.d.src[p.pos] {
+		case '\'':
+			p.pos++
+			return string(p.d.src[start : p.pos-1]), nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string")
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	p.quote = '"'
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.d.src) {
+		c := p.d.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string")
+		case '\\':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// multilineString reads a multi-line basic or literal string.
+func (p *tomlParser) multilineString(quote byte) (string, error) {
+	p.quote = quote
+	delim := strings.Repeat(string(quote), 3)
+	p.pos += 3
+	p.newline() // a newline right after the opening quotes is trimmed
+
+	var sb strings.Builder
+	for p.pos < len(p.d.src) {
+		if p.hasPrefix(delim) {
+			// Up to two quotes right before the closing ones are content
+			n := 3
+			for n < 5 && p.pos+n < len(p.d.src) && p.d.src[p.pos+n] == quote {
+				n++
+			}
+			sb.WriteString(strings.Repeat(string(quote), n-3))
+			p.pos += n
+			return sb.String(), nil
+		}
+		c := p.d.src[p.pos]
+		if c == '\\' && quote == '"' {
+			// A backslash at the end of a line trims the line break and
+			// the whitespace after it
+			rest := p.pos + 1
+			for rest < len(p.d.src) && (p.d.src[rest] == ' ' || p.d.src[rest] == '\t') {
+				rest++
+			}
+			if rest < len(p.d.src) && (p.d.src[rest] == '\n' || p.d.src[rest] == '\r') {
+				p.pos = rest
+				for p.pos < len(p.d.src) && strings.ContainsRune(" \t\r\n", rune(p.d.src[p.pos])) {
+					p.pos++
+				}
+				continue
+			}
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// escape decodes the escape sequence at the current position.
+func (p *tomlParser) escape(sb *strings.Builder) error {
+	if p.pos+1 >= len(p.d.src) {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.d.src[p.pos+1]
+	p.pos += 2
+	simple := map[byte]string{'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", 'e': "\x1b", '"': `"`, '\\': `\`}
+	if s, ok := simple[c]; ok {
+		sb.WriteString(s)
+		return nil
+	}
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if digits == 0 || p.pos+digits > len(p.d.src) {
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	n, err := strconv.ParseUint(string(p.d.src[p.pos:p.pos+digits]), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return fmt.Errorf("invalid escape \\%c%s", c, p.d.src[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	sb.WriteRune(rune(n))
+	return nil
+}
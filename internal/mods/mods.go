@@ -0,0 +1,280 @@
+// Package mods reads the metadata that Fabric, Quilt, Forge and NeoForge
+// mods declare inside their jars.
+package mods
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/modconfig"
+)
+
+// Loader is the mod loader a jar was written for.
+type Loader string
+
+const (
+	LoaderFabric   Loader = "fabric"
+	LoaderQuilt    Loader = "quilt"
+	LoaderForge    Loader = "forge"
+	LoaderNeoForge Loader = "neoforge"
+)
+
+// Mod is one mod declared by a jar. Forge jars may declare several.
+type Mod struct {
+	ID      string `json:"id" yaml:"id"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// Jar describes a jar of an instance's mods folder. Jars without metadata,
+// such as libraries, have no Loader and no Mods.
+type Jar struct {
+	File   string `json:"file" yaml:"file"`
+	Path   string `json:"path" yaml:"path"`
+	Loader Loader `json:"loader,omitempty" yaml:"loader,omitempty"`
+	Mods   []Mod  `json:"mods" yaml:"mods"`
+}
+
+// IDs returns the ids of the jar's mods.
+func (j *Jar) IDs() []string {
+	ids := make([]string, len(j.Mods))
+	for i, m := range j.Mods {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// metadataFiles are the files ReadJar looks for, in order.
+var metadataFiles = []struct {
+	name   string
+	loader Loader
+	parse  func(data []byte, manifest func() map[string]string) ([]Mod, error)
+}{
+	{"fabric.mod.json", LoaderFabric, parseFabric},
+	{"quilt.mod.json", LoaderQuilt, parseQuilt},
+	{"META-INF/neoforge.mods.toml", LoaderNeoForge, parseModsToml},
+	{"META-INF/mods.toml", LoaderForge, parseModsToml},
+	{"mcmod.info", LoaderForge, parseMcmodInfo},
+}
+
+// ReadJar reads the metadata of the jar at path.
+func ReadJar(path string) (*Jar, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	manifest := func() map[string]string {
+		data, err := readZipFile(files["META-INF/MANIFEST.MF"])
+		if err != nil {
+			return nil
+		}
+		return parseManifest(data)
+	}
+
+	jar := &Jar{File: filepath.Base(path), Path: path, Mods: []Mod{}}
+	for _, meta := range metadataFiles {
+		f, ok := files[meta.name]
+		if !ok {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", meta.name, jar.File, err)
+		}
+		mods, err := meta.parse(data, manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s of %s: %w", meta.name, jar.File, err)
+		}
+		jar.Loader, jar.Mods = meta.loader, mods
+		break
+	}
+	return jar, nil
+}
+
+// List reads the jars in modsDir, sorted by file name. Jars that cannot be
+// read are listed without metadata. A missing directory has no jars.
+func List(modsDir string) ([]Jar, error) {
+	entries, err := os.ReadDir(modsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mods directory: %w", err)
+	}
+
+	var jars []Jar
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jar") {
+			continue
+		}
+		path := filepath.Join(modsDir, e.Name())
+		jar, err := ReadJar(path)
+		if err != nil {
+			jar = &Jar{File: e.Name(), Path: path, Mods: []Mod{}}
+		}
+		jars = append(jars, *jar)
+	}
+	sort.Slice(jars, func(i, j int) bool { return jars[i].File < jars[j].File })
+	return jars, nil
+}
+
+// Packages returns the Java packages, such as "com.example.mymod", that
+// have classes in the jar at path, sorted. Classes of multi-release jars'
+// META-INF/versions count for their package as well.
+func Packages(path string) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer zr.Close()
+
+	seen := make(map[string]bool)
+	for _, f := range zr.File {
+		name := f.Name
+		if !strings.HasSuffix(name, ".class") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(name, "META-INF/versions/"); ok {
+			_, name, _ = strings.Cut(rest, "/")
+		}
+		// Classes of the default package have no package to match
+		if i := strings.LastIndex(name, "/"); i > 0 && !strings.HasPrefix(name, "META-INF/") {
+			seen[strings.ReplaceAll(name[:i], "/", ".")] = true
+		}
+	}
+	packages := make([]string, 0, len(seen))
+	for p := range seen {
+		packages = append(packages, p)
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, os.ErrNotExist
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// Metadata files are small; don't let a bogus one eat memory
+	return io.ReadAll(io.LimitReader(rc, 1<<20))
+}
+
+func parseFabric(data []byte, _ func() map[string]string) ([]Mod, error) {
+	var meta struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return []Mod{{ID: meta.ID, Name: meta.Name, Version: meta.Version}}, nil
+}
+
+func parseQuilt(data []byte, _ func() map[string]string) ([]Mod, error) {
+	var meta struct {
+		Loader struct {
+			ID       string `json:"id"`
+			Version  string `json:"version"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"quilt_loader"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	l := meta.Loader
+	return []Mod{{ID: l.ID, Name: l.Metadata.Name, Version: l.Version}}, nil
+}
+
+// parseModsToml reads the [[mods]] of a Forge or NeoForge mods.toml. A
+// version of ${file.jarVersion} is taken from the jar's manifest.
+func parseModsToml(data []byte, manifest func() map[string]string) ([]Mod, error) {
+	doc, err := modconfig.Parse(modconfig.FormatTOML, data)
+	if err != nil {
+		return nil, err
+	}
+	value := func(i int, key string) string {
+		if e := doc.Find(fmt.Sprintf("mods[%d].%s", i, key)); e != nil {
+			return e.Value
+		}
+		return ""
+	}
+
+	var mods []Mod
+	for i := 0; doc.Find(fmt.Sprintf("mods[%d]", i)) != nil; i++ {
+		m := Mod{ID: value(i, "modId"), Name: value(i, "displayName"), Version: value(i, "version")}
+		if strings.Contains(m.Version, "${file.jarVersion}") {
+			if v := manifest()["Implementation-Version"]; v != "" {
+				m.Version = strings.ReplaceAll(m.Version, "${file.jarVersion}", v)
+			}
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+// parseMcmodInfo reads the mcmod.info of mods for Forge before 1.13, which
+// is either a list of mods or an object holding one under modList.
+func parseMcmodInfo(data []byte, _ func() map[string]string) ([]Mod, error) {
+	type info struct {
+		ModID   string `json:"modid"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var list []info
+	if err := json.Unmarshal(data, &list); err != nil {
+		var v2 struct {
+			ModList []info `json:"modList"`
+		}
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, err
+		}
+		list = v2.ModList
+	}
+	mods := make([]Mod, len(list))
+	for i, m := range list {
+		mods[i] = Mod{ID: m.ModID, Name: m.Name, Version: m.Version}
+	}
+	return mods, nil
+}
+
+// parseManifest reads the main section of a jar's MANIFEST.MF.
+func parseManifest(data []byte) map[string]string {
+	attrs := make(map[string]string)
+	var last string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "":
+			return attrs // the per-entry sections follow
+		case strings.HasPrefix(line, " ") && last != "":
+			attrs[last] += line[1:]
+		default:
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				last = name
+				attrs[name] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return attrs
+}
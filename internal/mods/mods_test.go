@@ -0,0 +1,149 @@
+package mods
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeJar writes a jar holding files to dir and returns its path.
+func writeJar(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const modsToml = `modLoader = "javafml"
+loaderVersion = "[47,)"
+
+[[mods]]
+modId = "create"
+displayName = "Create"
+version = "${file.jarVersion}"
+
+[[mods]]
+modId = "flywheel"
+version = "1.0-${file.jarVersion}-forge"
+
+[[dependencies.create]]
+modId = "minecraft"
+`
+
+func TestReadJar(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		loader Loader
+		mods   []Mod
+	}{
+		{"fabric", map[string]string{
+			"fabric.mod.json": `{"schemaVersion": 1, "id": "sodium", "name": "Sodium", "version": "0.5.3"}`,
+		}, LoaderFabric, []Mod{{"sodium", "Sodium", "0.5.3"}}},
+		{"quilt", map[string]string{
+			"quilt.mod.json": `{"quilt_loader": {"id": "qsl", "version": "6.1.0", "metadata": {"name": "QSL"}}}`,
+		}, LoaderQuilt, []Mod{{"qsl", "QSL", "6.1.0"}}},
+		{"forge jar version from the manifest", map[string]string{
+			"META-INF/mods.toml":   modsToml,
+			"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\nImplementation-Title: create\r\nImplementation-Version: 0.5.1.f\r\n\r\nName: com/simibubi/\r\nImplementation-Version: wrong\r\n",
+		}, LoaderForge, []Mod{{"create", "Create", "0.5.1.f"}, {"flywheel", "", "1.0-0.5.1.f-forge"}}},
+		{"forge manifest value continued on the next line", map[string]string{
+			"META-INF/mods.toml":   modsToml,
+			"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nImplementation-Version: 1.20.1-0.5.1\n .f\n",
+		}, LoaderForge, []Mod{{"create", "Create", "1.20.1-0.5.1.f"}, {"flywheel", "", "1.0-1.20.1-0.5.1.f-forge"}}},
+		{"forge placeholder kept without a manifest version", map[string]string{
+			"META-INF/mods.toml":   modsToml,
+			"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\n",
+		}, LoaderForge, []Mod{{"create", "Create", "${file.jarVersion}"}, {"flywheel", "", "1.0-${file.jarVersion}-forge"}}},
+		{"forge placeholder kept without a manifest", map[string]string{
+			"META-INF/mods.toml": modsToml,
+		}, LoaderForge, []Mod{{"create", "Create", "${file.jarVersion}"}, {"flywheel", "", "1.0-${file.jarVersion}-forge"}}},
+		{"neoforge wins over mods.toml", map[string]string{
+			"META-INF/neoforge.mods.toml": "[[mods]]\nmodId = \"neo\"\nversion = \"2.0\"\n",
+			"META-INF/mods.toml":          "[[mods]]\nmodId = \"old\"\nversion = \"1.0\"\n",
+		}, LoaderNeoForge, []Mod{{"neo", "", "2.0"}}},
+		{"mcmod.info list", map[string]string{
+			"mcmod.info": `[{"modid": "jei", "name": "Just Enough Items", "version": "4.16"}]`,
+		}, LoaderForge, []Mod{{"jei", "Just Enough Items", "4.16"}}},
+		{"mcmod.info modList", map[string]string{
+			"mcmod.info": `{"modListVersion": 2, "modList": [{"modid": "a"}, {"modid": "b", "version": "1"}]}`,
+		}, LoaderForge, []Mod{{"a", "", ""}, {"b", "", "1"}}},
+		{"library", map[string]string{
+			"com/example/Lib.class": "",
+		}, "", []Mod{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jar, err := ReadJar(writeJar(t, t.TempDir(), "mod.jar", tt.files))
+			if err != nil {
+				t.Fatalf("ReadJar: %v", err)
+			}
+			if jar.Loader != tt.loader {
+				t.Errorf("Loader = %q, want %q", jar.Loader, tt.loader)
+			}
+			if !reflect.DeepEqual(jar.Mods, tt.mods) {
+				t.Errorf("Mods = %+v, want %+v", jar.Mods, tt.mods)
+			}
+		})
+	}
+}
+
+func TestReadJarMalformed(t *testing.T) {
+	dir := t.TempDir()
+	for name, files := range map[string]map[string]string{
+		"fabric.jar": {"fabric.mod.json": `{"id": `},
+		"forge.jar":  {"META-INF/mods.toml": "[[mods]\nmodId = 1"},
+	} {
+		if _, err := ReadJar(writeJar(t, dir, name, files)); err == nil {
+			t.Errorf("ReadJar(%s) succeeded, want an error", name)
+		}
+	}
+
+	// List still shows jars it cannot read, without metadata
+	os.WriteFile(filepath.Join(dir, "broken.jar"), []byte("not a zip"), 0644)
+	jars, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jars) != 3 {
+		t.Errorf("List returned %d jars, want 3", len(jars))
+	}
+}
+
+func TestPackages(t *testing.T) {
+	path := writeJar(t, t.TempDir(), "mod.jar", map[string]string{
+		"com/example/mymod/MyMod.class":                   "",
+		"com/example/mymod/client/Renderer.class":         "",
+		"META-INF/versions/17/com/example/java17/X.class": "",
+		"META-INF/Other.class":                            "",
+		"Default.class":                                   "",
+		"assets/mymod/lang/en_us.json":                    "{}",
+	})
+	got, err := Packages(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"com.example.java17", "com.example.mymod", "com.example.mymod.client"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Packages = %q, want %q", got, want)
+	}
+}
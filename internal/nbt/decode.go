@@ -0,0 +1,246 @@
+package nbt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MaxDepth bounds the nesting of lists and compounds, so that a corrupt or
+// hostile file cannot exhaust the stack. Minecraft itself stops at 512.
+const MaxDepth = 512
+
+// maxPrealloc caps how many elements are allocated up front from lengths
+// read from the input, summed over the whole decode so that nested lists
+// cannot multiply it. Longer arrays grow as their data actually arrives,
+// so a bogus length fails on EOF instead of allocating gigabytes.
+const maxPrealloc = 1 << 16
+
+// ErrTruncated is returned when the input ends in the middle of a tag.
+var ErrTruncated = errors.New("truncated NBT data")
+
+// Decode reads one uncompressed named tag from r and returns its name and
+// value. Java edition files hold a single compound here.
+func Decode(r io.Reader) (string, Tag, error) {
+	d := decoder{r: bufio.NewReader(r), prealloc: maxPrealloc}
+	typ, err := d.byte()
+	if err != nil {
+		return "", nil, err
+	}
+	if Type(typ) == TagEnd || Type(typ) > TagLongArray {
+		return "", nil, fmt.Errorf("invalid root tag type %d", typ)
+	}
+	name, err := d.string()
+	if err != nil {
+		return "", nil, err
+	}
+	v, err := d.payload(Type(typ), 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, v, nil
+}
+
+type decoder struct {
+	r        *bufio.Reader
+	buf      [8]byte
+	prealloc int // elements that may still be allocated up front
+}
+
+// reserve returns the capacity to allocate for n elements, taken from the
+// decode's preallocation budget.
+func (d *decoder) reserve(n int) int {
+	n = min(n, d.prealloc)
+	d.prealloc -= n
+	return n
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		return nil, truncated(err)
+	}
+	return d.buf[:n], nil
+}
+
+// truncated turns the EOF errors of a short read into ErrTruncated.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return err
+}
+
+func (d *decoder) byte() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) uint16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *decoder) length() (int, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int32(n) < 0 {
+		return 0, fmt.Errorf("negative NBT length %d", int32(n))
+	}
+	return int(n), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uint16()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", truncated(err)
+	}
+	return decodeMUTF8(b)
+}
+
+func (d *decoder) payload(typ Type, depth int) (Tag, error) {
+	switch typ {
+	case TagByte:
+		b, err := d.byte()
+		return Byte(b), err
+	case TagShort:
+		v, err := d.uint16()
+		return Short(v), err
+	case TagInt:
+		v, err := d.uint32()
+		return Int(v), err
+	case TagLong:
+		v, err := d.uint64()
+		return Long(v), err
+	case TagFloat:
+		v, err := d.uint32()
+		return Float(math.Float32frombits(v)), err
+	case TagDouble:
+		v, err := d.uint64()
+		return Double(math.Float64frombits(v)), err
+	case TagString:
+		s, err := d.string()
+		return String(s), err
+	case TagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(io.LimitReader(d.r, int64(n)))
+		if err == nil && len(b) != n {
+			err = ErrTruncated
+		}
+		return ByteArray(b), err
+	case TagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make(IntArray, 0, d.reserve(n))
+		for i := 0; i < n; i++ {
+			v, err := d.uint32()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, int32(v))
+		}
+		return a, nil
+	case TagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make(LongArray, 0, d.reserve(n))
+		for i := 0; i < n; i++ {
+			v, err := d.uint64()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, int64(v))
+		}
+		return a, nil
+	case TagList:
+		if depth >= MaxDepth {
+			return nil, fmt.Errorf("NBT nested deeper than %d", MaxDepth)
+		}
+		elem, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if Type(elem) > TagLongArray || (Type(elem) == TagEnd && n > 0) {
+			return nil, fmt.Errorf("invalid list element type %d", elem)
+		}
+		l := &List{Elem: Type(elem), Items: make([]Tag, 0, d.reserve(n))}
+		for i := 0; i < n; i++ {
+			v, err := d.payload(l.Elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			l.Items = append(l.Items, v)
+		}
+		return l, nil
+	case TagCompound:
+		if depth >= MaxDepth {
+			return nil, fmt.Errorf("NBT nested deeper than %d", MaxDepth)
+		}
+		c := &Compound{}
+		for {
+			t, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			if Type(t) == TagEnd {
+				return c, nil
+			}
+			if Type(t) > TagLongArray {
+				return nil, fmt.Errorf("unknown NBT tag type %d", t)
+			}
+			name, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.payload(Type(t), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			// Fields are appended as read; a duplicate name, which the game
+			// never writes, is kept so that the file is written back as is
+			c.Fields = append(c.Fields, Field{Name: name, Value: v})
+		}
+	}
+	return nil, fmt.Errorf("unknown NBT tag type %d", typ)
+}
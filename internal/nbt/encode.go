@@ -0,0 +1,157 @@
+package nbt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Encode writes value as one uncompressed named tag to w.
+func Encode(w io.Writer, name string, value Tag) error {
+	if value == nil {
+		return fmt.Errorf("cannot encode a nil tag")
+	}
+	e := encoder{w: bufio.NewWriter(w)}
+	e.byte(byte(value.Type()))
+	e.string(name)
+	e.payload(value, 0)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// encoder remembers the first error, so that payload can write without
+// checking every call.
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (e *encoder) fail(format string, args ...any) {
+	if e.err == nil {
+		e.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf[0] = b
+	e.write(e.buf[:1])
+}
+
+func (e *encoder) uint16(v uint16) {
+	binary.BigEndian.PutUint16(e.buf[:2], v)
+	e.write(e.buf[:2])
+}
+
+func (e *encoder) uint32(v uint32) {
+	binary.BigEndian.PutUint32(e.buf[:4], v)
+	e.write(e.buf[:4])
+}
+
+func (e *encoder) uint64(v uint64) {
+	binary.BigEndian.PutUint64(e.buf[:8], v)
+	e.write(e.buf[:8])
+}
+
+func (e *encoder) length(n int) {
+	if n > math.MaxInt32 {
+		e.fail("NBT array of %d elements is too long", n)
+		return
+	}
+	e.uint32(uint32(n))
+}
+
+func (e *encoder) string(s string) {
+	b := encodeMUTF8(s)
+	if len(b) > math.MaxUint16 {
+		e.fail("NBT string of %d bytes is too long", len(b))
+		return
+	}
+	e.uint16(uint16(len(b)))
+	e.write(b)
+}
+
+func (e *encoder) payload(v Tag, depth int) {
+	switch v := v.(type) {
+	case Byte:
+		e.byte(byte(v))
+	case Short:
+		e.uint16(uint16(v))
+	case Int:
+		e.uint32(uint32(v))
+	case Long:
+		e.uint64(uint64(v))
+	case Float:
+		e.uint32(math.Float32bits(float32(v)))
+	case Double:
+		e.uint64(math.Float64bits(float64(v)))
+	case String:
+		e.string(string(v))
+	case ByteArray:
+		e.length(len(v))
+		e.write(v)
+	case IntArray:
+		e.length(len(v))
+		for _, n := range v {
+			e.uint32(uint32(n))
+		}
+	case LongArray:
+		e.length(len(v))
+		for _, n := range v {
+			e.uint64(uint64(n))
+		}
+	case *List:
+		if v == nil {
+			e.fail("cannot encode a nil list")
+			return
+		}
+		if depth >= MaxDepth {
+			e.fail("NBT nested deeper than %d", MaxDepth)
+			return
+		}
+		elem := v.Elem
+		if elem == TagEnd && len(v.Items) > 0 {
+			elem = v.Items[0].Type()
+		}
+		e.byte(byte(elem))
+		e.length(len(v.Items))
+		for i, item := range v.Items {
+			if item == nil || item.Type() != elem {
+				e.fail("list item %d is not a %s tag", i, elem)
+				return
+			}
+			e.payload(item, depth+1)
+		}
+	case *Compound:
+		if v == nil {
+			e.fail("cannot encode a nil compound")
+			return
+		}
+		if depth >= MaxDepth {
+			e.fail("NBT nested deeper than %d", MaxDepth)
+			return
+		}
+		for _, f := range v.Fields {
+			if f.Value == nil {
+				e.fail("compound field %q has no value", f.Name)
+				return
+			}
+			e.byte(byte(f.Value.Type()))
+			e.string(f.Name)
+			e.payload(f.Value, depth+1)
+		}
+		e.byte(byte(TagEnd))
+	default:
+		e.fail("cannot encode %T as NBT", v)
+	}
+}
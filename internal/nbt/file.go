@@ -0,0 +1,156 @@
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Compression is the container an NBT file is stored in.
+type Compression int
+
+const (
+	// Uncompressed files start directly with the root tag.
+	Uncompressed Compression = iota
+	// Gzip is used by level.dat, player data and most other files.
+	Gzip
+	// Zlib is used for chunks inside region files.
+	Zlib
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	}
+	return "uncompressed"
+}
+
+// File is a decoded NBT file: its root tag and how it was stored, so that
+// it can be written back the same way.
+type File struct {
+	Name        string // name of the root tag, usually empty
+	Root        Tag
+	Compression Compression
+}
+
+// RootCompound returns the root tag if it is a compound, as it is in every
+// Java edition file.
+func (f *File) RootCompound() (*Compound, error) {
+	c, ok := f.Root.(*Compound)
+	if !ok {
+		return nil, fmt.Errorf("root tag is a %s, not a compound", f.Root.Type())
+	}
+	return c, nil
+}
+
+// detect tells gzip and zlib streams from raw NBT by their first two bytes.
+// A raw file starts with a tag type, which is never 0x1f or 0x78.
+func detect(head []byte) Compression {
+	if len(head) >= 2 && head[0] == 0x1f && head[1] == 0x8b {
+		return Gzip
+	}
+	if len(head) >= 2 && head[0] == 0x78 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0 {
+		return Zlib
+	}
+	return Uncompressed
+}
+
+// Read decodes an NBT file from r, detecting its compression.
+func Read(r io.Reader) (*File, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(2)
+	f := &File{Compression: detect(head)}
+
+	var src io.Reader = br
+	switch f.Compression {
+	case Gzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	case Zlib:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zlib data: %w", err)
+		}
+		defer zr.Close()
+		src = zr
+	}
+
+	var err error
+	if f.Name, f.Root, err = Decode(src); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write encodes f to w with its compression.
+func (f *File) Write(w io.Writer) error {
+	switch f.Compression {
+	case Gzip:
+		gz := gzip.NewWriter(w)
+		if err := Encode(gz, f.Name, f.Root); err != nil {
+			return err
+		}
+		return gz.Close()
+	case Zlib:
+		zw := zlib.NewWriter(w)
+		if err := Encode(zw, f.Name, f.Root); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+	return Encode(w, f.Name, f.Root)
+}
+
+// ReadFile decodes the NBT file at path.
+func ReadFile(path string) (*File, error) {
+	data, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+	return Read(data)
+}
+
+// WriteFile encodes f to path. The file is replaced atomically and keeps
+// its permissions, so the game never sees it half written.
+func WriteFile(path string, f *File) error {
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package nbt
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// NBT strings use Java's modified UTF-8: NUL is written as two bytes, and
+// characters outside the Basic Multilingual Plane as two three-byte
+// surrogates instead of one four-byte sequence.
+
+var errMalformedString = errors.New("malformed modified UTF-8 string")
+
+// plainASCII reports whether b is the same in UTF-8 and modified UTF-8.
+func plainASCII[T string | []byte](b T) bool {
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0 || b[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeMUTF8 converts modified UTF-8 to a Go string. Unpaired surrogates,
+// which Java allows, are kept in their three-byte form so that they are
+// written back unchanged.
+func decodeMUTF8(b []byte) (string, error) {
+	if plainASCII(b) {
+		return string(b), nil
+	}
+
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		a := b[i]
+		switch {
+		case a < 0x80:
+			units = append(units, uint16(a))
+			i++
+		case a&0xE0 == 0xC0:
+			if i+1 >= len(b) || b[i+1]&0xC0 != 0x80 {
+				return "", errMalformedString
+			}
+			units = append(units, uint16(a&0x1F)<<6|uint16(b[i+1]&0x3F))
+			i += 2
+		case a&0xF0 == 0xE0:
+			if i+2 >= len(b) || b[i+1]&0xC0 != 0x80 || b[i+2]&0xC0 != 0x80 {
+				return "", errMalformedString
+			}
+			units = append(units, uint16(a&0x0F)<<12|uint16(b[i+1]&0x3F)<<6|uint16(b[i+2]&0x3F))
+			i += 3
+		default:
+			return "", errMalformedString
+		}
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for i := 0; i < len(units); i++ {
+		u := units[i]
+		if !utf16.IsSurrogate(rune(u)) {
+			sb.WriteRune(rune(u))
+			continue
+		}
+		if u < 0xDC00 && i+1 < len(units) && units[i+1] >= 0xDC00 && units[i+1] <= 0xDFFF {
+			sb.WriteRune(utf16.DecodeRune(rune(u), rune(units[i+1])))
+			i++
+			continue
+		}
+		sb.Write(appendUnit(nil, u))
+	}
+	return sb.String(), nil
+}
+
+// encodeMUTF8 converts a Go string to modified UTF-8 the way Java writes
+// it. Invalid UTF-8 is replaced by U+FFFD.
+func encodeMUTF8(s string) []byte {
+	if plainASCII(s) {
+		return []byte(s)
+	}
+
+	out := make([]byte, 0, len(s)+8)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 && i+2 < len(s) &&
+			s[i] == 0xED && s[i+1]&0xE0 == 0xA0 && s[i+2]&0xC0 == 0x80 {
+			// An unpaired surrogate kept by decodeMUTF8
+			out = append(out, s[i:i+3]...)
+			i += 3
+			continue
+		}
+		i += size
+		if r >= 0x10000 {
+			hi, lo := utf16.EncodeRune(r)
+			out = appendUnit(appendUnit(out, uint16(hi)), uint16(lo))
+			continue
+		}
+		out = appendUnit(out, uint16(r))
+	}
+	return out
+}
+
+func appendUnit(out []byte, u uint16) []byte {
+	switch {
+	case u != 0 && u < 0x80:
+		return append(out, byte(u))
+	case u < 0x800:
+		return append(out, 0xC0|byte(u>>6), 0x80|byte(u)&0x3F)
+	}
+	return append(out, 0xE0|byte(u>>12), 0x80|byte(u>>6)&0x3F, 0x80|byte(u)&0x3F)
+}
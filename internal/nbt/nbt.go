@@ -0,0 +1,162 @@
+// Package nbt reads and writes Minecraft's Named Binary Tag format, as used
+// by level.dat, servers.dat and other Java edition files.
+//
+// Tags keep everything needed to write a file back unchanged: compounds keep
+// their field order, lists keep their element type even when empty, and
+// floating point values keep their exact bits.
+package nbt
+
+import (
+	"fmt"
+)
+
+// Type identifies the kind of a tag.
+type Type byte
+
+// Tag types of the Java edition format.
+const (
+	TagEnd Type = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+var typeNames = [...]string{
+	"end", "byte", "short", "int", "long", "float", "double",
+	"byte_array", "string", "list", "compound", "int_array", "long_array",
+}
+
+func (t Type) String() string {
+	if int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("type(%d)", byte(t))
+}
+
+// Tag is the value of one NBT tag: one of the types below.
+type Tag interface {
+	Type() Type
+}
+
+// Scalar, string and array tags.
+type (
+	Byte      int8
+	Short     int16
+	Int       int32
+	Long      int64
+	Float     float32
+	Double    float64
+	ByteArray []byte
+	String    string
+	IntArray  []int32
+	LongArray []int64
+)
+
+func (Byte) Type() Type      { return TagByte }
+func (Short) Type() Type     { return TagShort }
+func (Int) Type() Type       { return TagInt }
+func (Long) Type() Type      { return TagLong }
+func (Float) Type() Type     { return TagFloat }
+func (Double) Type() Type    { return TagDouble }
+func (ByteArray) Type() Type { return TagByteArray }
+func (String) Type() Type    { return TagString }
+func (IntArray) Type() Type  { return TagIntArray }
+func (LongArray) Type() Type { return TagLongArray }
+func (*List) Type() Type     { return TagList }
+func (*Compound) Type() Type { return TagCompound }
+
+// List is a list of tags that all have the type Elem.
+type List struct {
+	Elem  Type
+	Items []Tag
+}
+
+// Field is one named tag of a compound.
+type Field struct {
+	Name  string
+	Value Tag
+}
+
+// Compound is an ordered set of named tags.
+type Compound struct {
+	Fields []Field
+}
+
+// Get returns the tag called name.
+func (c *Compound) Get(name string) (Tag, bool) {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the tag called name in place, or appends it if c has none.
+func (c *Compound) Set(name string, v Tag) {
+	for i, f := range c.Fields {
+		if f.Name == name {
+			c.Fields[i].Value = v
+			return
+		}
+	}
+	c.Fields = append(c.Fields, Field{Name: name, Value: v})
+}
+
+// Remove deletes the tag called name and reports whether there was one.
+func (c *Compound) Remove(name string) bool {
+	for i, f := range c.Fields {
+		if f.Name == name {
+			c.Fields = append(c.Fields[:i], c.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Compound returns the compound called name.
+func (c *Compound) Compound(name string) (*Compound, bool) {
+	v, _ := c.Get(name)
+	sub, ok := v.(*Compound)
+	return sub, ok
+}
+
+// List returns the list called name.
+func (c *Compound) List(name string) (*List, bool) {
+	v, _ := c.Get(name)
+	l, ok := v.(*List)
+	return l, ok
+}
+
+// String returns the string called name.
+func (c *Compound) String(name string) (string, bool) {
+	v, _ := c.Get(name)
+	s, ok := v.(String)
+	return string(s), ok
+}
+
+// Int64 returns the integer called name, whichever of byte, short, int or
+// long it is stored as.
+func (c *Compound) Int64(name string) (int64, bool) {
+	v, _ := c.Get(name)
+	switch n := v.(type) {
+	case Byte:
+		return int64(n), true
+	case Short:
+		return int64(n), true
+	case Int:
+		return int64(n), true
+	case Long:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,262 @@
+package nbt
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// named wraps payload into an uncompressed named tag of type typ with an
+// empty name.
+func named(typ Type, payload ...byte) []byte {
+	return append([]byte{byte(typ), 0, 0}, payload...)
+}
+
+func TestDecodeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Tag
+	}{
+		{"byte", named(TagByte, 0xff), Byte(-1)},
+		{"short", named(TagShort, 0x80, 0x00), Short(math.MinInt16)},
+		{"int", named(TagInt, 0x00, 0x01, 0x00, 0x00), Int(65536)},
+		{"long", named(TagLong, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff), Long(math.MaxInt64)},
+		{"float", named(TagFloat, 0x3f, 0xc0, 0x00, 0x00), Float(1.5)},
+		{"double", named(TagDouble, 0xc0, 0x04, 0, 0, 0, 0, 0, 0), Double(-2.5)},
+		{"byte array", named(TagByteArray, 0, 0, 0, 3, 1, 2, 0xff), ByteArray{1, 2, 0xff}},
+		{"string", named(TagString, 0, 5, 'h', 'e', 'l', 'l', 'o'), String("hello")},
+		{"empty list keeps its type", named(TagList, byte(TagInt), 0, 0, 0, 0), &List{Elem: TagInt, Items: []Tag{}}},
+		{"list", named(TagList, byte(TagShort), 0, 0, 0, 2, 0, 1, 0, 2), &List{Elem: TagShort, Items: []Tag{Short(1), Short(2)}}},
+		{"compound keeps field order", named(TagCompound,
+			byte(TagByte), 0, 1, 'b', 1,
+			byte(TagByte), 0, 1, 'a', 2,
+			byte(TagEnd)),
+			&Compound{Fields: []Field{{"b", Byte(1)}, {"a", Byte(2)}}}},
+		{"int array", named(TagIntArray, 0, 0, 0, 2, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 7), IntArray{-1, 7}},
+		{"long array", named(TagLongArray, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 0), LongArray{256}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, got, err := Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if name != "" {
+				t.Errorf("name = %q, want empty", name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %#v, want %#v", got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			if err := Encode(&buf, "", got); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.data) {
+				t.Errorf("Encode = % x, want % x", buf.Bytes(), tt.data)
+			}
+		})
+	}
+}
+
+func TestFloatBitsSurvive(t *testing.T) {
+	// A NaN with a payload must be written back with the same bits
+	data := named(TagDouble, 0x7f, 0xf8, 0, 0, 0, 0, 0, 1)
+	_, v, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, "", v); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("Encode = % x, want % x", buf.Bytes(), data)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"end as root", []byte{0}},
+		{"unknown root type", []byte{13, 0, 0}},
+		{"truncated name", []byte{byte(TagByte), 0, 5, 'a'}},
+		{"truncated int", named(TagInt, 0, 1)},
+		{"negative length", named(TagByteArray, 0xff, 0xff, 0xff, 0xff)},
+		{"short byte array", named(TagByteArray, 0, 0, 0, 4, 1)},
+		{"huge int array", named(TagIntArray, 0x7f, 0xff, 0xff, 0xff, 1, 2, 3, 4)},
+		{"list of end with items", named(TagList, byte(TagEnd), 0, 0, 0, 1)},
+		{"unknown list type", named(TagList, 42, 0, 0, 0, 0)},
+		{"compound without end", named(TagCompound, byte(TagByte), 0, 1, 'a', 1)},
+		{"unknown field type", named(TagCompound, 99, 0, 0)},
+		{"four-byte UTF-8 in a string", named(TagString, 0, 4, 0xf0, 0x9f, 0x98, 0x80)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(bytes.NewReader(tt.data)); err == nil {
+				t.Error("Decode succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestDecodeTooDeep(t *testing.T) {
+	var data []byte
+	data = append(data, byte(TagList), 0, 0)
+	for i := 0; i < MaxDepth+1; i++ {
+		data = append(data, byte(TagList), 0, 0, 0, 1)
+	}
+	if _, _, err := Decode(bytes.NewReader(data)); err == nil || errors.Is(err, ErrTruncated) {
+		t.Errorf("Decode = %v, want a depth error", err)
+	}
+}
+
+// nestedLists is a list of lists, each claiming 2^31-1 elements, nested
+// just below MaxDepth.
+func nestedLists() []byte {
+	data := []byte{byte(TagList), 0, 0}
+	for i := 0; i < MaxDepth-1; i++ {
+		data = append(data, byte(TagList), 0x7f, 0xff, 0xff, 0xff)
+	}
+	return data
+}
+
+func TestDecodeNestedListLengths(t *testing.T) {
+	data := nestedLists()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, _, err := Decode(bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("Decode = %v, want ErrTruncated", err)
+	}
+	// The lengths in the headers must not be trusted beyond one budget
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("Decode of %d bytes allocated %d MB", len(data), allocated>>20)
+	}
+}
+
+func TestMUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		encoded []byte
+	}{
+		{"ascii", "abc", []byte("abc")},
+		{"NUL takes two bytes", "a\x00b", []byte{'a', 0xc0, 0x80, 'b'}},
+		{"two-byte", "é", []byte{0xc3, 0xa9}},
+		{"three-byte", "€", []byte{0xe2, 0x82, 0xac}},
+		{"supplementary as surrogate pair", "😀", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}},
+		{"unpaired high surrogate", "\xed\xa0\xbd", []byte{0xed, 0xa0, 0xbd}},
+		{"unpaired low surrogate", "x\xed\xb8\x80", []byte{'x', 0xed, 0xb8, 0x80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeMUTF8(tt.s); !bytes.Equal(got, tt.encoded) {
+				t.Errorf("encodeMUTF8(%q) = % x, want % x", tt.s, got, tt.encoded)
+			}
+			got, err := decodeMUTF8(tt.encoded)
+			if err != nil {
+				t.Fatalf("decodeMUTF8: %v", err)
+			}
+			if got != tt.s {
+				t.Errorf("decodeMUTF8(% x) = %q, want %q", tt.encoded, got, tt.s)
+			}
+		})
+	}
+}
+
+func TestMUTF8Malformed(t *testing.T) {
+	for _, b := range [][]byte{
+		{0xc3},             // cut two-byte sequence
+		{0xe2, 0x82},       // cut three-byte sequence
+		{0xc3, 0x41},       // bad continuation byte
+		{0x80},             // lone continuation byte
+		{0xf0, 0x9f, 0x98}, // four-byte lead
+	} {
+		if _, err := decodeMUTF8(b); err == nil {
+			t.Errorf("decodeMUTF8(% x) succeeded, want an error", b)
+		}
+	}
+}
+
+func TestFileCompressionRoundTrip(t *testing.T) {
+	for _, c := range []Compression{Uncompressed, Gzip, Zlib} {
+		t.Run(c.String(), func(t *testing.T) {
+			data := sampleFile(t, levelDat(), c)
+			f, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if f.Compression != c {
+				t.Errorf("Compression = %s, want %s", f.Compression, c)
+			}
+			root, err := f.RootCompound()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data2, _ := root.Compound("Data")
+			if name, _ := data2.String("LevelName"); name != "New World" {
+				t.Errorf("LevelName = %q", name)
+			}
+			if !reflect.DeepEqual(f.Root, levelDat()) {
+				t.Errorf("Root = %#v, want %#v", f.Root, levelDat())
+			}
+		})
+	}
+}
+
+// levelDat is a trimmed down level.dat root.
+func levelDat() *Compound {
+	return &Compound{Fields: []Field{{"Data", &Compound{Fields: []Field{
+		{"LevelName", String("New World")},
+		{"DataVersion", Int(3465)},
+		{"GameType", Int(0)},
+		{"hardcore", Byte(0)},
+		{"LastPlayed", Long(1760781600000)},
+		{"SpawnAngle", Float(0)},
+		{"BorderSize", Double(5.9999968e7)},
+		{"Version", &Compound{Fields: []Field{
+			{"Name", String("1.20.1")},
+			{"Snapshot", Byte(0)},
+		}}},
+		{"ServerBrands", &List{Elem: TagString, Items: []Tag{String("fabric")}}},
+		{"DataPacks", &Compound{Fields: []Field{
+			{"Enabled", &List{Elem: TagString, Items: []Tag{String("vanilla")}}},
+			{"Disabled", &List{Elem: TagEnd, Items: []Tag{}}},
+		}}},
+		{"WorldGenSettings", &Compound{Fields: []Field{{"seed", Long(-4172144997902289642)}}}},
+		{"UUID", IntArray{1, -2, 3, -4}},
+	}}}}}
+}
+
+// serversDat is a servers.dat root with two servers.
+func serversDat() *Compound {
+	server := func(name, ip string) Tag {
+		return &Compound{Fields: []Field{
+			{"ip", String(ip)},
+			{"name", String(name)},
+			{"acceptTextures", Byte(1)},
+		}}
+	}
+	return &Compound{Fields: []Field{{"servers", &List{Elem: TagCompound, Items: []Tag{
+		server("Hypixel", "mc.hypixel.net"),
+		server("Home \x00 ☃ 😀", "192.168.0.2:25566"),
+	}}}}}
+}
+
+func sampleFile(t testing.TB, root Tag, c Compression) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (&File{Root: root, Compression: c}).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,405 @@
+// Package packs reads and manages the resource packs and shader packs of an
+// instance.
+package packs
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
+)
+
+// Kind tells resource packs from shader packs.
+type Kind string
+
+const (
+	KindResource Kind = "resourcepack"
+	KindShader   Kind = "shaderpack"
+)
+
+// Dir returns the folder of an instance that holds packs of this kind; it is
+// also the section name used for trashing them.
+func (k Kind) Dir() string {
+	if k == KindShader {
+		return "shaderpacks"
+	}
+	return "resourcepacks"
+}
+
+// Label is the name of the kind for messages.
+func (k Kind) Label() string {
+	if k == KindShader {
+		return "shader pack"
+	}
+	return "resource pack"
+}
+
+// Kinds are all pack kinds, in the order they are listed.
+var Kinds = []Kind{KindResource, KindShader}
+
+// DisabledSuffix is appended to the file name of a disabled pack, which
+// hides it from the game.
+const DisabledSuffix = ".disabled"
+
+// mcmetaName is the metadata file at the root of a resource pack.
+const mcmetaName = "pack.mcmeta"
+
+// Pack is one entry of an instance's resourcepacks or shaderpacks folder.
+type Pack struct {
+	Name        string `json:"name" yaml:"name"` // file name without DisabledSuffix
+	File        string `json:"file" yaml:"file"` // file name on disk
+	Path        string `json:"path" yaml:"path"`
+	Kind        Kind   `json:"kind" yaml:"kind"`
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// PackFormat is pack_format from pack.mcmeta; MinFormat and MaxFormat
+	// are the range a pack declares with supported_formats or
+	// min_format/max_format, or PackFormat for both
+	PackFormat int `json:"pack_format,omitempty" yaml:"pack_format,omitempty"`
+	MinFormat  int `json:"min_format,omitempty" yaml:"min_format,omitempty"`
+	MaxFormat  int `json:"max_format,omitempty" yaml:"max_format,omitempty"`
+	// Warning explains why the pack may not work: an unreadable pack.mcmeta
+	// or a format the instance's Minecraft version does not match
+	Warning string `json:"warning,omitempty" yaml:"warning,omitempty"`
+}
+
+// List returns the packs of kind in the instance at instancePath, sorted by
+// name. Resource packs are checked against the pack format expected by the
+// instance's Minecraft version, if it is known.
+func List(instancePath string, kind Kind) ([]Pack, error) {
+	dir := filepath.Join(instancePath, kind.Dir())
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", kind.Dir(), err)
+	}
+
+	expected := 0
+	if kind == KindResource {
+		expected = ExpectedFormat(GameDataVersion(instancePath))
+	}
+
+	var packs []Pack
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := Pack{
+			Name:    strings.TrimSuffix(e.Name(), DisabledSuffix),
+			File:    e.Name(),
+			Path:    filepath.Join(dir, e.Name()),
+			Kind:    kind,
+			Enabled: !strings.HasSuffix(e.Name(), DisabledSuffix),
+		}
+		if kind == KindResource {
+			p.readMeta()
+			if p.Warning == "" && expected > 0 && p.PackFormat > 0 && (expected < p.MinFormat || expected > p.MaxFormat) {
+				p.Warning = fmt.Sprintf("made for pack format %s, this instance uses %d", p.formatRange(), expected)
+			}
+		}
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(i, j int) bool {
+		return strings.ToLower(packs[i].Name) < strings.ToLower(packs[j].Name)
+	})
+	return packs, nil
+}
+
+func (p Pack) formatRange() string {
+	if p.MinFormat == p.MaxFormat {
+		return strconv.Itoa(p.MinFormat)
+	}
+	return fmt.Sprintf("%d-%d", p.MinFormat, p.MaxFormat)
+}
+
+// Find returns the pack of kind called name (with or without
+// DisabledSuffix).
+func Find(instancePath string, kind Kind, name string) (*Pack, error) {
+	packs, err := List(instancePath, kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		if p.Name == name || p.File == name {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("instance has no %s '%s'", kind.Label(), name)
+}
+
+// PlanSetEnabled returns the steps SetEnabled would perform.
+func PlanSetEnabled(p Pack, enabled bool) (*instance.Plan, error) {
+	action := "disable-pack"
+	if enabled {
+		action = "enable-pack"
+	}
+	plan := &instance.Plan{Action: action}
+	if p.Enabled == enabled {
+		return plan, nil
+	}
+	dst, err := toggledPath(p)
+	if err != nil {
+		return nil, err
+	}
+	plan.Steps = append(plan.Steps, instance.Step{Op: instance.OpRename, Path: p.Path, Target: dst})
+	return plan, nil
+}
+
+// SetEnabled enables or disables p by removing or adding DisabledSuffix,
+// and returns its new path. Packs already in that state are left alone.
+func SetEnabled(p Pack, enabled bool) (string, error) {
+	if p.Enabled == enabled {
+		return p.Path, nil
+	}
+	dst, err := toggledPath(p)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(p.Path, dst); err != nil {
+		return "", fmt.Errorf("failed to rename %s: %w", p.File, err)
+	}
+	return dst, nil
+}
+
+func toggledPath(p Pack) (string, error) {
+	file := p.Name
+	if p.Enabled {
+		file += DisabledSuffix
+	}
+	dst := filepath.Join(filepath.Dir(p.Path), file)
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("%s already exists", file)
+	}
+	return dst, nil
+}
+
+// mcmeta is the part of pack.mcmeta this package reads.
+type mcmeta struct {
+	Pack struct {
+		Description      json.RawMessage `json:"description"`
+		PackFormat       int             `json:"pack_format"`
+		SupportedFormats json.RawMessage `json:"supported_formats"`
+		MinFormat        json.RawMessage `json:"min_format"`
+		MaxFormat        json.RawMessage `json:"max_format"`
+	} `json:"pack"`
+}
+
+// readMeta fills in the pack.mcmeta details of a resource pack.
+func (p *Pack) readMeta() {
+	data, err := readMcmeta(p.Path)
+	if err != nil {
+		p.Warning = err.Error()
+		return
+	}
+	var meta mcmeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		p.Warning = "invalid " + mcmetaName + ": " + err.Error()
+		return
+	}
+	p.Description = textComponent(meta.Pack.Description)
+	p.PackFormat = meta.Pack.PackFormat
+	p.MinFormat, p.MaxFormat = p.PackFormat, p.PackFormat
+	if lo, hi, ok := formatRange(meta.Pack.SupportedFormats); ok {
+		p.MinFormat, p.MaxFormat = lo, hi
+	}
+	// 1.21.9 replaced both with min_format/max_format, a major version or
+	// a [major, minor] pair
+	if lo, ok := majorFormat(meta.Pack.MinFormat); ok {
+		p.MinFormat = lo
+		if p.PackFormat == 0 {
+			p.PackFormat = lo
+		}
+	}
+	if hi, ok := majorFormat(meta.Pack.MaxFormat); ok {
+		p.MaxFormat = hi
+	}
+}
+
+// readMcmeta returns pack.mcmeta of the pack at path, a zip or a folder.
+func readMcmeta(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		data, err := os.ReadFile(filepath.Join(path, mcmetaName))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no %s", mcmetaName)
+		}
+		return data, err
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a zip file")
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != mcmetaName {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(io.LimitReader(r, 1<<20))
+	}
+	return nil, fmt.Errorf("no %s", mcmetaName)
+}
+
+// formatCodes matches the § formatting codes of legacy descriptions.
+var formatCodes = regexp.MustCompile("§.")
+
+// textComponent flattens a description, which is a string or a JSON text
+// component, to plain text.
+func textComponent(raw json.RawMessage) string {
+	var flatten func(v any) string
+	flatten = func(v any) string {
+		switch v := v.(type) {
+		case string:
+			return v
+		case []any:
+			var sb strings.Builder
+			for _, part := range v {
+				sb.WriteString(flatten(part))
+			}
+			return sb.String()
+		case map[string]any:
+			s := flatten(v["text"])
+			if s == "" {
+				s = flatten(v["translate"])
+			}
+			return s + flatten(v["extra"])
+		case float64, bool:
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	var v any
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return ""
+	}
+	return strings.TrimSpace(formatCodes.ReplaceAllString(flatten(v), ""))
+}
+
+// formatRange parses supported_formats: a number, [min, max] or
+// {"min_inclusive": min, "max_inclusive": max}.
+func formatRange(raw json.RawMessage) (int, int, bool) {
+	if len(raw) == 0 {
+		return 0, 0, false
+	}
+	var n int
+	if json.Unmarshal(raw, &n) == nil {
+		return n, n, true
+	}
+	var pair []int
+	if json.Unmarshal(raw, &pair) == nil && len(pair) == 2 {
+		return pair[0], pair[1], true
+	}
+	var obj struct {
+		Min int `json:"min_inclusive"`
+		Max int `json:"max_inclusive"`
+	}
+	if json.Unmarshal(raw, &obj) == nil && obj.Max > 0 {
+		return obj.Min, obj.Max, true
+	}
+	return 0, 0, false
+}
+
+// majorFormat parses min_format or max_format: a number or [major, minor].
+func majorFormat(raw json.RawMessage) (int, bool) {
+	if len(raw) == 0 {
+		return 0, false
+	}
+	var n int
+	if json.Unmarshal(raw, &n) == nil {
+		return n, true
+	}
+	var pair []int
+	if json.Unmarshal(raw, &pair) == nil && len(pair) > 0 {
+		return pair[0], true
+	}
+	return 0, false
+}
+
+// packFormats maps the first data version of each release to the resource
+// pack format it expects.
+var packFormats = []struct{ dataVersion, format int }{
+	{169, 2},   // 1.9
+	{819, 3},   // 1.11
+	{1519, 4},  // 1.13
+	{2225, 5},  // 1.15
+	{2578, 6},  // 1.16.2
+	{2724, 7},  // 1.17
+	{2860, 8},  // 1.18
+	{3105, 9},  // 1.19
+	{3218, 12}, // 1.19.3
+	{3337, 13}, // 1.19.4
+	{3463, 15}, // 1.20
+	{3578, 18}, // 1.20.2
+	{3698, 22}, // 1.20.3
+	{3837, 32}, // 1.20.5
+	{3953, 34}, // 1.21
+	{4080, 42}, // 1.21.2
+	{4189, 46}, // 1.21.4
+	{4325, 55}, // 1.21.5
+	{4435, 63}, // 1.21.6
+	{4438, 64}, // 1.21.7
+}
+
+// lastKnownDataVersion is the last release packFormats covers (1.21.8).
+// Newer versions are not judged.
+const lastKnownDataVersion = 4440
+
+// ExpectedFormat returns the resource pack format of the Minecraft version
+// with the given data version, or 0 if it is unknown.
+func ExpectedFormat(dataVersion int) int {
+	if dataVersion > lastKnownDataVersion {
+		return 0
+	}
+	format := 0
+	for _, f := range packFormats {
+		if dataVersion >= f.dataVersion {
+			format = f.format
+		}
+	}
+	return format
+}
+
+// GameDataVersion returns the data version of the Minecraft version the
+// instance was last played with: the version line of options.txt, or else
+// the newest world's. It returns 0 if neither is known.
+func GameDataVersion(instancePath string) int {
+	if f, err := os.Open(filepath.Join(instancePath, "options.txt")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if v, ok := strings.CutPrefix(scanner.Text(), "version:"); ok {
+				if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+					return n
+				}
+			}
+		}
+	}
+
+	list, _ := worlds.List(worlds.SavesDir(instancePath))
+	for _, w := range list {
+		if w.DataVersion > 0 {
+			return int(w.DataVersion)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package packs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpectedFormat(t *testing.T) {
+	tests := []struct {
+		dataVersion int
+		want        int
+	}{
+		{0, 0},
+		{-1, 0},
+		{168, 0}, // before 1.9
+		{169, 2},
+		{818, 2},
+		{819, 3},
+		{3217, 9}, // 1.19.2
+		{3218, 12},
+		{3465, 15}, // 1.20.1
+		{3953, 34},
+		{4437, 63},
+		{4438, 64},
+		{lastKnownDataVersion, 64},
+		{lastKnownDataVersion + 1, 0}, // newer than packFormats knows
+	}
+	for _, tt := range tests {
+		if got := ExpectedFormat(tt.dataVersion); got != tt.want {
+			t.Errorf("ExpectedFormat(%d) = %d, want %d", tt.dataVersion, got, tt.want)
+		}
+	}
+}
+
+func TestPackFormatsSorted(t *testing.T) {
+	for i := 1; i < len(packFormats); i++ {
+		prev, cur := packFormats[i-1], packFormats[i]
+		if cur.dataVersion <= prev.dataVersion || cur.format <= prev.format {
+			t.Errorf("packFormats[%d] = %v does not follow %v", i, cur, prev)
+		}
+	}
+	if last := packFormats[len(packFormats)-1]; last.dataVersion > lastKnownDataVersion {
+		t.Errorf("lastKnownDataVersion %d is older than %v", lastKnownDataVersion, last)
+	}
+}
+
+func TestFormatRange(t *testing.T) {
+	tests := []struct {
+		raw    string
+		lo, hi int
+		ok     bool
+	}{
+		{``, 0, 0, false},
+		{`15`, 15, 15, true},
+		{`[15, 22]`, 15, 22, true},
+		{`{"min_inclusive": 18, "max_inclusive": 34}`, 18, 34, true},
+		{`[15]`, 0, 0, false},
+		{`"15"`, 0, 0, false},
+	}
+	for _, tt := range tests {
+		lo, hi, ok := formatRange(json.RawMessage(tt.raw))
+		if lo != tt.lo || hi != tt.hi || ok != tt.ok {
+			t.Errorf("formatRange(%s) = %d, %d, %v, want %d, %d, %v", tt.raw, lo, hi, ok, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestListWarnsAboutFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "options.txt"), []byte("version:3465\nlang:en_us\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	packs := map[string]string{
+		"Matching":          `{"pack": {"pack_format": 15, "description": "ok"}}`,
+		"Range":             `{"pack": {"pack_format": 9, "supported_formats": [9, 15]}}`,
+		"Old":               `{"pack": {"pack_format": 9, "description": "§6old"}}`,
+		"NewMinMax":         `{"pack": {"min_format": [64, 0], "max_format": 65}}`,
+		"Broken":            `{"pack": `,
+		"Disabled.disabled": `{"pack": {"pack_format": 15}}`,
+	}
+	for name, meta := range packs {
+		pack := filepath.Join(dir, "resourcepacks", name)
+		if err := os.MkdirAll(pack, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(pack, mcmetaName), []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := List(dir, KindResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warned := map[string]bool{}
+	for _, p := range list {
+		warned[p.Name] = p.Warning != ""
+		if p.Name == "Old" && p.Description != "old" {
+			t.Errorf("Old: Description = %q, want formatting codes removed", p.Description)
+		}
+		if p.Name == "Disabled" && p.Enabled {
+			t.Error("Disabled: Enabled = true")
+		}
+	}
+	want := map[string]bool{"Matching": false, "Range": false, "Old": true, "NewMinMax": true, "Broken": true, "Disabled": false}
+	for name, w := range want {
+		if warned[name] != w {
+			t.Errorf("%s: warned = %v, want %v", name, warned[name], w)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+// Package search indexes what every instance contains, such as file names,
+// mod ids and versions, world names and optionally the text of config files,
+// and matches queries against the index fuzzily.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/mods"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
+	"github.com/sahilm/fuzzy"
+)
+
+// CacheFileName is the file inside AppDir that keeps the metadata of
+// indexed jars, so unchanged jars are not opened again.
+const CacheFileName = "search-cache.json"
+
+// maxConfigTextSize is the largest config file whose text is indexed.
+const maxConfigTextSize = 256 << 10
+
+// Kind is what an entry of the index refers to.
+type Kind string
+
+const (
+	KindInstance     Kind = "instance"
+	KindMod          Kind = "mod"
+	KindConfig       Kind = "config"
+	KindConfigText   Kind = "config-text" // a line of a config file
+	KindWorld        Kind = "world"
+	KindResourcePack Kind = "resourcepack"
+	KindShaderPack   Kind = "shaderpack"
+)
+
+// Kinds lists every kind, in the order results of equal score are shown.
+// Config text always comes after the other matches.
+var Kinds = []Kind{KindInstance, KindMod, KindConfig, KindWorld, KindResourcePack, KindShaderPack, KindConfigText}
+
+// Entry is one indexed item of an instance.
+type Entry struct {
+	Instance string `json:"instance" yaml:"instance"`
+	Kind     Kind   `json:"kind" yaml:"kind"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"` // relative to the instance, with forward slashes
+	Name     string `json:"name" yaml:"name"`
+	Detail   string `json:"detail,omitempty" yaml:"detail,omitempty"` // mod ids and versions, world name, config file
+	Line     int    `json:"line,omitempty" yaml:"line,omitempty"`     // of a config-text entry
+}
+
+// text is what a query is matched against.
+func (e *Entry) text() string {
+	if e.Detail == "" || e.Kind == KindConfigText {
+		return e.Name
+	}
+	return e.Name + " " + e.Detail
+}
+
+// Result is an entry matching a query. Higher scores are better matches.
+type Result struct {
+	Entry
+	Score int `json:"score" yaml:"score"`
+}
+
+// Options select what is indexed.
+type Options struct {
+	ConfigText bool // index every line of config files too
+}
+
+// cachedJar is the metadata of a jar as of its size and mtime.
+type cachedJar struct {
+	Size    int64       `json:"size"`
+	ModTime time.Time   `json:"mod_time"`
+	Loader  mods.Loader `json:"loader,omitempty"`
+	Mods    []mods.Mod  `json:"mods"`
+}
+
+// Index holds the entries of every instance. It is safe to search while an
+// update runs; instances are replaced one at a time as they are scanned.
+type Index struct {
+	Options
+	cachePath string
+
+	mu      sync.RWMutex
+	entries map[string][]Entry // by instance
+	jars    map[string]cachedJar
+}
+
+// New returns an empty index that keeps its jar cache in cachePath; an
+// empty cachePath keeps it in memory only.
+func New(cachePath string, opts Options) *Index {
+	x := &Index{
+		Options:   opts,
+		cachePath: cachePath,
+		entries:   make(map[string][]Entry),
+		jars:      make(map[string]cachedJar),
+	}
+	if cachePath != "" {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			// A damaged cache is rebuilt
+			json.Unmarshal(data, &x.jars)
+		}
+	}
+	return x
+}
+
+// ProgressFunc is called after each instance is indexed.
+type ProgressFunc func(done, total int, name string)
+
+// Update indexes the given instances, reusing the metadata of jars that did
+// not change, and drops instances that are no longer listed.
+func (x *Index) Update(ctx context.Context, list []instance.Instance, progress ProgressFunc) error {
+	seen := make(map[string]bool)
+	names := make(map[string]bool)
+	for i, inst := range list {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entries := x.scan(inst, seen)
+		x.mu.Lock()
+		x.entries[inst.Name] = entries
+		x.mu.Unlock()
+		names[inst.Name] = true
+		if progress != nil {
+			progress(i+1, len(list), inst.Name)
+		}
+	}
+
+	x.mu.Lock()
+	for name := range x.entries {
+		if !names[name] {
+			delete(x.entries, name)
+		}
+	}
+	for p := range x.jars {
+		if !seen[p] {
+			delete(x.jars, p)
+		}
+	}
+	x.mu.Unlock()
+	return x.saveCache()
+}
+
+func (x *Index) saveCache() error {
+	if x.cachePath == "" {
+		return nil
+	}
+	x.mu.RLock()
+	data, err := json.Marshal(x.jars)
+	x.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(x.cachePath), "."+filepath.Base(x.cachePath)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write search cache: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), x.cachePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write search cache: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of indexed entries.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	n := 0
+	for _, entries := range x.entries {
+		n += len(entries)
+	}
+	return n
+}
+
+// scan reads the entries of one instance. Paths of the jars it looks at
+// are added to seen.
+func (x *Index) scan(inst instance.Instance, seen map[string]bool) []Entry {
+	entries := []Entry{{Instance: inst.Name, Kind: KindInstance, Name: inst.Name}}
+	add := func(kind Kind, rel, name, detail string) {
+		entries = append(entries, Entry{Instance: inst.Name, Kind: kind, Path: rel, Name: name, Detail: detail})
+	}
+
+	dirEntries, _ := os.ReadDir(filepath.Join(inst.Path, "mods"))
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(strings.TrimSuffix(e.Name(), ".disabled"), ".jar") {
+			continue
+		}
+		p := filepath.Join(inst.Path, "mods", e.Name())
+		seen[p] = true
+		add(KindMod, "mods/"+e.Name(), e.Name(), modsDetail(x.jar(p)))
+	}
+
+	configDir := filepath.Join(inst.Path, "config")
+	filepath.WalkDir(configDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(configDir, p)
+		rel = filepath.ToSlash(rel)
+		add(KindConfig, "config/"+rel, rel, "")
+		if x.ConfigText {
+			entries = append(entries, configLines(inst.Name, p, rel)...)
+		}
+		return nil
+	})
+
+	dirEntries, _ = os.ReadDir(worlds.SavesDir(inst.Path))
+	for _, e := range dirEntries {
+		if !e.IsDir() {
+			continue
+		}
+		name := worlds.LevelName(filepath.Join(worlds.SavesDir(inst.Path), e.Name()))
+		if name == e.Name() {
+			name = ""
+		}
+		add(KindWorld, worlds.SavesDirName+"/"+e.Name(), e.Name(), name)
+	}
+
+	for kind, dir := range map[Kind]string{KindResourcePack: "resourcepacks", KindShaderPack: "shaderpacks"} {
+		dirEntries, _ = os.ReadDir(filepath.Join(inst.Path, dir))
+		for _, e := range dirEntries {
+			if !strings.HasPrefix(e.Name(), ".") {
+				add(kind, dir+"/"+e.Name(), e.Name(), "")
+			}
+		}
+	}
+	return entries
+}
+
+// jar returns the metadata of the jar at p, from the cache if the jar has
+// not changed since it was last read.
+func (x *Index) jar(p string) cachedJar {
+	info, err := os.Stat(p)
+	if err != nil {
+		return cachedJar{}
+	}
+	x.mu.RLock()
+	cached, ok := x.jars[p]
+	x.mu.RUnlock()
+	if ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+		return cached
+	}
+
+	cached = cachedJar{Size: info.Size(), ModTime: info.ModTime()}
+	if jar, err := mods.ReadJar(p); err == nil {
+		cached.Loader, cached.Mods = jar.Loader, jar.Mods
+	}
+	x.mu.Lock()
+	x.jars[p] = cached
+	x.mu.Unlock()
+	return cached
+}
+
+// modsDetail describes the mods of a jar, such as "Sodium (sodium 0.5.8)".
+func modsDetail(jar cachedJar) string {
+	var parts []string
+	for _, m := range jar.Mods {
+		s := strings.TrimSpace(m.ID + " " + m.Version)
+		if m.Name != "" && !strings.EqualFold(m.Name, m.ID) {
+			s = m.Name + " (" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// configLines returns an entry for each non-empty line of a text config
+// file. Large and binary files are skipped.
+func configLines(instanceName, p, rel string) []Entry {
+	info, err := os.Stat(p)
+	if err != nil || info.Size() > maxConfigTextSize {
+		return nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil || strings.ContainsRune(string(data[:min(len(data), 512)]), 0) {
+		return nil
+	}
+	var entries []Entry
+	for i, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, Entry{
+				Instance: instanceName, Kind: KindConfigText, Path: "config/" + rel,
+				Name: line, Detail: rel, Line: i + 1,
+			})
+		}
+	}
+	return entries
+}
+
+// entrySource adapts entries to the fuzzy matcher.
+type entrySource []*Entry
+
+func (s entrySource) String(i int) string { return s[i].text() }
+func (s entrySource) Len() int            { return len(s) }
+
+// Search returns the entries matching query, best first, at most limit of
+// them unless limit is 0. Each word of the query must match: fuzzily for
+// names, as a substring for config text, where fuzzy matches of long lines
+// would mostly be noise.
+func (x *Index) Search(query string, limit int) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	var names, lines []*Entry
+	for _, entries := range x.entries {
+		for i := range entries {
+			if entries[i].Kind == KindConfigText {
+				lines = append(lines, &entries[i])
+			} else {
+				names = append(names, &entries[i])
+			}
+		}
+	}
+	x.mu.RUnlock()
+
+	// Sum the scores of the terms over the entries matching all of them
+	scores := make(map[int]int, len(names))
+	for n, term := range terms {
+		matched := make(map[int]int)
+		for _, m := range fuzzy.FindFromNoSort(term, entrySource(names)) {
+			if score, ok := scores[m.Index]; ok || n == 0 {
+				matched[m.Index] = score + m.Score
+			}
+		}
+		scores = matched
+	}
+
+	var results []Result
+	for i, score := range scores {
+		results = append(results, Result{Entry: *names[i], Score: score})
+	}
+	for _, e := range lines {
+		if containsAll(strings.ToLower(e.Name), terms) {
+			results = append(results, Result{Entry: *e})
+		}
+	}
+
+	rank := make(map[Kind]int, len(Kinds))
+	for i, k := range Kinds {
+		rank[k] = i
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case (a.Kind == KindConfigText) != (b.Kind == KindConfigText):
+			return b.Kind == KindConfigText
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case a.Kind != b.Kind:
+			return rank[a.Kind] < rank[b.Kind]
+		case a.Instance != b.Instance:
+			return a.Instance < b.Instance
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func containsAll(s string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(s, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigFile returns the path of a config or config-text entry relative to
+// the config folder.
+func (e *Entry) ConfigFile() string {
+	return strings.TrimPrefix(e.Path, "config/")
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+)
+
+// writeFiles creates files under dir, with their parent folders.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	home := t.TempDir()
+	list := []instance.Instance{
+		{Name: "skyblock", Path: filepath.Join(home, "skyblock")},
+		{Name: "vanilla", Path: filepath.Join(home, "vanilla")},
+	}
+	writeFiles(t, list[0].Path, map[string]string{
+		"mods/sodium.jar":             "not a zip",
+		"mods/old.jar.disabled":       "not a zip",
+		"config/jei/jei-client.toml":  "# JEI\ncheatMode = false\n\nshowHiddenItems = true\n",
+		"resourcepacks/Faithful.zip":  "",
+		"resourcepacks/.hidden":       "",
+		"shaderpacks/BSL.zip":         "",
+		"saves/World 1/level.dat_old": "",
+	})
+	writeFiles(t, list[1].Path, map[string]string{
+		"config/sodium-options.json": "{}",
+	})
+
+	x := New("", Options{ConfigText: true})
+	var done []string
+	if err := x.Update(context.Background(), list, func(_, _ int, name string) { done = append(done, name) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 {
+		t.Errorf("progress reported %v, want both instances", done)
+	}
+
+	if got := x.Search("sodium", 0); len(got) != 2 {
+		t.Errorf("Search(sodium) = %+v, want the jar and the options file", got)
+	}
+	// Every word must match
+	if got := x.Search("sodium json", 0); len(got) != 1 || got[0].Instance != "vanilla" {
+		t.Errorf("Search(sodium json) = %+v, want only the options file", got)
+	}
+	if got := x.Search("old.jar", 0); len(got) != 1 || got[0].Path != "mods/old.jar.disabled" {
+		t.Errorf("Search(old.jar) = %+v, want the disabled jar", got)
+	}
+	if got := x.Search(".hidden", 0); len(got) != 0 {
+		t.Errorf("Search(.hidden) = %+v, want hidden files skipped", got)
+	}
+
+	// Config text matches as a substring, after every name match
+	got := x.Search("hidden", 0)
+	if len(got) == 0 {
+		t.Fatal("Search(hidden) found nothing")
+	}
+	last := got[len(got)-1]
+	if last.Kind != KindConfigText || last.Line != 4 || last.ConfigFile() != "jei/jei-client.toml" {
+		t.Errorf("last result of Search(hidden) = %+v, want line 4 of jei/jei-client.toml", last)
+	}
+	if got := x.Search("cheatmode false", 1); len(got) != 1 || got[0].Line != 2 {
+		t.Errorf("Search(cheatmode false, 1) = %+v", got)
+	}
+	if got := x.Search("  ", 0); got != nil {
+		t.Errorf("Search of an empty query = %+v", got)
+	}
+
+	// Instances no longer listed are dropped
+	if err := x.Update(context.Background(), list[1:], nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range x.Search("sodium", 0) {
+		if r.Instance != "vanilla" {
+			t.Errorf("removed instance still found: %+v", r)
+		}
+	}
+}
+
+func TestJarCache(t *testing.T) {
+	home := t.TempDir()
+	inst := instance.Instance{Name: "a", Path: filepath.Join(home, "a")}
+	writeFiles(t, inst.Path, map[string]string{"mods/broken.jar": "not a zip"})
+	cache := filepath.Join(home, CacheFileName)
+
+	if err := New(cache, Options{}).Update(context.Background(), []instance.Instance{inst}, nil); err != nil {
+		t.Fatal(err)
+	}
+	x := New(cache, Options{})
+	if _, ok := x.jars[filepath.Join(inst.Path, "mods", "broken.jar")]; !ok {
+		t.Errorf("cache %v does not hold the jar", x.jars)
+	}
+
+	// A damaged cache is ignored and rewritten
+	os.WriteFile(cache, []byte("{"), 0644)
+	x = New(cache, Options{})
+	if len(x.jars) != 0 {
+		t.Errorf("damaged cache loaded %v", x.jars)
+	}
+	if err := x.Update(context.Background(), []instance.Instance{inst}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if x.Len() != 2 {
+		t.Errorf("Len = %d, want the instance and its jar", x.Len())
+	}
+}
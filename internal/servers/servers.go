@@ -0,0 +1,231 @@
+// Package servers edits the multiplayer server list (servers.dat) of an
+// instance.
+package servers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/nbt"
+)
+
+// FileName is the file in an instance that holds its server list.
+const FileName = "servers.dat"
+
+// Resource pack policies of a server entry, from its acceptTextures tag.
+const (
+	ResourcePacksPrompt   = "prompt"
+	ResourcePacksEnabled  = "enabled"
+	ResourcePacksDisabled = "disabled"
+)
+
+// Server is one entry of the multiplayer server list.
+type Server struct {
+	Name          string `json:"name" yaml:"name"`
+	Address       string `json:"address" yaml:"address"`
+	Hidden        bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	ResourcePacks string `json:"resource_packs" yaml:"resource_packs"`
+	HasIcon       bool   `json:"has_icon,omitempty" yaml:"has_icon,omitempty"`
+}
+
+// List is the server list of one servers.dat. Entries keep tags this
+// package does not know about, such as the cached server icon, so that
+// saving writes them back unchanged.
+type List struct {
+	file    *nbt.File
+	servers *nbt.List
+}
+
+// Path returns the servers.dat of the instance at instancePath.
+func Path(instancePath string) string {
+	return filepath.Join(instancePath, FileName)
+}
+
+// Load reads the server list at path. A missing file is an empty list,
+// like the game treats it.
+func Load(path string) (*List, error) {
+	f, err := nbt.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &List{
+			file:    &nbt.File{Root: &nbt.Compound{}, Compression: nbt.Uncompressed},
+			servers: &nbt.List{Elem: nbt.TagCompound},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FileName, err)
+	}
+	root, err := f.RootCompound()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", FileName, err)
+	}
+
+	l := &List{file: f, servers: &nbt.List{Elem: nbt.TagCompound}}
+	if v, ok := root.Get("servers"); ok {
+		list, ok := v.(*nbt.List)
+		if !ok || (len(list.Items) > 0 && list.Elem != nbt.TagCompound) {
+			return nil, fmt.Errorf("%s: servers is not a list of compounds", FileName)
+		}
+		l.servers = list
+	}
+	return l, nil
+}
+
+// Save writes the list to path.
+func (l *List) Save(path string) error {
+	root, err := l.file.RootCompound()
+	if err != nil {
+		return err
+	}
+	root.Set("servers", l.servers)
+	if err := nbt.WriteFile(path, l.file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", FileName, err)
+	}
+	return nil
+}
+
+func entry(tag nbt.Tag) Server {
+	c, _ := tag.(*nbt.Compound)
+	if c == nil {
+		return Server{}
+	}
+	s := Server{ResourcePacks: ResourcePacksPrompt}
+	s.Name, _ = c.String("name")
+	s.Address, _ = c.String("ip")
+	if hidden, ok := c.Int64("hidden"); ok {
+		s.Hidden = hidden != 0
+	}
+	if accept, ok := c.Int64("acceptTextures"); ok {
+		s.ResourcePacks = ResourcePacksDisabled
+		if accept != 0 {
+			s.ResourcePacks = ResourcePacksEnabled
+		}
+	}
+	icon, _ := c.String("icon")
+	s.HasIcon = icon != ""
+	return s
+}
+
+// Servers returns the entries in the order the game shows them.
+func (l *List) Servers() []Server {
+	out := make([]Server, 0, len(l.servers.Items))
+	for _, tag := range l.servers.Items {
+		out = append(out, entry(tag))
+	}
+	return out
+}
+
+// find returns the indexes of the entries whose address or name is key.
+func (l *List) find(key string) []int {
+	var byAddress, byName []int
+	for i, tag := range l.servers.Items {
+		s := entry(tag)
+		if strings.EqualFold(s.Address, key) {
+			byAddress = append(byAddress, i)
+		} else if s.Name == key {
+			byName = append(byName, i)
+		}
+	}
+	if len(byAddress) > 0 {
+		return byAddress
+	}
+	return byName
+}
+
+func (l *List) hasAddress(address string) bool {
+	for _, s := range l.Servers() {
+		if strings.EqualFold(s.Address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a server. Addresses must be unique.
+func (l *List) Add(s Server) error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("server name cannot be empty")
+	}
+	if strings.TrimSpace(s.Address) == "" || strings.ContainsAny(s.Address, " \t/") {
+		return fmt.Errorf("invalid server address %q", s.Address)
+	}
+	if l.hasAddress(s.Address) {
+		return fmt.Errorf("server %s is already listed", s.Address)
+	}
+
+	c := &nbt.Compound{}
+	c.Set("name", nbt.String(s.Name))
+	c.Set("ip", nbt.String(s.Address))
+	switch s.ResourcePacks {
+	case ResourcePacksEnabled:
+		c.Set("acceptTextures", nbt.Byte(1))
+	case ResourcePacksDisabled:
+		c.Set("acceptTextures", nbt.Byte(0))
+	case "", ResourcePacksPrompt:
+	default:
+		return fmt.Errorf("invalid resource pack policy %q", s.ResourcePacks)
+	}
+	if s.Hidden {
+		c.Set("hidden", nbt.Byte(1))
+	}
+	l.servers.Elem = nbt.TagCompound
+	l.servers.Items = append(l.servers.Items, c)
+	return nil
+}
+
+// Remove deletes the server whose address or name is key and returns it.
+func (l *List) Remove(key string) (Server, error) {
+	matches := l.find(key)
+	switch len(matches) {
+	case 0:
+		return Server{}, fmt.Errorf("no server named or at '%s'", key)
+	case 1:
+	default:
+		return Server{}, fmt.Errorf("'%s' matches %d servers; use the address", key, len(matches))
+	}
+	i := matches[0]
+	removed := entry(l.servers.Items[i])
+	l.servers.Items = append(l.servers.Items[:i], l.servers.Items[i+1:]...)
+	return removed, nil
+}
+
+// Sync makes l list the servers of canonical, in its order. With keepExtra,
+// servers only l has stay at the end of the list; otherwise they are
+// dropped. It reports whether l changed.
+func (l *List) Sync(canonical *List, keepExtra bool) bool {
+	before := l.servers.Items
+
+	items := append([]nbt.Tag(nil), canonical.servers.Items...)
+	if keepExtra {
+		for _, tag := range l.servers.Items {
+			s := entry(tag)
+			if !canonical.hasAddress(s.Address) {
+				items = append(items, tag)
+			}
+		}
+	}
+	l.servers = &nbt.List{Elem: nbt.TagCompound, Items: items}
+
+	// Compare whole entries, so that a new icon or any other tag this
+	// package does not read counts as a change too
+	if len(before) != len(items) {
+		return true
+	}
+	for i := range before {
+		if !sameTag(before[i], items[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTag reports whether a and b encode to the same bytes.
+func sameTag(a, b nbt.Tag) bool {
+	var bufA, bufB bytes.Buffer
+	if nbt.Encode(&bufA, "", a) != nil || nbt.Encode(&bufB, "", b) != nil {
+		return false
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
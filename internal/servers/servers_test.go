@@ -0,0 +1,208 @@
+package servers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/nbt"
+)
+
+// entryTag builds a servers.dat entry as the game writes it.
+func entryTag(name, ip, icon string) *nbt.Compound {
+	c := &nbt.Compound{}
+	c.Set("name", nbt.String(name))
+	c.Set("ip", nbt.String(ip))
+	if icon != "" {
+		c.Set("icon", nbt.String(icon))
+	}
+	return c
+}
+
+// writeServers writes a servers.dat holding entries to path.
+func writeServers(t *testing.T, path string, entries ...*nbt.Compound) {
+	t.Helper()
+	list := &nbt.List{Elem: nbt.TagCompound}
+	for _, e := range entries {
+		list.Items = append(list.Items, e)
+	}
+	root := &nbt.Compound{}
+	root.Set("servers", list)
+	if err := nbt.WriteFile(path, &nbt.File{Root: root, Compression: nbt.Uncompressed}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func load(t *testing.T, path string) *List {
+	t.Helper()
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func addresses(l *List) []string {
+	var out []string
+	for _, s := range l.Servers() {
+		out = append(out, s.Address)
+	}
+	return out
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if l := load(t, filepath.Join(dir, "missing.dat")); len(l.Servers()) != 0 {
+		t.Errorf("missing file lists %v", l.Servers())
+	}
+
+	path := filepath.Join(dir, FileName)
+	accepting := entryTag("Hypixel", "mc.hypixel.net", "iVBORw0KGgo=")
+	accepting.Set("acceptTextures", nbt.Byte(1))
+	accepting.Set("hidden", nbt.Byte(1))
+	refusing := entryTag("Local", "localhost:25566", "")
+	refusing.Set("acceptTextures", nbt.Byte(0))
+	writeServers(t, path, accepting, refusing, entryTag("Plain", "play.example.org", ""))
+
+	want := []Server{
+		{Name: "Hypixel", Address: "mc.hypixel.net", Hidden: true, ResourcePacks: ResourcePacksEnabled, HasIcon: true},
+		{Name: "Local", Address: "localhost:25566", ResourcePacks: ResourcePacksDisabled},
+		{Name: "Plain", Address: "play.example.org", ResourcePacks: ResourcePacksPrompt},
+	}
+	if got := load(t, path).Servers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Servers = %+v, want %+v", got, want)
+	}
+
+	root := &nbt.Compound{}
+	root.Set("servers", &nbt.List{Elem: nbt.TagString, Items: []nbt.Tag{nbt.String("x")}})
+	nbt.WriteFile(path, &nbt.File{Root: root})
+	if _, err := Load(path); err == nil {
+		t.Error("Load of a list of strings succeeded")
+	}
+	os.WriteFile(path, []byte("garbage"), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load of garbage succeeded")
+	}
+}
+
+func TestAddAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeServers(t, path, entryTag("Hypixel", "mc.hypixel.net", "iVBORw0KGgo="))
+	l := load(t, path)
+
+	for _, s := range []Server{
+		{Name: "", Address: "a.example.org"},
+		{Name: "Spaces", Address: "a example.org"},
+		{Name: "Path", Address: "a.example.org/x"},
+		{Name: "Duplicate", Address: "MC.Hypixel.net"},
+		{Name: "Policy", Address: "b.example.org", ResourcePacks: "always"},
+	} {
+		if err := l.Add(s); err == nil {
+			t.Errorf("Add(%+v) succeeded", s)
+		}
+	}
+	if err := l.Add(Server{Name: "Mine", Address: "mine.example.org", ResourcePacks: ResourcePacksDisabled}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := load(t, path).Servers()
+	if len(saved) != 2 || !saved[0].HasIcon || saved[1] != (Server{Name: "Mine", Address: "mine.example.org", ResourcePacks: ResourcePacksDisabled}) {
+		t.Errorf("saved list = %+v, want the icon kept and the new server last", saved)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeServers(t, path,
+		entryTag("Survival", "a.example.org", ""),
+		entryTag("Survival", "b.example.org", ""),
+		entryTag("b.example.org", "c.example.org", ""), // a name that is another's address
+		entryTag("Creative", "d.example.org", ""),
+	)
+	l := load(t, path)
+
+	if _, err := l.Remove("Survival"); err == nil {
+		t.Error("removing an ambiguous name succeeded")
+	}
+	if _, err := l.Remove("nowhere.example.org"); err == nil {
+		t.Error("removing an unknown server succeeded")
+	}
+	if got := addresses(l); len(got) != 4 {
+		t.Fatalf("failed removals changed the list to %v", got)
+	}
+
+	// An address wins over a name
+	if s, err := l.Remove("B.EXAMPLE.ORG"); err != nil || s.Address != "b.example.org" {
+		t.Errorf("Remove(B.EXAMPLE.ORG) = %+v, %v", s, err)
+	}
+	if s, err := l.Remove("Creative"); err != nil || s.Address != "d.example.org" {
+		t.Errorf("Remove(Creative) = %+v, %v", s, err)
+	}
+	// With one left, the name is no longer ambiguous
+	if s, err := l.Remove("Survival"); err != nil || s.Address != "a.example.org" {
+		t.Errorf("Remove(Survival) = %+v, %v", s, err)
+	}
+	if got := addresses(l); !reflect.DeepEqual(got, []string{"c.example.org"}) {
+		t.Errorf("left = %v", got)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	canonicalPath := filepath.Join(dir, "canonical.dat")
+	writeServers(t, canonicalPath,
+		entryTag("A", "a.example.org", "icon-a"),
+		entryTag("B", "b.example.org", ""),
+	)
+	canonical := load(t, canonicalPath)
+
+	tests := []struct {
+		name      string
+		target    []*nbt.Compound
+		keepExtra bool
+		want      []string
+		changed   bool
+	}{
+		{"same list", []*nbt.Compound{entryTag("A", "a.example.org", "icon-a"), entryTag("B", "b.example.org", "")},
+			false, []string{"a.example.org", "b.example.org"}, false},
+		{"other order", []*nbt.Compound{entryTag("B", "b.example.org", ""), entryTag("A", "a.example.org", "icon-a")},
+			false, []string{"a.example.org", "b.example.org"}, true},
+		{"only the icon differs", []*nbt.Compound{entryTag("A", "a.example.org", "old-icon"), entryTag("B", "b.example.org", "")},
+			false, []string{"a.example.org", "b.example.org"}, true},
+		{"extra dropped", []*nbt.Compound{entryTag("X", "x.example.org", ""), entryTag("A", "a.example.org", "icon-a")},
+			false, []string{"a.example.org", "b.example.org"}, true},
+		{"extra kept at the end", []*nbt.Compound{entryTag("X", "x.example.org", ""), entryTag("A", "a.example.org", "icon-a")},
+			true, []string{"a.example.org", "b.example.org", "x.example.org"}, true},
+		{"nothing to add", []*nbt.Compound{entryTag("A", "a.example.org", "icon-a"), entryTag("B", "b.example.org", ""), entryTag("X", "x.example.org", "")},
+			true, []string{"a.example.org", "b.example.org", "x.example.org"}, false},
+		{"empty target", nil, true, []string{"a.example.org", "b.example.org"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			writeServers(t, path, tt.target...)
+			l := load(t, path)
+
+			if changed := l.Sync(canonical, tt.keepExtra); changed != tt.changed {
+				t.Errorf("Sync reported changed = %v, want %v", changed, tt.changed)
+			}
+			if err := l.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			saved := load(t, path)
+			if got := addresses(saved); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("synced list = %v, want %v", got, tt.want)
+			}
+			if !saved.Servers()[0].HasIcon {
+				t.Error("the canonical icon was not copied")
+			}
+		})
+	}
+	if got := addresses(canonical); len(got) != 2 {
+		t.Errorf("Sync changed the canonical list to %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// configFileItem is a file or folder of the configs panel's tree.
+type configFileItem struct {
+	fileItem
+	File     instance.ConfigFile
+	Expanded bool
+	Flat     bool // shown with its whole path, as while filtering
+}
+
+func (c configFileItem) FilterValue() string { return c.File.Path }
+
+func (c configFileItem) prefix() string {
+	if c.Flat {
+		return ""
+	}
+	prefix := strings.Repeat("  ", c.File.Depth())
+	if c.File.IsDir {
+		if c.Expanded {
+			return prefix + "▾ "
+		}
+		return prefix + "▸ "
+	}
+	return prefix
+}
+
+func (c configFileItem) Title() string {
+	prefix := c.prefix()
+	f := c.fileItem
+	f.MaxWidth -= len([]rune(prefix))
+	return prefix + f.Title()
+}
+
+func (c configFileItem) Description() string {
+	return strings.Repeat(" ", len([]rune(c.prefix()))) +
+		formatBytes(c.File.Size) + " • " + c.File.ModTime.Local().Format("2006-01-02 15:04")
+}
+
+// loadConfigTree fills the configs panel from the instance info. Folders
+// are collapsed until opened; flat lists every file and folder with its
+// path, so the filter finds files in collapsed folders too.
+func (m *model) loadConfigTree(flat bool) {
+	if m.instanceInfo == nil {
+		return
+	}
+	if m.configExpanded == nil {
+		m.configExpanded = make(map[string]bool)
+	}
+	itemMaxWidth := detailPanelWidth(m.terminalWidth) - 4
+
+	// A folder's contents show if it and all folders above it are open
+	open := map[string]bool{".": true}
+	var items []list.Item
+	for _, f := range m.instanceInfo.ConfigFiles {
+		if !flat && !open[path.Dir(f.Path)] {
+			continue
+		}
+		name := f.Name()
+		if flat {
+			name = f.Path
+		}
+		expanded := m.configExpanded[f.Path]
+		if f.IsDir && expanded {
+			open[f.Path] = true
+		}
+		items = append(items, configFileItem{
+			fileItem: fileItem{Name: name, MaxWidth: itemMaxWidth, ScrollOffset: m.scrollOffset},
+			File:     f,
+			Expanded: expanded,
+			Flat:     flat,
+		})
+	}
+	m.configsList.SetItems(items)
+}
+
+// selectedConfigFile returns the file or folder selected in the configs
+// panel.
+func (m model) selectedConfigFile() *instance.ConfigFile {
+	if item, ok := m.configsList.SelectedItem().(configFileItem); ok {
+		return &item.File
+	}
+	return nil
+}
+
+// toggleConfigFolder opens or closes the selected folder of the tree.
+func (m *model) toggleConfigFolder() {
+	f := m.selectedConfigFile()
+	if f == nil || !f.IsDir || m.configsList.FilterState() != list.Unfiltered {
+		return
+	}
+	m.configExpanded[f.Path] = !m.configExpanded[f.Path]
+	m.loadConfigTree(false)
+}
+
+// markConfigSelection moves the scroll offset and selection marker of the
+// configs panel to the selected item.
+func (m *model) markConfigSelection() {
+	if m.configsList.FilterState() != list.Unfiltered {
+		// Indexes refer to the filtered items then
+		return
+	}
+	items := m.configsList.Items()
+	for i, item := range items {
+		if c, ok := item.(configFileItem); ok {
+			c.ScrollOffset = m.scrollOffset
+			c.IsSelected = i == m.configsList.Index()
+			items[i] = c
+		}
+	}
+	m.configsList.SetItems(items)
+}
+
+// updateConfigFilter passes keys to the configs panel's filter input and
+// folds the tree up again when the filter is cancelled.
+func (m model) updateConfigFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.configsList, cmd = m.configsList.Update(msg)
+	if m.configsList.FilterState() == list.Unfiltered {
+		m.loadConfigTree(false)
+	}
+	return m, cmd
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// contextItem is a context in the switcher opened from the config screen.
+type contextItem struct {
+	instance.Context
+}
+
+func (c contextItem) FilterValue() string { return c.Name }
+
+func (c contextItem) Title() string {
+	if c.Current {
+		return c.Name + " (current)"
+	}
+	return c.Name
+}
+
+func (c contextItem) Description() string {
+	return c.MinecraftPath + " → " + c.InstancesPath
+}
+
+// listTitle names the active context unless it is the default one, and
+// the sort order when it is not the default one.
+func listTitle(manager *instance.Manager, recentFirst bool) string {
+	title := "Minecraft Instance Manager"
+	if manager != nil && manager.Context != instance.DefaultContextName {
+		title += " [" + manager.Context + "]"
+	}
+	if recentFirst {
+		title += " (recent first)"
+	}
+	return title
+}
+
+func (m model) openContextSwitcher() (tea.Model, tea.Cmd) {
+	contexts := m.manager.ListContexts()
+	items := make([]list.Item, len(contexts))
+	selected := 0
+	for i, c := range contexts {
+		items[i] = contextItem{c}
+		if c.Current {
+			selected = i
+		}
+	}
+	m.contextList.SetItems(items)
+	m.contextList.Select(selected)
+	m.state = stateContexts
+	return m, nil
+}
+
+func (m model) updateContexts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.state = stateConfig
+		return m, nil
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Enter):
+		item, ok := m.contextList.SelectedItem().(contextItem)
+		if !ok {
+			return m, nil
+		}
+		return m.useContext(item.Name)
+	}
+
+	var cmd tea.Cmd
+	m.contextList, cmd = m.contextList.Update(msg)
+	return m, cmd
+}
+
+// useContext makes name the current context and rebuilds the manager and
+// the filesystem watcher for its paths.
+func (m model) useContext(name string) (tea.Model, tea.Cmd) {
+	m.state = stateConfig
+	if err := m.manager.UseContext(name); err != nil {
+		m.err = err
+		return m, nil
+	}
+	opts := m.opts
+	opts.Context = name
+	manager, err := instance.NewManagerWithOptions(opts)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.opts = opts
+	cmd := m.setManager(manager)
+
+	m.lastTrashed = nil
+	m.err = nil
+	m.message = fmt.Sprintf("Switched to context: %s", name)
+	m.configList.SetItems(m.buildConfigListItems())
+	return m, tea.Batch(refreshInstances, cmd)
+}
+
+// setManager replaces the manager and rebuilds the filesystem watcher for
+// its paths.
+func (m *model) setManager(manager *instance.Manager) tea.Cmd {
+	m.manager = manager
+	m.list.Title = listTitle(manager, m.sortByRecent)
+	if m.watcher != nil {
+		m.watcher.Close()
+		m.watcher = nil
+	}
+	if fw, err := newFSWatcher(manager); err == nil {
+		m.watcher = fw
+		return fw.wait()
+	}
+	return nil
+}
+
+func (m model) viewContexts() string {
+	var content strings.Builder
+
+	if m.err != nil {
+		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		content.WriteString("\n\n")
+	}
+
+	content.WriteString(m.contextList.View())
+	content.WriteString("\n\n")
+	content.WriteString(dimStyle.Render("Enter to use context • ESC to go back • create contexts with 'context create'"))
+	return content.String()
+}
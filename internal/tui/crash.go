@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/crash"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// crashFramesShown is the number of stack frames shown per exception.
+const crashFramesShown = 8
+
+// openCrash explains the newest crash report of inst and marks it seen,
+// which clears the instance's crash badge.
+func (m model) openCrash(inst instance.Instance) (tea.Model, tea.Cmd) {
+	newest, err := crash.Newest(inst.Path)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	if newest == nil {
+		m.err = nil
+		m.message = fmt.Sprintf("Instance '%s' has no crash reports", inst.Name)
+		return m, nil
+	}
+	report, err := crash.Parse(*newest)
+	if err == nil {
+		m.crashReport, err = crash.Analyze(report, filepath.Join(inst.Path, "mods"))
+	}
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.err = crash.LoadSeen(filepath.Join(m.manager.AppDir, crash.SeenFileName)).Mark(inst.Path, *newest)
+	m.message = ""
+	m.selectedInstance = &inst
+	m.crashView = viewport.New(m.terminalWidth, m.crashViewHeight())
+	m.crashView.SetContent(m.renderCrash())
+	m.state = stateCrash
+	return m, refreshInstances
+}
+
+func (m model) crashViewHeight() int {
+	return max(m.terminalHeight-5, 3) // title, status and instructions
+}
+
+// renderCrash lays out the open crash report for the viewport.
+func (m model) renderCrash() string {
+	a := m.crashReport
+	if a == nil {
+		return ""
+	}
+	wrap := lipgloss.NewStyle().Width(max(m.terminalWidth-2, 20))
+	var content strings.Builder
+	section := func(title string) {
+		content.WriteString("\n")
+		content.WriteString(subtitleStyle.Render(title))
+		content.WriteString("\n")
+	}
+
+	content.WriteString(wrap.Render(a.Description))
+	content.WriteString("\n")
+	if a.Signal != "" {
+		content.WriteString(dimStyle.Render(wrap.Render(a.Signal)))
+		content.WriteString("\n")
+	}
+	if a.ProblematicFrame != "" {
+		content.WriteString(dimStyle.Render(wrap.Render("in " + a.ProblematicFrame)))
+		content.WriteString("\n")
+	}
+
+	section("Diagnosis:")
+	for _, d := range a.Diagnosis {
+		content.WriteString(wrap.Render("• " + d))
+		content.WriteString("\n")
+	}
+
+	if len(a.Culprits) > 0 {
+		section("Installed mods in the stack trace:")
+		for _, c := range a.Culprits {
+			line := fmt.Sprintf("• %s [%s]: %d frames", c.Label(), c.Jar, c.Frames)
+			if c.Frames == 1 {
+				line = strings.TrimSuffix(line, "s")
+			}
+			if c.Suspected {
+				line += ", suspected by the game"
+			}
+			content.WriteString(wrap.Render(line))
+			content.WriteString("\n")
+		}
+	}
+
+	if len(a.SuspectedMods) > 0 {
+		section("Suspected mods:")
+		for _, s := range a.SuspectedMods {
+			content.WriteString(fmt.Sprintf("• %s (%s) %s\n", s.Name, s.ID, s.Version))
+		}
+	}
+
+	if len(a.Exceptions) > 0 {
+		section("Exception:")
+		for i, e := range a.Exceptions {
+			text := e.String()
+			if i > 0 {
+				text = "Caused by: " + text
+			}
+			content.WriteString(errorStyle.Render(wrap.Render(text)))
+			content.WriteString("\n")
+			m.renderFrames(&content, e.Frames)
+		}
+	} else if len(a.Frames) > 0 {
+		section("Java frames:")
+		m.renderFrames(&content, a.Frames)
+	}
+	return content.String()
+}
+
+func (m model) renderFrames(content *strings.Builder, frames []string) {
+	for _, f := range frames[:min(len(frames), crashFramesShown)] {
+		content.WriteString(dimStyle.Render("    at " + f))
+		content.WriteString("\n")
+	}
+	if len(frames) > crashFramesShown {
+		content.WriteString(dimStyle.Render(fmt.Sprintf("    ... %d more", len(frames)-crashFramesShown)))
+		content.WriteString("\n")
+	}
+}
+
+func (m model) updateCrash(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.crashReport = nil
+		m.err = nil
+		m.state = stateList
+		return m, nil
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+	case msg.String() == "g":
+		m.crashView.GotoTop()
+		return m, nil
+	case msg.String() == "G":
+		m.crashView.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.crashView, cmd = m.crashView.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewCrash() string {
+	var content strings.Builder
+
+	a := m.crashReport
+	content.WriteString(titleStyle.Render(fmt.Sprintf("%s: %s", m.selectedInstance.Name, a.Name)))
+	content.WriteString("\n")
+	if m.err != nil {
+		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	} else {
+		status := []string{a.ModTime.Local().Format("2006-01-02 15:04")}
+		if a.MinecraftVersion != "" {
+			status = append(status, "Minecraft "+a.MinecraftVersion)
+		}
+		if len(a.Mods) > 0 {
+			status = append(status, fmt.Sprintf("%d mods loaded", len(a.Mods)))
+		}
+		content.WriteString(dimStyle.Render(strings.Join(status, " • ")))
+	}
+	content.WriteString("\n")
+
+	content.WriteString(m.crashView.View())
+	content.WriteString("\n")
+	content.WriteString(dimStyle.Render("↑/↓ to scroll • g/G top/bottom • ESC to go back"))
+	return content.String()
+}
@@ -0,0 +1,310 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/logs"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// logViewMaxLines is the number of lines the log view keeps; older ones
+// are dropped while following.
+const logViewMaxLines = 5000
+
+// logLevels are the minimum levels the log view cycles through.
+var logLevels = []logs.Level{logs.LevelTrace, logs.LevelInfo, logs.LevelWarn, logs.LevelError}
+
+var logLevelStyles = map[logs.Level]lipgloss.Style{
+	logs.LevelTrace: dimStyle,
+	logs.LevelDebug: dimStyle,
+	logs.LevelInfo:  lipgloss.NewStyle(),
+	logs.LevelWarn:  lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB000")),
+	logs.LevelError: lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")),
+	logs.LevelFatal: errorStyle,
+}
+
+// logFileItem is one entry of the logs panel.
+type logFileItem struct {
+	logs.File
+}
+
+func (l logFileItem) FilterValue() string { return l.Name }
+func (l logFileItem) Title() string       { return l.Name }
+func (l logFileItem) Description() string {
+	return formatBytes(l.Size) + " • " + l.ModTime.Local().Format("2006-01-02 15:04")
+}
+
+// loadLogFiles fills the logs panel from the selected instance.
+func (m *model) loadLogFiles() {
+	m.logsList.SetItems(nil)
+	if m.selectedInstance == nil {
+		return
+	}
+	files, err := logs.Files(m.selectedInstance.Path)
+	if err != nil {
+		m.err = err
+		return
+	}
+	items := make([]list.Item, len(files))
+	for i, f := range files {
+		items[i] = logFileItem{f}
+	}
+	m.logsList.SetItems(items)
+}
+
+// logFollower tails a log in the background. Like an indexer, its messages
+// name it so ones from a stopped follower are dropped.
+type logFollower struct {
+	cancel  context.CancelFunc
+	updates chan tea.Msg
+}
+
+type logLinesMsg struct {
+	f     *logFollower
+	lines []logs.Line
+}
+
+type logFollowDoneMsg struct {
+	f   *logFollower
+	err error
+}
+
+// wait returns a command that blocks until the follower's next message.
+func (f *logFollower) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-f.updates
+	}
+}
+
+// openLog shows the log selected in the logs panel. latest.log is followed
+// right away, as it grows while the game runs.
+func (m model) openLog() (tea.Model, tea.Cmd) {
+	item, ok := m.logsList.SelectedItem().(logFileItem)
+	if !ok {
+		return m, nil
+	}
+	m.logFile = item.File
+	m.logLevel = logs.LevelTrace
+	m.logGrep = nil
+	m.err = nil
+	m.logView = viewport.New(m.terminalWidth, m.logViewHeight())
+	m.state = stateLogView
+	return m, m.loadLog(!item.Compressed)
+}
+
+func (m model) logViewHeight() int {
+	return max(m.terminalHeight-5, 3) // title, status and instructions
+}
+
+// loadLog reads the open log with the current filter, and follows it if
+// follow is set. A running follower is stopped first.
+func (m *model) loadLog(follow bool) tea.Cmd {
+	m.stopFollowingLog()
+	reader := logs.NewReader(m.logFile.Path, logs.Filter{MinLevel: m.logLevel, Grep: m.logGrep})
+	lines, err := reader.Read(logViewMaxLines)
+	if err != nil {
+		m.err = err
+		m.logLines = nil
+		m.renderLog(true)
+		return nil
+	}
+	m.logLines = lines
+	m.renderLog(true)
+	if !follow {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &logFollower{cancel: cancel, updates: make(chan tea.Msg, 16)}
+	m.logFollower = f
+	go func() {
+		err := reader.Follow(ctx, func(lines []logs.Line) {
+			select {
+			case f.updates <- logLinesMsg{f, lines}:
+			case <-ctx.Done():
+			}
+		})
+		select {
+		case f.updates <- logFollowDoneMsg{f, err}:
+		case <-ctx.Done():
+		}
+	}()
+	return f.wait()
+}
+
+// stopFollowingLog stops tailing the open log, if it is being followed.
+func (m *model) stopFollowingLog() {
+	if m.logFollower != nil {
+		m.logFollower.cancel()
+		m.logFollower = nil
+	}
+}
+
+// renderLog puts the log lines into the viewport, scrolled to the bottom
+// if toBottom is set.
+func (m *model) renderLog(toBottom bool) {
+	var content strings.Builder
+	for i, l := range m.logLines {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(logLevelStyles[l.Level].Render(l.Text))
+	}
+	m.logView.SetContent(content.String())
+	if toBottom {
+		m.logView.GotoBottom()
+	}
+}
+
+// updateLogFollow handles the messages of the log follower.
+func (m model) updateLogFollow(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case logLinesMsg:
+		if msg.f != m.logFollower {
+			return m, nil
+		}
+		atBottom := m.logView.AtBottom()
+		m.logLines = append(m.logLines, msg.lines...)
+		if len(m.logLines) > logViewMaxLines {
+			m.logLines = m.logLines[len(m.logLines)-logViewMaxLines:]
+		}
+		// Stay at the end unless scrolled up to read something
+		m.renderLog(atBottom)
+		return m, msg.f.wait()
+	case logFollowDoneMsg:
+		if msg.f != m.logFollower {
+			return m, nil
+		}
+		m.logFollower = nil
+		m.err = msg.err
+	}
+	return m, nil
+}
+
+func (m model) updateLogView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.stopFollowingLog()
+		m.logLines = nil
+		m.err = nil
+		m.state = stateDetailPanel
+		m.loadLogFiles()
+		return m, nil
+	case key.Matches(msg, m.keys.Quit):
+		m.stopFollowingLog()
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.LogFollow):
+		if m.logFollower != nil {
+			m.stopFollowingLog()
+			return m, nil
+		}
+		if m.logFile.Compressed {
+			m.err = fmt.Errorf("%s is an archived log and does not change", m.logFile.Name)
+			return m, nil
+		}
+		m.err = nil
+		return m, m.loadLog(true)
+	case key.Matches(msg, m.keys.LogLevel):
+		next := 0
+		for i, l := range logLevels {
+			if l == m.logLevel {
+				next = (i + 1) % len(logLevels)
+			}
+		}
+		m.logLevel = logLevels[next]
+		return m, m.loadLog(m.logFollower != nil)
+	case key.Matches(msg, m.keys.Find):
+		m.textInput.SetValue("")
+		if m.logGrep != nil {
+			m.textInput.SetValue(strings.TrimPrefix(m.logGrep.String(), "(?i)"))
+		}
+		m.textInput.Placeholder = "Regular expression..."
+		m.textInput.CursorEnd()
+		m.textInput.Focus()
+		m.editError = nil
+		m.state = stateLogGrep
+		return m, nil
+	case msg.String() == "g":
+		m.logView.GotoTop()
+		return m, nil
+	case msg.String() == "G":
+		m.logView.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateLogGrep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.textInput.Blur()
+		m.editError = nil
+		m.state = stateLogView
+		return m, nil
+	case "enter":
+		pattern := strings.TrimSpace(m.textInput.Value())
+		m.logGrep = nil
+		if pattern != "" {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				m.editError = err
+				return m, nil
+			}
+			m.logGrep = re
+		}
+		m.textInput.Blur()
+		m.editError = nil
+		m.state = stateLogView
+		return m, m.loadLog(m.logFollower != nil)
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewLog() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render(fmt.Sprintf("%s: %s", m.selectedInstance.Name, m.logFile.Name)))
+	content.WriteString("\n")
+
+	status := []string{fmt.Sprintf("%d lines", len(m.logLines))}
+	if m.logLevel > logs.LevelTrace {
+		status = append(status, m.logLevel.String()+" and above")
+	}
+	if m.logGrep != nil {
+		status = append(status, "matching "+strings.TrimPrefix(m.logGrep.String(), "(?i)"))
+	}
+	if m.logFollower != nil {
+		status = append(status, "following")
+	}
+	if m.err != nil {
+		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	} else {
+		content.WriteString(dimStyle.Render(strings.Join(status, " • ")))
+	}
+	content.WriteString("\n")
+
+	content.WriteString(m.logView.View())
+	content.WriteString("\n")
+	if m.state == stateLogGrep {
+		content.WriteString(m.textInput.View())
+		if m.editError != nil {
+			content.WriteString("  " + errorStyle.Render("✗ "+m.editError.Error()))
+		}
+		return content.String()
+	}
+	content.WriteString(dimStyle.Render("↑/↓ to scroll • g/G top/bottom • 'f' to follow • 'l' to change level • / to grep • ESC to go back"))
+	return content.String()
+}
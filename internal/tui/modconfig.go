@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/modconfig"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// modConfigItem is one key or section of the mod config being edited.
+type modConfigItem struct {
+	*modconfig.Entry
+}
+
+func (e modConfigItem) FilterValue() string { return e.Name() + " " + e.Value }
+func (e modConfigItem) Title() string {
+	indent := strings.Repeat("  ", e.Depth())
+	if e.Type == modconfig.TypeSection {
+		return indent + "▾ " + e.Key()
+	}
+	return indent + e.Key() + " = " + e.Value
+}
+func (e modConfigItem) Description() string {
+	parts := []string{string(e.Type)}
+	if c := e.Constraint(); c != "" {
+		parts = append(parts, c)
+	}
+	if e.Type != modconfig.TypeSection && !e.Editable() {
+		parts = append(parts, "read-only")
+	}
+	if line := commentSummary(e.Comment); line != "" {
+		parts = append(parts, line)
+	}
+	return strings.Repeat("  ", e.Depth()) + strings.Join(parts, " • ")
+}
+
+// commentSummary returns the first line of a comment that is not a range
+// or allowed values note, which the description shows already.
+func commentSummary(comment string) string {
+	for _, line := range strings.Split(comment, "\n") {
+		lower := strings.ToLower(strings.TrimLeft(line, "["))
+		if line != "" && !strings.HasPrefix(lower, "range:") && !strings.HasPrefix(lower, "allowed values:") {
+			return line
+		}
+	}
+	return ""
+}
+
+// editorClosedMsg is sent when $EDITOR exits.
+type editorClosedMsg struct{ err error }
+
+// configFilePath returns the path of a file in the selected instance's
+// config folder; name may be a slash-separated path below it.
+func (m model) configFilePath(name string) string {
+	return filepath.Join(m.manager.InstancesPath, m.selectedInstance.Name, "config", filepath.FromSlash(name))
+}
+
+// openModConfig parses a config file and shows its keys. A file that does
+// not parse still opens, showing the error, so it can be fixed in $EDITOR.
+func (m model) openModConfig(name string) (tea.Model, tea.Cmd) {
+	m.modConfigFile = name
+	m.message = ""
+	m.loadModConfig()
+	m.modConfigList.ResetFilter()
+	m.modConfigList.Select(0)
+	m.state = stateModConfig
+	return m, nil
+}
+
+// loadModConfig (re)reads the open config file, keeping the selection.
+func (m *model) loadModConfig() {
+	m.modConfigList.Title = m.modConfigFile
+	doc, err := modconfig.ReadFile(m.configFilePath(m.modConfigFile))
+	m.modConfig = doc
+	m.err = err
+	if err != nil {
+		m.modConfigList.SetItems(nil)
+		return
+	}
+	items := make([]list.Item, len(doc.Entries))
+	for i, e := range doc.Entries {
+		items[i] = modConfigItem{e}
+	}
+	m.modConfigList.SetItems(items)
+}
+
+// selectedModConfigEntry returns the entry under the cursor, if any.
+func (m model) selectedModConfigEntry() *modconfig.Entry {
+	if item, ok := m.modConfigList.SelectedItem().(modConfigItem); ok {
+		return item.Entry
+	}
+	return nil
+}
+
+func (m model) updateModConfig(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While filtering, keys belong to the filter input
+	if m.modConfigList.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.modConfigList, cmd = m.modConfigList.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Back) && m.modConfigList.FilterState() == list.FilterApplied:
+		m.modConfigList.ResetFilter()
+		return m, nil
+	case key.Matches(msg, m.keys.Back):
+		m.err = nil
+		m.message = ""
+		m.modConfig = nil
+		m.state = stateDetailPanel
+		return m, nil
+	case key.Matches(msg, m.keys.ExternalEdit):
+		return m, m.editConfigFile(m.modConfigFile)
+	case key.Matches(msg, m.keys.Enter), key.Matches(msg, m.keys.Edit):
+		e := m.selectedModConfigEntry()
+		if e == nil || e.Type == modconfig.TypeSection {
+			return m, nil
+		}
+		if !e.Editable() {
+			m.err = fmt.Errorf("%s can only be edited in $EDITOR (press 'E')", e.Name())
+			return m, nil
+		}
+		m.modConfigEntry = e
+		m.err = nil
+		m.message = ""
+		m.textInput.CharLimit = 0 // lists can be long
+		m.textInput.SetValue(e.Value)
+		m.textInput.Placeholder = "Enter value..."
+		m.textInput.CursorEnd()
+		m.textInput.Focus()
+		m.editError = nil
+		m.state = stateEditModConfig
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.modConfigList, cmd = m.modConfigList.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateEditModConfig(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.textInput.Blur()
+		m.textInput.CharLimit = inputCharLimit
+		m.editError = nil
+		m.state = stateModConfig
+		return m, nil
+	case "enter":
+		if err := m.modConfig.Set(m.modConfigEntry, m.textInput.Value()); err != nil {
+			m.editError = err
+			return m, nil
+		}
+		if err := modconfig.WriteFile(m.configFilePath(m.modConfigFile), m.modConfig); err != nil {
+			m.err = err
+		} else {
+			m.err = nil
+			m.message = fmt.Sprintf("Saved %s", m.modConfigEntry.Name())
+		}
+		index := m.modConfigList.Index()
+		m.loadModConfig()
+		m.modConfigList.Select(index)
+		m.textInput.Blur()
+		m.textInput.CharLimit = inputCharLimit
+		m.editError = nil
+		m.state = stateModConfig
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	m.editError = m.modConfig.Validate(m.modConfigEntry, m.textInput.Value())
+	return m, cmd
+}
+
+func (m model) viewModConfig() string {
+	var content strings.Builder
+	if m.err != nil {
+		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		content.WriteString("\n")
+	} else if m.message != "" {
+		content.WriteString(successStyle.Render(m.message))
+		content.WriteString("\n")
+	}
+
+	if m.modConfig == nil {
+		content.WriteString(titleStyle.Render(m.modConfigFile))
+		content.WriteString("\n\n")
+		content.WriteString(dimStyle.Render("This file can't be shown as keys. Press 'E' to open it in $EDITOR • ESC to go back"))
+		return content.String()
+	}
+
+	content.WriteString(m.modConfigList.View())
+	content.WriteString("\n")
+	if e := m.selectedModConfigEntry(); e != nil {
+		content.WriteString(dimStyle.Render(fmt.Sprintf("%s (line %d)", e.Name(), e.Line)))
+		if e.Comment != "" {
+			content.WriteString("\n")
+			content.WriteString(subtitleStyle.Render(e.Comment))
+		}
+		content.WriteString("\n")
+	}
+	content.WriteString(dimStyle.Render("Enter to edit • / to search • 'E' to open in $EDITOR • ESC to go back"))
+	return content.String()
+}
+
+func (m model) viewEditModConfig() string {
+	e := m.modConfigEntry
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Edit %s: %s", m.modConfigFile, e.Name())))
+	content.WriteString("\n\n")
+	about := string(e.Type)
+	if c := e.Constraint(); c != "" {
+		about += " • " + c
+	}
+	content.WriteString(dimStyle.Render(about))
+	content.WriteString("\n")
+	if e.Comment != "" {
+		content.WriteString(subtitleStyle.Render(e.Comment))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(m.textInput.View())
+	content.WriteString("\n")
+	if m.editError != nil {
+		content.WriteString(errorStyle.Render("✗ " + m.editError.Error()))
+	} else {
+		content.WriteString(successStyle.Render("✓ valid"))
+	}
+	content.WriteString("\n\n")
+	content.WriteString(dimStyle.Render("Enter to save • ESC to cancel"))
+	return content.String()
+}
@@ -1,18 +1,29 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/crash"
 	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/logs"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/modconfig"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/servers"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -28,6 +39,19 @@ const (
 	stateConfirmFileDelete
 	stateConfig     // NEW: show config variables list
 	stateEditConfig // NEW: edit single config value
+	stateOperation  // long-running create/delete/restore with progress bar
+	stateConfirmForceSwitch
+	stateConfirmMoveInstances // choose whether a new instances-path takes the instances along
+	stateContexts             // pick the context to use
+	stateWorldAction          // target of a world copy/move/rename
+	stateServerAdd            // name and address of a new server
+	stateConfirmServerRemove
+	stateModConfig     // keys of a mod config file
+	stateEditModConfig // edit one mod config value
+	stateSearch        // search the contents of all instances
+	stateLogView       // a log of the selected instance
+	stateLogGrep       // pattern the log view is filtered by
+	stateCrash         // newest crash report of an instance, explained
 )
 
 type detailPanel int
@@ -36,35 +60,80 @@ const (
 	panelMods detailPanel = iota
 	panelConfigs
 	panelSaves
+	panelResourcePacks
+	panelShaderPacks
+	panelServers
+	panelLogs
 )
 
+// detailPanelCount is the number of panels of the detail view.
+const detailPanelCount = 7
+
+// minPanelWidth is the narrowest a detail panel gets. Terminals too narrow
+// to show every panel at this width show as many as fit, scrolling
+// sideways to keep the active panel in view.
+const minPanelWidth = 30
+
+// visiblePanelCount returns how many detail panels fit side by side.
+func visiblePanelCount(terminalWidth int) int {
+	n := terminalWidth / minPanelWidth
+	if n < 1 {
+		return 1
+	}
+	if n > detailPanelCount {
+		return detailPanelCount
+	}
+	return n
+}
+
+// detailPanelWidth returns the content width of one detail panel.
+func detailPanelWidth(terminalWidth int) int {
+	// Account for borders (2 chars) per panel
+	width := terminalWidth/visiblePanelCount(terminalWidth) - 2
+	if width < 15 {
+		width = 15 // Minimum usable width
+	}
+	return width
+}
+
 type keyMap struct {
-	Up        key.Binding
-	Down      key.Binding
-	Enter     key.Binding
-	Back      key.Binding
-	Quit      key.Binding
-	Help      key.Binding
-	Create    key.Binding
-	Delete    key.Binding
-	Refresh   key.Binding
-	Restore   key.Binding
-	Search    key.Binding
-	TabNext   key.Binding
-	TabPrev   key.Binding
-	Edit      key.Binding
-	Configure key.Binding // NEW: open config UI
+	Up           key.Binding
+	Down         key.Binding
+	Enter        key.Binding
+	Back         key.Binding
+	Quit         key.Binding
+	Help         key.Binding
+	Create       key.Binding
+	Delete       key.Binding
+	Refresh      key.Binding
+	Restore      key.Binding
+	Search       key.Binding
+	Find         key.Binding
+	TabNext      key.Binding
+	TabPrev      key.Binding
+	Edit         key.Binding
+	Configure    key.Binding // NEW: open config UI
+	Sort         key.Binding
+	Undo         key.Binding
+	WorldCopy    key.Binding
+	WorldMove    key.Binding
+	WorldRename  key.Binding
+	PackToggle   key.Binding
+	ExternalEdit key.Binding
+	LogFollow    key.Binding
+	LogLevel     key.Binding
+	Crash        key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Create, k.Delete, k.Restore, k.Search, k.Configure, k.Help, k.Quit}
+	return []key.Binding{k.Create, k.Delete, k.Restore, k.Search, k.Find, k.Configure, k.Help, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Enter},
-		{k.Create, k.Delete, k.Restore},
-		{k.Search, k.Edit, k.Configure, k.Refresh, k.Help},
+		{k.Create, k.Delete, k.Undo, k.Restore},
+		{k.Search, k.Find, k.Crash, k.Edit, k.Configure, k.Sort, k.Refresh, k.Help},
 		{k.Back, k.Quit},
 	}
 }
@@ -115,6 +184,10 @@ var keys = keyMap{
 		key.WithKeys("s"),
 		key.WithHelp("s", "show details"),
 	),
+	Find: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search all"),
+	),
 	TabNext: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "next panel"),
@@ -131,32 +204,74 @@ var keys = keyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "configure"),
 	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort by name/recent"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo delete"),
+	),
+	WorldCopy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy world"),
+	),
+	WorldMove: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "move world"),
+	),
+	WorldRename: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "rename world"),
+	),
+	PackToggle: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "enable/disable pack"),
+	),
+	ExternalEdit: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "edit config in $EDITOR"),
+	),
+	LogFollow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "follow log"),
+	),
+	LogLevel: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "change log level"),
+	),
+	Crash: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "show last crash"),
+	),
 }
 
+// inputCharLimit is the length limit of the text input, lifted while
+// editing mod config values.
+const inputCharLimit = 50
+
+// undoHint is appended to the status message after something was trashed.
+const undoHint = " (press 'u' to undo)"
+
 // NEW: list item representing a config key/value
 type configItem struct {
-	Key   string
-	Value string
+	Key    string
+	Value  string
+	Source instance.ConfigSource // NEW: where the value came from
 }
 
 func (c configItem) FilterValue() string { return c.Key + " " + c.Value }
 func (c configItem) Title() string       { return c.Key }
-func (c configItem) Description() string { return c.Value }
+func (c configItem) Description() string {
+	if c.Source.Layer == "" || c.Source.Layer == instance.SourceDefault || c.Source.Layer == instance.SourceFile {
+		return c.Value
+	}
+	return c.Value + "  (" + c.Source.String() + ")"
+}
 
 type instanceItem struct {
 	instance.Instance
-}
-
-type searchItem struct {
-	InstanceName string
-	Directory    string
-	Files        []string
-	FileCount    int
-}
-
-type searchData struct {
-	Instances map[string][]searchItem
-	AllItems  []searchItem
+	UnseenCrash bool // a crash report newer than the last one looked at
 }
 
 func (i instanceItem) FilterValue() string { return i.Name }
@@ -168,24 +283,12 @@ func (i instanceItem) Description() string {
 	} else {
 		status = "○ Inactive"
 	}
-	return fmt.Sprintf("%s | %d mods | %d configs | %d saves",
-		status, i.ModCount, i.ConfigCount, i.SaveCount)
-}
-
-func (s searchItem) FilterValue() string { return s.InstanceName + " " + s.Directory }
-func (s searchItem) Title() string {
-	return fmt.Sprintf("%s/%s", s.InstanceName, s.Directory)
-}
-func (s searchItem) Description() string {
-	fileDesc := fmt.Sprintf("%d files", s.FileCount)
-	if len(s.Files) > 0 {
-		preview := strings.Join(s.Files[:min(len(s.Files), 3)], ", ")
-		if len(s.Files) > 3 {
-			preview += "..."
-		}
-		return fmt.Sprintf("%s: %s", fileDesc, preview)
+	desc := fmt.Sprintf("%s | %d mods | %d configs | %d saves | %d packs | %d shaders",
+		status, i.ModCount, i.ConfigCount, i.SaveCount, i.ResourcePackCount, i.ShaderPackCount)
+	if i.UnseenCrash {
+		desc += " | ⚠ crashed (press 'x')"
 	}
-	return fileDesc
+	return desc
 }
 
 type fileItem struct {
@@ -236,62 +339,121 @@ func (f fileItem) scrollText(text string, maxWidth, offset int) string {
 }
 
 type model struct {
-	state            state
-	manager          *instance.Manager
-	list             list.Model
-	searchList       list.Model
-	modsList         list.Model
-	configsList      list.Model
-	savesList        list.Model
-	help             help.Model
-	textInput        textinput.Model
-	instances        []instance.Instance
-	selectedInstance *instance.Instance
-	instanceInfo     *instance.InstanceInfo
-	searchData       *searchData
-	activePanel      detailPanel
-	terminalWidth    int
-	terminalHeight   int
-	scrollOffset     int
-	message          string
-	err              error
-	keys             keyMap
+	state             state
+	manager           *instance.Manager
+	list              list.Model
+	searchList        list.Model
+	modsList          list.Model
+	configsList       list.Model
+	savesList         list.Model
+	serversList       list.Model
+	logsList          list.Model
+	resourcePacksList list.Model
+	shaderPacksList   list.Model
+	help              help.Model
+	textInput         textinput.Model
+	instances         []instance.Instance
+	selectedInstance  *instance.Instance
+	instanceInfo      *instance.InstanceInfo
+	activePanel       detailPanel
+	terminalWidth     int
+	terminalHeight    int
+	scrollOffset      int
+	message           string
+	err               error
+	keys              keyMap
 	// File deletion context
 	fileToDelete string
-	fileType     string // "mod", "config", or "save"
+	fileType     string // "mod", "config", "save", "resource pack" or "shader pack"
 
 	// NEW: config UI
 	configList list.Model
 	editingKey string // the config key currently being edited
+	editError  error  // validation result for the value being typed
+	// New instances-path waiting for the move/keep choice
+	pendingInstancesPath string
+	// Context switcher, and the options to rebuild the manager with
+	contextList list.Model
+	opts        instance.Options
+	// Worlds of the selected instance by folder, and the pending world action
+	worlds      map[string]worlds.World
+	worldAction string
+	worldFolder string
+	// Servers panel: load error, server being added or removed
+	serversErr     error
+	newServer      servers.Server
+	serverToRemove servers.Server
+	// Background index of the search screen; nil until it is first opened
+	indexer *indexer
+	// Log open in the log view, its filter, and the follower tailing it
+	logFile     logs.File
+	logLevel    logs.Level
+	logGrep     *regexp.Regexp
+	logLines    []logs.Line
+	logView     viewport.Model
+	logFollower *logFollower
+	// Crash report shown on the crash screen
+	crashReport *crash.Analysis
+	crashView   viewport.Model
+	// Open folders of the configs panel's tree
+	configExpanded map[string]bool
+	// Mod config open in the built-in editor, and the entry being edited
+	modConfig      *modconfig.Document
+	modConfigFile  string
+	modConfigList  list.Model
+	modConfigEntry *modconfig.Entry
+
+	// Running background operation, if any
+	op          *operation
+	progressBar progress.Model
+
+	// Filesystem watcher; nil if inotify is unavailable (F5 still works)
+	watcher *fsWatcher
+
+	// List order: alphabetical (default) or most recently switched first
+	sortByRecent bool
+
+	// Most recent deletion, restorable with the undo key
+	lastTrashed *instance.TrashEntry
+
+	// What .minecraft currently is, refreshed with the instance list
+	active instance.ActiveState
 }
 
 type refreshMsg struct{}
-type switchMsg struct{ name string }
+type switchMsg struct {
+	name  string
+	force bool
+}
 type createMsg struct{ name string }
 type deleteMsg struct{ name string }
 type restoreMsg struct{}
 type confirmRestoreMsg struct{}
 type tickMsg struct{}
 type deleteFileMsg struct{ fileName, fileType string }
+type undoMsg struct{}
+
+// restoredTrash is the result of an undo operation, so that the entry it
+// restored is no longer offered for undo.
+type restoredTrash struct{ id string }
 
-func initialModel() model {
-	manager, err := instance.NewManager()
+func initialModel(opts instance.Options) model {
+	manager, err := instance.NewManagerWithOptions(opts)
 
 	// Initialize list
 	items := []list.Item{}
 	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "Minecraft Instance Manager"
+	l.Title = listTitle(manager, false)
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 	l.Styles.Title = titleStyle
 
-	// Initialize search list
-	searchItems := []list.Item{}
-	sl := list.New(searchItems, list.NewDefaultDelegate(), 0, 0)
-	sl.Title = "Instance Directory Search"
+	// Initialize search results list; the query is typed into textInput
+	sl := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	sl.SetShowTitle(false)
 	sl.SetShowStatusBar(false)
-	sl.SetFilteringEnabled(true)
-	sl.Styles.Title = titleStyle
+	sl.SetShowHelp(false) // letters go to the query
+	sl.SetFilteringEnabled(false)
 
 	// Initialize detail panel lists
 	modsList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
@@ -303,7 +465,7 @@ func initialModel() model {
 	configsList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	configsList.Title = "Configs"
 	configsList.SetShowStatusBar(false)
-	configsList.SetFilteringEnabled(false)
+	configsList.SetFilteringEnabled(true) // matches paths in subfolders too
 	configsList.Styles.Title = titleStyle
 
 	savesList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
@@ -312,6 +474,30 @@ func initialModel() model {
 	savesList.SetFilteringEnabled(false)
 	savesList.Styles.Title = titleStyle
 
+	resourcePacksList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	resourcePacksList.Title = "Resource Packs"
+	resourcePacksList.SetShowStatusBar(false)
+	resourcePacksList.SetFilteringEnabled(false)
+	resourcePacksList.Styles.Title = titleStyle
+
+	shaderPacksList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	shaderPacksList.Title = "Shader Packs"
+	shaderPacksList.SetShowStatusBar(false)
+	shaderPacksList.SetFilteringEnabled(false)
+	shaderPacksList.Styles.Title = titleStyle
+
+	serversList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	serversList.Title = "Servers"
+	serversList.SetShowStatusBar(false)
+	serversList.SetFilteringEnabled(false)
+	serversList.Styles.Title = titleStyle
+
+	logsList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	logsList.Title = "Logs"
+	logsList.SetShowStatusBar(false)
+	logsList.SetFilteringEnabled(false)
+	logsList.Styles.Title = titleStyle
+
 	// Initialize config list (NEW)
 	cfgItems := []list.Item{}
 	cfgList := list.New(cfgItems, list.NewDefaultDelegate(), 0, 0)
@@ -320,43 +506,72 @@ func initialModel() model {
 	cfgList.SetFilteringEnabled(true)
 	cfgList.Styles.Title = titleStyle
 
+	modCfgList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	modCfgList.SetShowStatusBar(false)
+	modCfgList.SetFilteringEnabled(true)
+	modCfgList.Styles.Title = titleStyle
+
+	ctxList := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	ctxList.Title = "Contexts"
+	ctxList.SetShowStatusBar(false)
+	ctxList.SetFilteringEnabled(false)
+	ctxList.Styles.Title = titleStyle
+
 	// Initialize text input
 	ti := textinput.New()
 	ti.Placeholder = "Enter instance name..."
 	ti.Focus()
-	ti.CharLimit = 50
+	ti.CharLimit = inputCharLimit
 	ti.Width = 30
 
 	// Initialize help
 	h := help.New()
 
 	m := model{
-		state:          stateList,
-		manager:        manager,
-		list:           l,
-		searchList:     sl,
-		modsList:       modsList,
-		configsList:    configsList,
-		savesList:      savesList,
-		help:           h,
-		textInput:      ti,
-		activePanel:    panelMods,
-		terminalWidth:  120, // Default fallback
-		terminalHeight: 30,  // Default fallback
-		keys:           keys,
-		err:            err,
-		configList:     cfgList, // NEW
+		state:             stateList,
+		manager:           manager,
+		list:              l,
+		searchList:        sl,
+		modsList:          modsList,
+		configsList:       configsList,
+		savesList:         savesList,
+		serversList:       serversList,
+		logsList:          logsList,
+		resourcePacksList: resourcePacksList,
+		shaderPacksList:   shaderPacksList,
+		help:              h,
+		textInput:         ti,
+		activePanel:       panelMods,
+		terminalWidth:     120, // Default fallback
+		terminalHeight:    30,  // Default fallback
+		keys:              keys,
+		err:               err,
+		configList:        cfgList, // NEW
+		modConfigList:     modCfgList,
+		contextList:       ctxList,
+		opts:              opts,
+		progressBar:       newProgressBar(),
+	}
+
+	if manager != nil {
+		if fw, werr := newFSWatcher(manager); werr == nil {
+			m.watcher = fw
+		}
 	}
 
 	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		refreshInstances,
 		textinput.Blink,
 		tickCmd(),
-	)
+	}
+	if m.watcher != nil {
+		cmds = append(cmds, m.watcher.wait())
+	}
+	return tea.Batch(cmds...)
 }
 
 func tickCmd() tea.Cmd {
@@ -387,6 +602,32 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateConfigList(msg)
 		case stateEditConfig: // NEW
 			return m.updateEditConfig(msg)
+		case stateOperation:
+			return m.updateOperation(msg)
+		case stateConfirmForceSwitch:
+			return m.updateConfirmForceSwitch(msg)
+		case stateConfirmMoveInstances:
+			return m.updateConfirmMoveInstances(msg)
+		case stateContexts:
+			return m.updateContexts(msg)
+		case stateWorldAction:
+			return m.updateWorldAction(msg)
+		case stateServerAdd:
+			return m.updateServerAdd(msg)
+		case stateConfirmServerRemove:
+			return m.updateConfirmServerRemove(msg)
+		case stateModConfig:
+			return m.updateModConfig(msg)
+		case stateEditModConfig:
+			return m.updateEditModConfig(msg)
+		case stateSearch:
+			return m.updateSearch(msg)
+		case stateLogView:
+			return m.updateLogView(msg)
+		case stateLogGrep:
+			return m.updateLogGrep(msg)
+		case stateCrash:
+			return m.updateCrash(msg)
 		}
 
 	case tea.WindowSizeMsg:
@@ -399,9 +640,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.terminalWidth = msg.Width
 		m.terminalHeight = msg.Height
 
-		m.list.SetSize(msg.Width, msg.Height-4)
-		m.searchList.SetSize(msg.Width, msg.Height-4)
+		m.list.SetSize(msg.Width, msg.Height-5)       // one line for the .minecraft status
+		m.searchList.SetSize(msg.Width, msg.Height-6) // room for the query and index status
 		m.configList.SetSize(msg.Width, msg.Height-4) // NEW: set size for config list
+		m.contextList.SetSize(msg.Width, msg.Height-4)
+		m.modConfigList.SetSize(msg.Width, msg.Height-6) // room for the selected key's comment
+		m.progressBar.Width = msg.Width - 10
 
 		// Update text input width to match terminal width (with some padding)
 		textInputWidth := msg.Width - 4 // Leave 4 chars for padding/borders
@@ -410,12 +654,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.textInput.Width = textInputWidth
 
-		// Set panel sizes for detail view (each panel gets 1/3 of width)
-		// Account for borders (2 chars) + padding (2 chars) per panel, but be less conservative
-		panelWidth := (msg.Width / 3) - 2 // Minimal border accounting
-		if panelWidth < 15 {
-			panelWidth = 15 // Minimum usable width
-		}
+		// Set panel sizes for detail view (the visible panels share the width)
+		panelWidth := detailPanelWidth(msg.Width)
 		panelHeight := msg.Height - 6 // Leave space for header and instructions
 		if panelHeight < 5 {
 			panelHeight = 5 // Minimum height
@@ -423,6 +663,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.modsList.SetSize(panelWidth, panelHeight)
 		m.configsList.SetSize(panelWidth, panelHeight)
 		m.savesList.SetSize(panelWidth, panelHeight)
+		m.resourcePacksList.SetSize(panelWidth, panelHeight)
+		m.shaderPacksList.SetSize(panelWidth, panelHeight)
+		m.serversList.SetSize(panelWidth, panelHeight)
+		m.logsList.SetSize(panelWidth, panelHeight)
+		m.logView.Width = msg.Width
+		m.logView.Height = m.logViewHeight()
+		m.crashView.Width = msg.Width
+		m.crashView.Height = m.crashViewHeight()
+		m.crashView.SetContent(m.renderCrash())
 		return m, nil
 
 	case refreshMsg:
@@ -432,18 +681,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.sortByRecent {
+			// Instances without history keep their alphabetical order at the end
+			recent := m.manager.LastSwitchTimes()
+			sort.SliceStable(instances, func(i, j int) bool {
+				return recent[instances[i].Name].After(recent[instances[j].Name])
+			})
+		}
+
 		m.instances = instances
+		seen := crash.LoadSeen(filepath.Join(m.manager.AppDir, crash.SeenFileName))
 		items := make([]list.Item, len(instances))
 		for i, inst := range instances {
-			items[i] = instanceItem{inst}
+			items[i] = instanceItem{Instance: inst, UnseenCrash: seen.Unseen(inst.Path)}
 		}
 
 		m.list.SetItems(items)
+		m.active = m.manager.ActiveState()
 		m.err = nil
+		if m.watcher != nil {
+			m.watcher.sync(instances)
+		}
 		return m, nil
 
+	case fsChangedMsg:
+		if msg.watcher != m.watcher {
+			// From a watcher replaced by a context switch
+			return m, nil
+		}
+		// Keep the open detail view in sync with the disk as well
+		if m.state == stateDetailPanel && m.selectedInstance != nil {
+			if info, err := m.manager.GetInstanceInfo(m.selectedInstance.Name); err == nil {
+				m.instanceInfo = info
+				m.refreshDetailPanelLists()
+			}
+		}
+		return m, tea.Batch(refreshInstances, m.watcher.wait())
+
 	case switchMsg:
-		err := m.manager.SwitchInstance(msg.name)
+		switchFn := m.manager.SwitchInstance
+		if msg.force {
+			switchFn = m.manager.ForceSwitchInstance
+		}
+		err := switchFn(msg.name)
+		if errors.Is(err, instance.ErrForeignSymlink) {
+			// Ask before replacing a link this tool did not create
+			m.selectedInstance = &instance.Instance{Name: msg.name}
+			m.active = m.manager.ActiveState()
+			m.state = stateConfirmForceSwitch
+			return m, nil
+		}
+		m.state = stateList
 		if err != nil {
 			m.err = err
 		} else {
@@ -452,53 +740,113 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, refreshInstances
 
 	case createMsg:
-		err := m.manager.CreateInstance(msg.name)
-		if err != nil {
-			m.err = err
-		} else {
-			m.message = fmt.Sprintf("Created instance: %s", msg.name)
-			m.state = stateList
-			// Clear the text input and unfocus it
-			m.textInput.SetValue("")
-			m.textInput.Blur()
-		}
-		return m, refreshInstances
+		// Clear the text input and unfocus it
+		m.textInput.SetValue("")
+		m.textInput.Blur()
+		name := msg.name
+		return m.runOperation("Creating "+name, "Created instance: "+name, stateList,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				return nil, m.manager.CreateInstanceContext(ctx, name, progress)
+			})
 
 	case deleteMsg:
-		err := m.manager.DeleteInstance(msg.name)
-		if err != nil {
-			m.err = err
-		} else {
-			m.message = fmt.Sprintf("Deleted instance: %s", msg.name)
-			m.state = stateList
-		}
-		return m, refreshInstances
+		name := msg.name
+		return m.runOperation("Deleting "+name, "Deleted instance: "+name, stateList,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				return m.manager.DeleteInstanceContext(ctx, name, progress)
+			})
 
 	case restoreMsg:
-		err := m.manager.RestoreDefault()
-		if err != nil {
-			m.err = err
+		return m.runOperation("Restoring default", "Restored default minecraft directory", stateList,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				return nil, m.manager.RestoreDefaultContext(ctx, progress)
+			})
+
+	case opProgressMsg:
+		if m.op == nil {
+			return m, nil
+		}
+		m.op.progress = msg.progress
+		return m, m.op.wait()
+
+	case opDoneMsg:
+		if m.op != nil {
+			m.state = m.op.returnTo
+			m.op = nil
+		}
+		cmds = append(cmds, refreshInstances)
+		if manager, ok := msg.result.(*instance.Manager); ok {
+			cmds = append(cmds, m.setManager(manager))
+		}
+		if m.state == stateConfig {
+			m.configList.SetItems(m.buildConfigListItems())
+		}
+		if m.state == stateDetailPanel {
+			m.refreshInstanceInfo()
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			m.message = ""
 		} else {
-			m.message = "Restored default minecraft directory"
+			m.err = nil
+			m.message = msg.success
+			if entry, ok := msg.result.(*instance.TrashEntry); ok {
+				m.lastTrashed = entry
+				m.message += undoHint
+			}
+			if restored, ok := msg.result.(restoredTrash); ok && m.lastTrashed != nil && m.lastTrashed.ID == restored.id {
+				m.lastTrashed = nil
+			}
 		}
-		return m, refreshInstances
+		return m, tea.Batch(cmds...)
 
 	case deleteFileMsg:
-		err := m.deleteFile(msg.fileName, msg.fileType)
+		if m.selectedInstance == nil {
+			m.err = fmt.Errorf("no instance selected")
+			m.state = stateDetailPanel
+			return m, nil
+		}
+		section, err := fileSection(msg.fileType)
 		if err != nil {
 			m.err = err
-		} else {
-			m.message = fmt.Sprintf("Deleted %s: %s", msg.fileType, msg.fileName)
-			// Refresh the instance info to update the lists
-			if info, infoErr := m.manager.GetInstanceInfo(m.selectedInstance.Name); infoErr == nil {
-				m.instanceInfo = info
-				m.refreshDetailPanelLists()
-			}
+			m.state = stateDetailPanel
+			return m, nil
+		}
+		instanceName := m.selectedInstance.Name
+		return m.runOperation("Deleting "+msg.fileName, fmt.Sprintf("Deleted %s: %s", msg.fileType, msg.fileName), stateDetailPanel,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				return m.manager.TrashFileContext(ctx, instanceName, section, msg.fileName, progress)
+			})
+
+	case undoMsg:
+		if m.lastTrashed == nil {
+			return m, nil
+		}
+		entry := m.lastTrashed
+		return m.runOperation("Restoring "+entry.Name, fmt.Sprintf("Restored %s: %s", entry.Kind, entry.Name), m.state,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				if _, err := m.manager.RestoreTrashContext(ctx, entry.ID, progress); err != nil {
+					return nil, err
+				}
+				return restoredTrash{id: entry.ID}, nil
+			})
+
+	case editorClosedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		} else if m.state == stateModConfig {
+			// Show what was changed in the editor
+			index := m.modConfigList.Index()
+			m.loadModConfig()
+			m.modConfigList.Select(index)
 		}
-		m.state = stateDetailPanel
 		return m, nil
 
-	// searchMsg removed - we now go directly to 3-column panel view
+	case indexProgressMsg, indexDoneMsg:
+		return m.updateIndexing(msg)
+
+	case logLinesMsg, logFollowDoneMsg:
+		return m.updateLogFollow(msg)
 
 	case confirmRestoreMsg:
 		m.state = stateConfirmRestore
@@ -521,15 +869,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.modsList.SetItems(items)
 			case panelConfigs:
-				items := m.configsList.Items()
-				for i, item := range items {
-					if fileItem, ok := item.(fileItem); ok {
-						fileItem.ScrollOffset = m.scrollOffset
-						fileItem.IsSelected = (i == m.configsList.Index())
-						items[i] = fileItem
-					}
-				}
-				m.configsList.SetItems(items)
+				m.markConfigSelection()
 			case panelSaves:
 				items := m.savesList.Items()
 				for i, item := range items {
@@ -566,6 +906,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.configList, cmd = m.configList.Update(msg)
 	cmds = append(cmds, cmd)
 
+	m.modConfigList, cmd = m.modConfigList.Update(msg)
+	cmds = append(cmds, cmd)
+
 	m.textInput, cmd = m.textInput.Update(msg)
 	cmds = append(cmds, cmd)
 
@@ -594,11 +937,7 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.instanceInfo = info
 
 			// Populate the detail panel lists directly
-			// Account for borders (2 chars) + padding (2 chars) per panel, but be less conservative
-			panelWidth := (m.terminalWidth / 3) - 2 // Minimal border accounting
-			if panelWidth < 15 {
-				panelWidth = 15 // Minimum usable width
-			}
+			panelWidth := detailPanelWidth(m.terminalWidth)
 			// Account for list item padding and borders for actual text width
 			itemMaxWidth := panelWidth - 4 // Account for border (2) and list padding (2)
 
@@ -613,16 +952,8 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.modsList.SetItems(modsItems)
 
-			configsItems := make([]list.Item, len(info.ConfigsDir))
-			for i, config := range info.ConfigsDir {
-				configsItems[i] = fileItem{
-					Name:         config,
-					MaxWidth:     itemMaxWidth,
-					ScrollOffset: m.scrollOffset,
-					IsSelected:   false, // Not initially selected
-				}
-			}
-			m.configsList.SetItems(configsItems)
+			m.configsList.ResetFilter()
+			m.loadConfigTree(false)
 
 			savesItems := make([]list.Item, len(info.SavesDir))
 			for i, save := range info.SavesDir {
@@ -634,6 +965,10 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 			m.savesList.SetItems(savesItems)
+			m.loadWorlds()
+			m.loadServers()
+			m.loadPacks()
+			m.loadLogFiles()
 
 			m.activePanel = panelMods
 			m.state = stateDetailPanel
@@ -668,6 +1003,14 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.err = nil
 		return m, refreshInstances
 
+	case key.Matches(msg, m.keys.Undo):
+		return m, undo
+
+	case key.Matches(msg, m.keys.Sort):
+		m.sortByRecent = !m.sortByRecent
+		m.list.Title = listTitle(m.manager, m.sortByRecent)
+		return m, refreshInstances
+
 	case key.Matches(msg, m.keys.Restore):
 		return m, func() tea.Msg {
 			return confirmRestoreMsg{}
@@ -689,10 +1032,7 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.instanceInfo = info
 
 		// Calculate proper width for file items
-		panelWidth := (m.terminalWidth / 3) - 2 // Minimal border accounting
-		if panelWidth < 15 {
-			panelWidth = 15 // Minimum usable width
-		}
+		panelWidth := detailPanelWidth(m.terminalWidth)
 		// Account for list item padding and borders for actual text width
 		itemMaxWidth := panelWidth - 4 // Account for border (2) and list padding (2)
 
@@ -706,14 +1046,8 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.modsList.SetItems(modsItems)
 
-		configsItems := make([]list.Item, len(info.ConfigsDir))
-		for i, config := range info.ConfigsDir {
-			configsItems[i] = fileItem{
-				Name:     config,
-				MaxWidth: itemMaxWidth,
-			}
-		}
-		m.configsList.SetItems(configsItems)
+		m.configsList.ResetFilter()
+		m.loadConfigTree(false)
 
 		savesItems := make([]list.Item, len(info.SavesDir))
 		for i, save := range info.SavesDir {
@@ -723,10 +1057,24 @@ func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.savesList.SetItems(savesItems)
+		m.loadWorlds()
+		m.loadServers()
+		m.loadPacks()
+		m.loadLogFiles()
 
 		m.activePanel = panelMods
 		m.state = stateDetailPanel
 
+	case key.Matches(msg, m.keys.Find):
+		return m.openSearch()
+
+	case key.Matches(msg, m.keys.Crash):
+		if len(m.instances) == 0 {
+			return m, nil
+		}
+		selected := m.list.SelectedItem().(instanceItem)
+		return m.openCrash(selected.Instance)
+
 	case key.Matches(msg, m.keys.Configure): // NEW: open global configuration UI
 		var items []list.Item
 		if m.manager != nil {
@@ -767,12 +1115,9 @@ func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.modsList.SetItems(modsItems)
 
-			// Populate configs list
-			configsItems := make([]list.Item, len(m.instanceInfo.ConfigsDir))
-			for i, config := range m.instanceInfo.ConfigsDir {
-				configsItems[i] = fileItem{Name: config}
-			}
-			m.configsList.SetItems(configsItems)
+			// Populate configs tree
+			m.configsList.ResetFilter()
+			m.loadConfigTree(false)
 
 			// Populate saves list
 			savesItems := make([]list.Item, len(m.instanceInfo.SavesDir))
@@ -780,6 +1125,10 @@ func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				savesItems[i] = fileItem{Name: save}
 			}
 			m.savesList.SetItems(savesItems)
+			m.loadWorlds()
+			m.loadServers()
+			m.loadPacks()
+			m.loadLogFiles()
 
 			m.activePanel = panelMods
 			m.state = stateDetailPanel
@@ -820,26 +1169,44 @@ func (m model) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch {
-	case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Quit):
+func (m model) updateConfirmForceSwitch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		return m, func() tea.Msg {
+			return switchMsg{name: m.selectedInstance.Name, force: true}
+		}
+	case "n", "N", "esc":
 		m.state = stateList
 	}
-
-	// Update search list
-	var cmd tea.Cmd
-	m.searchList, cmd = m.searchList.Update(msg)
-	return m, cmd
+	return m, nil
 }
 
 func (m model) updateDetailPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The configs panel's filter input takes all keys while it is open
+	if m.activePanel == panelConfigs && m.configsList.FilterState() == list.Filtering {
+		return m.updateConfigFilter(msg)
+	}
+
 	switch {
+	case key.Matches(msg, m.keys.Back) && m.activePanel == panelConfigs && m.configsList.FilterState() == list.FilterApplied:
+		m.configsList.ResetFilter()
+		m.loadConfigTree(false)
+		return m, nil
 	case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.Quit):
 		m.state = stateList
 	case key.Matches(msg, m.keys.TabNext):
-		m.activePanel = (m.activePanel + 1) % 3
+		m.activePanel = (m.activePanel + 1) % detailPanelCount
 	case key.Matches(msg, m.keys.TabPrev):
-		m.activePanel = (m.activePanel + 2) % 3 // +2 is same as -1 in mod 3
+		m.activePanel = (m.activePanel + detailPanelCount - 1) % detailPanelCount
+	case key.Matches(msg, m.keys.Undo):
+		return m, undo
+	case key.Matches(msg, m.keys.Create) && m.activePanel == panelServers:
+		return m.startServerAdd()
+	case key.Matches(msg, m.keys.Delete) && m.activePanel == panelServers:
+		if item, ok := m.serversList.SelectedItem().(serverItem); ok {
+			m.serverToRemove = item.Server
+			m.state = stateConfirmServerRemove
+		}
 	case key.Matches(msg, m.keys.Delete):
 		// Allow deleting files from any panel
 		if m.selectedInstance != nil {
@@ -853,8 +1220,8 @@ func (m model) updateDetailPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					fileType = "mod"
 				}
 			case panelConfigs:
-				if m.configsList.SelectedItem() != nil {
-					fileName = m.configsList.SelectedItem().(fileItem).Name
+				if f := m.selectedConfigFile(); f != nil {
+					fileName = f.Path
 					fileType = "config"
 				}
 			case panelSaves:
@@ -862,6 +1229,11 @@ func (m model) updateDetailPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					fileName = m.savesList.SelectedItem().(fileItem).Name
 					fileType = "save"
 				}
+			case panelResourcePacks, panelShaderPacks:
+				if p := m.selectedPack(); p != nil {
+					fileName = p.File
+					fileType = p.Kind.Label()
+				}
 			}
 
 			if fileName != "" {
@@ -870,14 +1242,33 @@ func (m model) updateDetailPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.state = stateConfirmFileDelete
 			}
 		}
-	case key.Matches(msg, m.keys.Edit):
-		// Only allow editing in config panel
+	case key.Matches(msg, m.keys.Edit), key.Matches(msg, m.keys.ExternalEdit):
+		// Only allow editing in config panel; formats the built-in editor
+		// reads open there unless 'E' asks for $EDITOR
 		if m.activePanel == panelConfigs && m.selectedInstance != nil {
-			if m.configsList.SelectedItem() != nil {
-				configFile := m.configsList.SelectedItem().(fileItem)
-				return m, m.editConfigFile(configFile.Name)
+			if f := m.selectedConfigFile(); f != nil && !f.IsDir {
+				if _, ok := modconfig.FormatOf(f.Path); ok && key.Matches(msg, m.keys.Edit) {
+					return m.openModConfig(f.Path)
+				}
+				return m, m.editConfigFile(f.Path)
 			}
 		}
+	case key.Matches(msg, m.keys.Enter) && m.activePanel == panelLogs:
+		return m.openLog()
+	case key.Matches(msg, m.keys.Enter) && m.activePanel == panelConfigs:
+		m.toggleConfigFolder()
+		return m, nil
+	case key.Matches(msg, m.configsList.KeyMap.Filter) && m.activePanel == panelConfigs:
+		// Let the filter see files in collapsed folders
+		m.loadConfigTree(true)
+	case key.Matches(msg, m.keys.PackToggle):
+		m.togglePack()
+	case key.Matches(msg, m.keys.WorldCopy):
+		return m.startWorldAction(worldActionCopy)
+	case key.Matches(msg, m.keys.WorldMove):
+		return m.startWorldAction(worldActionMove)
+	case key.Matches(msg, m.keys.WorldRename):
+		return m.startWorldAction(worldActionRename)
 	case key.Matches(msg, m.keys.Configure): // NEW
 		// Open config UI for the selected instance
 		if m.selectedInstance != nil {
@@ -903,6 +1294,14 @@ func (m model) updateDetailPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.savesList, cmd = m.savesList.Update(msg)
 		// Update selection state for all items
 		m.updateItemSelectionState(panelSaves)
+	case panelResourcePacks:
+		m.resourcePacksList, cmd = m.resourcePacksList.Update(msg)
+	case panelShaderPacks:
+		m.shaderPacksList, cmd = m.shaderPacksList.Update(msg)
+	case panelServers:
+		m.serversList, cmd = m.serversList.Update(msg)
+	case panelLogs:
+		m.logsList, cmd = m.logsList.Update(msg)
 	}
 	return m, cmd
 }
@@ -932,14 +1331,7 @@ func (m *model) updateItemSelectionState(panel detailPanel) {
 		}
 		m.modsList.SetItems(items)
 	case panelConfigs:
-		items := m.configsList.Items()
-		for i, item := range items {
-			if fileItem, ok := item.(fileItem); ok {
-				fileItem.IsSelected = (i == m.configsList.Index())
-				items[i] = fileItem
-			}
-		}
-		m.configsList.SetItems(items)
+		m.markConfigSelection()
 	case panelSaves:
 		items := m.savesList.Items()
 		for i, item := range items {
@@ -970,6 +1362,30 @@ func (m model) View() string {
 		return m.viewConfig()
 	case stateEditConfig: // NEW
 		return m.viewEditConfig()
+	case stateOperation:
+		return m.viewOperation()
+	case stateConfirmForceSwitch:
+		return m.viewConfirmForceSwitch()
+	case stateConfirmMoveInstances:
+		return m.viewConfirmMoveInstances()
+	case stateContexts:
+		return m.viewContexts()
+	case stateWorldAction:
+		return m.viewWorldAction()
+	case stateServerAdd:
+		return m.viewServerAdd()
+	case stateConfirmServerRemove:
+		return m.viewConfirmServerRemove()
+	case stateModConfig:
+		return m.viewModConfig()
+	case stateEditModConfig:
+		return m.viewEditModConfig()
+	case stateSearch:
+		return m.viewSearch()
+	case stateLogView, stateLogGrep:
+		return m.viewLog()
+	case stateCrash:
+		return m.viewCrash()
 	}
 	return ""
 }
@@ -987,6 +1403,8 @@ func (m model) viewList() string {
 		content.WriteString("\n\n")
 	}
 
+	content.WriteString(m.viewActiveState())
+	content.WriteString("\n")
 	content.WriteString(m.list.View())
 	content.WriteString("\n")
 	content.WriteString(m.help.View(m.keys))
@@ -1010,6 +1428,8 @@ func (m model) viewDetail() string {
 	content.WriteString(fmt.Sprintf("• Mods: %d\n", m.selectedInstance.ModCount))
 	content.WriteString(fmt.Sprintf("• Configs: %d\n", m.selectedInstance.ConfigCount))
 	content.WriteString(fmt.Sprintf("• Saves: %d\n", m.selectedInstance.SaveCount))
+	content.WriteString(fmt.Sprintf("• Resource packs: %d\n", m.selectedInstance.ResourcePackCount))
+	content.WriteString(fmt.Sprintf("• Shader packs: %d\n", m.selectedInstance.ShaderPackCount))
 	content.WriteString(fmt.Sprintf("• Status: %s\n", func() string {
 		if m.selectedInstance.IsActive {
 			return "Active"
@@ -1069,23 +1489,40 @@ func (m model) viewConfirmDelete() string {
 	content.WriteString(titleStyle.Render("Confirm Deletion"))
 	content.WriteString("\n\n")
 	content.WriteString(fmt.Sprintf("Are you sure you want to delete instance '%s'?\n", m.selectedInstance.Name))
-	content.WriteString("This action cannot be undone.\n\n")
+	content.WriteString("It will be moved to the trash; press 'u' afterwards to undo.\n\n")
 	content.WriteString(errorStyle.Render("Press 'y' to confirm, 'n' to cancel"))
 
 	return content.String()
 }
 
-func (m model) viewSearch() string {
-	var content strings.Builder
-
-	if m.err != nil {
-		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
-		content.WriteString("\n\n")
+// viewActiveState renders one line describing what .minecraft currently is.
+func (m model) viewActiveState() string {
+	switch m.active.Kind {
+	case instance.ActiveInstance:
+		if m.active.Relative {
+			return successStyle.Render("● .minecraft → " + m.active.Instance + " (relative link)")
+		}
+		return successStyle.Render("● .minecraft → " + m.active.Instance)
+	case instance.ActiveForeign:
+		return errorStyle.Render("⚠ .minecraft is a foreign symlink to " + m.active.Target)
+	case instance.ActiveMissing:
+		if m.active.Target != "" {
+			return errorStyle.Render("⚠ .minecraft is a dangling symlink to " + m.active.Target + " (press 'r' to restore)")
+		}
+		return errorStyle.Render("⚠ .minecraft does not exist")
 	}
+	return dimStyle.Render("○ .minecraft is the default directory")
+}
 
-	content.WriteString(m.searchList.View())
-	content.WriteString("\n")
-	content.WriteString(dimStyle.Render("Press ESC to go back, / to filter, Enter to view details"))
+func (m model) viewConfirmForceSwitch() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Replace Foreign Symlink"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("⚠️  .minecraft is a symlink to %s,\n", m.active.Target))
+	content.WriteString("which is not an instance managed here.\n\n")
+	content.WriteString(fmt.Sprintf("Replace the link with one to '%s'? The folder it points to is not touched.\n\n", m.selectedInstance.Name))
+	content.WriteString(errorStyle.Render("Press 'y' to replace, 'n' to cancel"))
 
 	return content.String()
 }
@@ -1117,26 +1554,7 @@ func (m model) viewDetailPanel() string {
 	if terminalWidth == 0 {
 		terminalWidth = 120 // Default fallback
 	}
-	// Account for borders (2 chars) + padding (2 chars) per panel, but be less conservative
-	panelWidth := (terminalWidth / 3) - 2 // Minimal border accounting
-	if panelWidth < 15 {
-		panelWidth = 15 // Minimum usable width
-	}
-
-	// Apply active styles to titles
-	if m.activePanel == panelMods {
-		m.modsList.Styles.Title = titleStyle
-		m.configsList.Styles.Title = dimStyle
-		m.savesList.Styles.Title = dimStyle
-	} else if m.activePanel == panelConfigs {
-		m.modsList.Styles.Title = dimStyle
-		m.configsList.Styles.Title = titleStyle
-		m.savesList.Styles.Title = dimStyle
-	} else {
-		m.modsList.Styles.Title = dimStyle
-		m.configsList.Styles.Title = dimStyle
-		m.savesList.Styles.Title = titleStyle
-	}
+	panelWidth := detailPanelWidth(terminalWidth)
 
 	// Create header
 	header := titleStyle.Render(fmt.Sprintf("Instance Details: %s", m.selectedInstance.Name))
@@ -1154,40 +1572,86 @@ func (m model) viewDetailPanel() string {
 		BorderForeground(lipgloss.Color("#444444")).
 		Padding(0, 0)
 
-	// Apply appropriate styles based on active panel
-	var modsView, configsView, savesView string
-	if m.activePanel == panelMods {
-		modsView = activePanelStyle.Render(m.modsList.View())
-		configsView = inactivePanelStyle.Render(m.configsList.View())
-		savesView = inactivePanelStyle.Render(m.savesList.View())
-	} else if m.activePanel == panelConfigs {
-		modsView = inactivePanelStyle.Render(m.modsList.View())
-		configsView = activePanelStyle.Render(m.configsList.View())
-		savesView = inactivePanelStyle.Render(m.savesList.View())
-	} else {
-		modsView = inactivePanelStyle.Render(m.modsList.View())
-		configsView = inactivePanelStyle.Render(m.configsList.View())
-		savesView = activePanelStyle.Render(m.savesList.View())
+	// Highlight the active panel and its title
+	panels := []*list.Model{&m.modsList, &m.configsList, &m.savesList,
+		&m.resourcePacksList, &m.shaderPacksList, &m.serversList, &m.logsList}
+
+	// Show the window of panels that fit, keeping the active one in view
+	visible := visiblePanelCount(terminalWidth)
+	first := 0
+	if int(m.activePanel) >= visible {
+		first = int(m.activePanel) - visible + 1
+	}
+	var views []string
+	for i := first; i < first+visible; i++ {
+		l := panels[i]
+		if detailPanel(i) == m.activePanel {
+			l.Styles.Title = titleStyle
+			views = append(views, activePanelStyle.Render(l.View()))
+		} else {
+			l.Styles.Title = dimStyle
+			views = append(views, inactivePanelStyle.Render(l.View()))
+		}
 	}
 
-	// Join the three panels horizontally
-	panelsView := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		modsView,
-		configsView,
-		savesView,
-	)
+	// Join the panels horizontally
+	panelsView := lipgloss.JoinHorizontal(lipgloss.Top, views...)
+	if visible < len(panels) {
+		header += dimStyle.Render(fmt.Sprintf("  (panels %d-%d of %d)", first+1, first+visible, len(panels)))
+	}
 
 	// Instructions
-	instructions := dimStyle.Render("Tab/Shift+Tab to switch panels • 'd' to delete file • 'e' to edit config • ESC to go back • ↑/↓ to navigate")
+	instructions := dimStyle.Render("Tab/Shift+Tab to switch panels • 'd' to delete file • 'u' to undo delete • ESC to go back • ↑/↓ to navigate")
+	if m.activePanel == panelConfigs {
+		instructions = dimStyle.Render("Tab/Shift+Tab to switch panels • Enter to open folder • 'e' to edit config ('E' in $EDITOR) • / to search • 'd' to delete • 'u' to undo delete • ESC to go back")
+	}
+	if m.activePanel == panelSaves {
+		instructions = dimStyle.Render("Tab/Shift+Tab to switch panels • 'y' copy / 'm' move / 'n' rename world • 'd' to delete • 'u' to undo delete • ESC to go back")
+		if info := m.viewWorldInfo(); info != "" {
+			panelsView += "\n" + info
+		}
+	}
+	if m.activePanel == panelResourcePacks || m.activePanel == panelShaderPacks {
+		instructions = dimStyle.Render("Tab/Shift+Tab to switch panels • 't' to enable/disable pack • 'd' to delete • 'u' to undo delete • ESC to go back")
+		if info := m.viewPackInfo(); info != "" {
+			panelsView += "\n" + info
+		}
+	}
+	if m.activePanel == panelServers {
+		instructions = dimStyle.Render("Tab/Shift+Tab to switch panels • 'a' to add server • 'd' to remove server • ESC to go back • ↑/↓ to navigate")
+		if m.serversErr != nil {
+			panelsView += "\n" + errorStyle.Render(m.serversErr.Error())
+		}
+	}
+	if m.activePanel == panelLogs {
+		instructions = dimStyle.Render("Tab/Shift+Tab to switch panels • Enter to view log • ESC to go back • ↑/↓ to navigate")
+	}
 
 	return fmt.Sprintf("%s\n\n%s\n\n%s", header, panelsView, instructions)
 }
 
+// runOperation switches to the progress view and starts fn in the background.
+func (m model) runOperation(title, success string, returnTo state, fn opFunc) (tea.Model, tea.Cmd) {
+	if m.op != nil {
+		m.err = fmt.Errorf("another operation is still running")
+		return m, nil
+	}
+	op, cmd := startOperation(title, success, returnTo, fn)
+	m.op = op
+	m.err = nil
+	m.message = ""
+	m.state = stateOperation
+	return m, cmd
+}
+
 func refreshInstances() tea.Msg {
 	return refreshMsg{}
 }
 
+func undo() tea.Msg {
+	return undoMsg{}
+}
+
 func (m model) editConfigFile(configFileName string) tea.Cmd {
 	if m.selectedInstance == nil {
 		return nil
@@ -1197,12 +1661,14 @@ func (m model) editConfigFile(configFileName string) tea.Cmd {
 		Path: getEditor(),
 		Args: []string{
 			getEditor(),
-			filepath.Join(m.manager.InstancesPath, m.selectedInstance.Name, "config", configFileName),
+			m.configFilePath(configFileName),
 		},
 		Stdin:  os.Stdin,
 		Stdout: os.Stdout,
 		Stderr: os.Stderr,
-	}, nil)
+	}, func(err error) tea.Msg {
+		return editorClosedMsg{err}
+	})
 }
 
 func getEditor() string {
@@ -1226,68 +1692,6 @@ func getEditor() string {
 	return "vi"
 }
 
-func (m *model) buildSearchData() (*searchData, error) {
-	instances, err := m.manager.ListInstances()
-	if err != nil {
-		return nil, err
-	}
-
-	searchData := &searchData{
-		Instances: make(map[string][]searchItem),
-		AllItems:  []searchItem{},
-	}
-
-	for _, inst := range instances {
-		instanceItems := []searchItem{}
-
-		// Get detailed instance info
-		info, err := m.manager.GetInstanceInfo(inst.Name)
-		if err != nil {
-			continue // Skip this instance if we can't read it
-		}
-
-		// Add mods directory
-		if len(info.ModsDir) > 0 {
-			item := searchItem{
-				InstanceName: inst.Name,
-				Directory:    "mods",
-				Files:        info.ModsDir,
-				FileCount:    len(info.ModsDir),
-			}
-			instanceItems = append(instanceItems, item)
-			searchData.AllItems = append(searchData.AllItems, item)
-		}
-
-		// Add config directory
-		if len(info.ConfigsDir) > 0 {
-			item := searchItem{
-				InstanceName: inst.Name,
-				Directory:    "config",
-				Files:        info.ConfigsDir[:min(len(info.ConfigsDir), 20)], // Limit for display
-				FileCount:    len(info.ConfigsDir),
-			}
-			instanceItems = append(instanceItems, item)
-			searchData.AllItems = append(searchData.AllItems, item)
-		}
-
-		// Add saves directory
-		if len(info.SavesDir) > 0 {
-			item := searchItem{
-				InstanceName: inst.Name,
-				Directory:    "saves",
-				Files:        info.SavesDir,
-				FileCount:    len(info.SavesDir),
-			}
-			instanceItems = append(instanceItems, item)
-			searchData.AllItems = append(searchData.AllItems, item)
-		}
-
-		searchData.Instances[inst.Name] = instanceItems
-	}
-
-	return searchData, nil
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1295,33 +1699,21 @@ func min(a, b int) int {
 	return b
 }
 
-// deleteFile deletes a file from the appropriate directory
-func (m model) deleteFile(fileName, fileType string) error {
-	if m.selectedInstance == nil {
-		return fmt.Errorf("no instance selected")
-	}
-
-	var filePath string
-	instancePath := filepath.Join(m.manager.InstancesPath, m.selectedInstance.Name)
-
+// fileSection returns the instance folder that holds files of fileType.
+func fileSection(fileType string) (string, error) {
 	switch fileType {
 	case "mod":
-		filePath = filepath.Join(instancePath, "mods", fileName)
+		return "mods", nil
 	case "config":
-		filePath = filepath.Join(instancePath, "config", fileName)
+		return "config", nil
 	case "save":
-		filePath = filepath.Join(instancePath, "saves", fileName)
-	default:
-		return fmt.Errorf("unknown file type: %s", fileType)
-	}
-
-	// Check if file/directory exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("%s does not exist", fileName)
+		return "saves", nil
+	case "resource pack":
+		return "resourcepacks", nil
+	case "shader pack":
+		return "shaderpacks", nil
 	}
-
-	// Delete the file or directory
-	return os.RemoveAll(filePath)
+	return "", fmt.Errorf("unknown file type: %s", fileType)
 }
 
 // refreshDetailPanelLists refreshes all detail panel lists with current instance info
@@ -1331,10 +1723,7 @@ func (m *model) refreshDetailPanelLists() {
 	}
 
 	// Calculate proper width for file items
-	panelWidth := (m.terminalWidth / 3) - 2
-	if panelWidth < 15 {
-		panelWidth = 15
-	}
+	panelWidth := detailPanelWidth(m.terminalWidth)
 	itemMaxWidth := panelWidth - 4
 
 	// Refresh mods list
@@ -1349,17 +1738,8 @@ func (m *model) refreshDetailPanelLists() {
 	}
 	m.modsList.SetItems(modsItems)
 
-	// Refresh configs list
-	configsItems := make([]list.Item, len(m.instanceInfo.ConfigsDir))
-	for i, config := range m.instanceInfo.ConfigsDir {
-		configsItems[i] = fileItem{
-			Name:         config,
-			MaxWidth:     itemMaxWidth,
-			ScrollOffset: m.scrollOffset,
-			IsSelected:   false,
-		}
-	}
-	m.configsList.SetItems(configsItems)
+	// Refresh configs tree, listing everything while a filter is on
+	m.loadConfigTree(m.configsList.FilterState() != list.Unfiltered)
 
 	// Refresh saves list
 	savesItems := make([]list.Item, len(m.instanceInfo.SavesDir))
@@ -1372,6 +1752,10 @@ func (m *model) refreshDetailPanelLists() {
 		}
 	}
 	m.savesList.SetItems(savesItems)
+	m.loadWorlds()
+	m.loadServers()
+	m.loadPacks()
+	m.loadLogFiles()
 }
 
 // updateConfirmFileDelete handles file deletion confirmation
@@ -1395,7 +1779,7 @@ func (m model) viewConfirmFileDelete() string {
 	content.WriteString("\n\n")
 	content.WriteString(fmt.Sprintf("Are you sure you want to delete this %s?\n", m.fileType))
 	content.WriteString(fmt.Sprintf("File: %s\n", m.fileToDelete))
-	content.WriteString("This action cannot be undone.\n\n")
+	content.WriteString("It will be moved to the trash; press 'u' afterwards to undo.\n\n")
 	content.WriteString(errorStyle.Render("Press 'y' to confirm, 'n' or ESC to cancel"))
 
 	return content.String()
@@ -1415,12 +1799,16 @@ func (m model) updateConfigList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		selected := m.configList.SelectedItem().(configItem)
+		if selected.Key == "context" {
+			return m.openContextSwitcher()
+		}
 		m.editingKey = selected.Key
 		// prepare text input for editing
 		m.textInput.SetValue(selected.Value)
 		m.textInput.Placeholder = "Enter new value..."
 		m.textInput.CursorEnd()
 		m.textInput.Focus()
+		m.editError = nil
 		m.state = stateEditConfig
 		return m, nil
 	}
@@ -1436,6 +1824,17 @@ func (m model) updateEditConfig(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case key.Matches(msg, m.keys.Enter):
 		newVal := strings.TrimSpace(m.textInput.Value())
+		if m.editError = m.manager.ValidateConfigValue(m.editingKey, newVal); m.editError != nil {
+			// Keep editing; the error is shown below the input
+			return m, nil
+		}
+		if m.editingKey == "instances-path" && newVal != m.manager.InstancesPath && len(m.instances) > 0 {
+			// Ask whether the instances should come along
+			m.pendingInstancesPath = newVal
+			m.textInput.Blur()
+			m.state = stateConfirmMoveInstances
+			return m, nil
+		}
 		// attempt update
 		if err := m.manager.UpdateConfig(m.editingKey, newVal); err != nil {
 			m.err = err
@@ -1458,12 +1857,67 @@ func (m model) updateEditConfig(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
-	// update text input
+	// update text input and check the syntax as the user types; the disk
+	// is only looked at on Enter
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
+	m.editError = m.manager.CheckConfigValue(m.editingKey, strings.TrimSpace(m.textInput.Value()))
 	return m, cmd
 }
 
+func (m model) updateConfirmMoveInstances(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	newPath := m.pendingInstancesPath
+	switch msg.String() {
+	case "m", "M":
+		m.pendingInstancesPath = ""
+		opts := m.opts
+		return m.runOperation("Moving instances", "Moved instances to "+newPath, stateConfig,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				// The move changes its manager's paths, so it runs on one of
+				// its own; the model's is read by refreshes and the watcher
+				// meanwhile and is replaced once the move is done
+				manager, err := instance.NewManagerWithOptions(opts)
+				if err != nil {
+					return nil, err
+				}
+				if err := manager.MoveInstancesPath(ctx, newPath, progress); err != nil {
+					return nil, err
+				}
+				return manager, nil
+			})
+	case "k", "K":
+		m.pendingInstancesPath = ""
+		if err := m.manager.UpdateConfig("instances-path", newPath); err != nil {
+			m.err = err
+			m.state = stateConfig
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Updated instances-path -> %s (instances left in place)", newPath)
+		m.configList.SetItems(m.buildConfigListItems())
+		m.state = stateConfig
+		return m, refreshInstances
+	case "esc":
+		m.pendingInstancesPath = ""
+		m.state = stateConfig
+	}
+	return m, nil
+}
+
+func (m model) viewConfirmMoveInstances() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Change Instances Directory"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("From: %s\n", m.manager.InstancesPath))
+	content.WriteString(fmt.Sprintf("To:   %s\n\n", m.pendingInstancesPath))
+	content.WriteString(fmt.Sprintf("There are %d instance(s) in the current directory.\n\n", len(m.instances)))
+	content.WriteString("• m: move them and update the active .minecraft link\n")
+	content.WriteString("• k: keep them where they are and only change the setting\n\n")
+	content.WriteString(dimStyle.Render("An interrupted move resumes when you choose m again • ESC to cancel"))
+
+	return content.String()
+}
+
 // NEW: build config list items from current manager config
 func (m model) buildConfigListItems() []list.Item {
 	if m.manager == nil {
@@ -1480,11 +1934,11 @@ func (m model) buildConfigListItems() []list.Item {
 	}
 
 	items := make([]list.Item, 0, len(cfg))
-	// deterministic order: minecraft-path, instances-path, backup-path, app-dir, config-file
-	keysOrder := []string{"minecraft-path", "instances-path", "backup-path", "app-dir", "config-file"}
+	// deterministic order as defined by the instance package
+	keysOrder := instance.ConfigKeys
 	for _, k := range keysOrder {
 		if v, ok := cfg[k]; ok {
-			items = append(items, configItem{Key: k, Value: v})
+			items = append(items, configItem{Key: k, Value: v, Source: m.manager.ConfigSource(k)})
 		}
 	}
 	// include any other keys not in the manual order
@@ -1525,7 +1979,7 @@ func (m model) viewConfig() string {
 
 	content.WriteString(m.configList.View())
 	content.WriteString("\n\n")
-	content.WriteString(dimStyle.Render("Enter to edit • ESC to go back • / to filter • q to quit"))
+	content.WriteString(dimStyle.Render("Enter to edit (on context: switch context) • ESC to go back • / to filter • q to quit"))
 	return content.String()
 }
 
@@ -1535,6 +1989,12 @@ func (m model) viewEditConfig() string {
 	content.WriteString("\n\n")
 	content.WriteString("Value:\n")
 	content.WriteString(m.textInput.View())
+	content.WriteString("\n")
+	if m.editError != nil {
+		content.WriteString(errorStyle.Render("✗ " + m.editError.Error()))
+	} else {
+		content.WriteString(successStyle.Render("✓ valid"))
+	}
 	content.WriteString("\n\n")
 	content.WriteString(dimStyle.Render("Enter to save • ESC to cancel"))
 	return content.String()
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// operation is a long-running Manager call executed outside of Update. The
+// worker goroutine feeds opProgressMsg and a final opDoneMsg into updates.
+type operation struct {
+	title    string
+	cancel   context.CancelFunc
+	updates  chan tea.Msg
+	progress instance.CopyProgress
+	// returnTo is the state shown once the operation has finished
+	returnTo state
+	// cancelling is set once the user asked to abort
+	cancelling bool
+}
+
+type opProgressMsg struct{ progress instance.CopyProgress }
+
+type opDoneMsg struct {
+	success string // message shown when err is nil
+	result  any    // optional value returned by the operation
+	err     error
+}
+
+// opFunc is the work done by an operation. The result is passed on in
+// opDoneMsg, e.g. the trash entry of a deleted instance.
+type opFunc func(ctx context.Context, progress instance.ProgressFunc) (any, error)
+
+// startOperation runs fn in the background and returns the operation along
+// with the command that delivers its first message.
+func startOperation(title, success string, returnTo state, fn opFunc) (*operation, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &operation{
+		title:    title,
+		cancel:   cancel,
+		updates:  make(chan tea.Msg, 1),
+		returnTo: returnTo,
+	}
+
+	go func() {
+		defer cancel()
+		result, err := fn(ctx, func(p instance.CopyProgress) {
+			// Never block the worker on the UI; a dropped update is
+			// superseded by the next one
+			select {
+			case op.updates <- opProgressMsg{p}:
+			default:
+			}
+		})
+		if err != nil && ctx.Err() != nil {
+			err = fmt.Errorf("%s cancelled", strings.ToLower(title))
+		}
+		op.updates <- opDoneMsg{success: success, result: result, err: err}
+	}()
+
+	return op, op.wait()
+}
+
+// wait returns a command that blocks until the operation's next message.
+func (op *operation) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-op.updates
+	}
+}
+
+func newProgressBar() progress.Model {
+	return progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
+}
+
+func (m model) updateOperation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.op == nil {
+		m.state = stateList
+		return m, nil
+	}
+	if msg.String() == "esc" && !m.op.cancelling {
+		m.op.cancelling = true
+		m.op.cancel()
+	}
+	return m, nil
+}
+
+func (m model) viewOperation() string {
+	if m.op == nil {
+		return ""
+	}
+
+	var content strings.Builder
+	p := m.op.progress
+
+	content.WriteString(titleStyle.Render(m.op.title))
+	content.WriteString("\n\n")
+	content.WriteString(m.progressBar.ViewAs(p.Percent()))
+	content.WriteString(fmt.Sprintf(" %3.0f%%\n\n", p.Percent()*100))
+	content.WriteString(fmt.Sprintf("Files: %d/%d\n", p.FilesDone, p.FilesTotal))
+	content.WriteString(fmt.Sprintf("Data:  %s/%s\n", formatBytes(p.BytesDone), formatBytes(p.BytesTotal)))
+	if eta := p.ETA(); eta > 0 {
+		content.WriteString(fmt.Sprintf("ETA:   %s\n", eta.Round(time.Second)))
+	}
+	if p.Current != "" {
+		content.WriteString(dimStyle.Render(p.Current))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
+	if m.op.cancelling {
+		content.WriteString(errorStyle.Render("Cancelling..."))
+	} else {
+		content.WriteString(dimStyle.Render("Press ESC to cancel"))
+	}
+	return content.String()
+}
+
+// formatBytes renders a byte count using binary units (KiB, MiB, ...).
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
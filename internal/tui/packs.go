@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/packs"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// packItem is one entry of the resource pack and shader pack panels.
+type packItem struct {
+	packs.Pack
+}
+
+func (p packItem) FilterValue() string { return p.Name }
+func (p packItem) Title() string {
+	title := p.Name
+	if p.Warning != "" {
+		title = "⚠ " + title
+	}
+	return title
+}
+func (p packItem) Description() string {
+	var parts []string
+	if !p.Enabled {
+		parts = append(parts, "disabled")
+	}
+	if p.PackFormat > 0 {
+		parts = append(parts, fmt.Sprintf("format %d", p.PackFormat))
+	}
+	if len(parts) == 0 {
+		return "enabled"
+	}
+	return strings.Join(parts, " • ")
+}
+
+// loadPacks fills the pack panels from the selected instance.
+func (m *model) loadPacks() {
+	m.resourcePacksList.SetItems(nil)
+	m.shaderPacksList.SetItems(nil)
+	if m.selectedInstance == nil {
+		return
+	}
+	for _, l := range []struct {
+		kind packs.Kind
+		list *list.Model
+	}{
+		{packs.KindResource, &m.resourcePacksList},
+		{packs.KindShader, &m.shaderPacksList},
+	} {
+		found, err := packs.List(m.selectedInstance.Path, l.kind)
+		if err != nil {
+			m.err = err
+			continue
+		}
+		items := make([]list.Item, len(found))
+		for i, p := range found {
+			items[i] = packItem{p}
+		}
+		l.list.SetItems(items)
+	}
+}
+
+// selectedPack returns the pack selected in the active pack panel.
+func (m model) selectedPack() *packs.Pack {
+	var item list.Item
+	switch m.activePanel {
+	case panelResourcePacks:
+		item = m.resourcePacksList.SelectedItem()
+	case panelShaderPacks:
+		item = m.shaderPacksList.SelectedItem()
+	}
+	if p, ok := item.(packItem); ok {
+		return &p.Pack
+	}
+	return nil
+}
+
+// togglePack enables the selected pack if it is disabled and disables it
+// otherwise.
+func (m *model) togglePack() {
+	p := m.selectedPack()
+	if p == nil {
+		return
+	}
+	if _, err := packs.SetEnabled(*p, !p.Enabled); err != nil {
+		m.err = err
+		return
+	}
+	m.err = nil
+	if p.Enabled {
+		m.message = fmt.Sprintf("Disabled %s: %s", p.Kind.Label(), p.Name)
+	} else {
+		m.message = fmt.Sprintf("Enabled %s: %s", p.Kind.Label(), p.Name)
+	}
+	m.loadPacks()
+}
+
+// viewPackInfo describes the selected pack below the panels.
+func (m model) viewPackInfo() string {
+	p := m.selectedPack()
+	if p == nil {
+		return ""
+	}
+	var lines []string
+	if p.Description != "" {
+		lines = append(lines, dimStyle.Render(strings.ReplaceAll(p.Description, "\n", " ")))
+	}
+	if p.Warning != "" {
+		lines = append(lines, errorStyle.Render("⚠ "+p.Warning))
+	}
+	return strings.Join(lines, "\n")
+}
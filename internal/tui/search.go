@@ -0,0 +1,368 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/modconfig"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/search"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchLimit is the number of results the search screen shows at most.
+const searchLimit = 200
+
+// searchResultItem is one match of the search screen.
+type searchResultItem struct {
+	search.Result
+}
+
+func (r searchResultItem) FilterValue() string { return r.Name }
+func (r searchResultItem) Title() string {
+	if r.Kind == search.KindConfigText {
+		return r.Name
+	}
+	if r.Kind == search.KindInstance {
+		return r.Instance
+	}
+	return r.Instance + " › " + r.Path
+}
+func (r searchResultItem) Description() string {
+	if r.Kind == search.KindConfigText {
+		return fmt.Sprintf("%s › %s:%d", r.Instance, r.Path, r.Line)
+	}
+	if r.Detail != "" {
+		return string(r.Kind) + " • " + r.Detail
+	}
+	return string(r.Kind)
+}
+
+// indexer updates the search index in the background. Like an operation,
+// its goroutine feeds indexProgressMsg and a final indexDoneMsg into
+// updates; the messages name the indexer so ones from a replaced indexer
+// are dropped.
+type indexer struct {
+	index   *search.Index
+	cancel  context.CancelFunc
+	updates chan tea.Msg
+	running bool
+	done    int
+	total   int
+	current string
+	err     error
+}
+
+type indexProgressMsg struct {
+	ix          *indexer
+	done, total int
+	name        string
+}
+
+type indexDoneMsg struct {
+	ix  *indexer
+	err error
+}
+
+// wait returns a command that blocks until the indexer's next message.
+func (ix *indexer) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-ix.updates
+	}
+}
+
+// startIndexing updates the search index of all instances in the
+// background. The index is kept between visits of the search screen; the
+// jar cache makes later updates cheap. configText rebuilds it with or
+// without the text of config files.
+func (m *model) startIndexing(configText bool) tea.Cmd {
+	ix := m.indexer
+	if ix != nil && ix.index.ConfigText != configText {
+		ix.cancel()
+		ix = nil
+	}
+	if ix != nil && ix.running {
+		return nil
+	}
+	if ix == nil {
+		cachePath := filepath.Join(m.manager.AppDir, search.CacheFileName)
+		ix = &indexer{index: search.New(cachePath, search.Options{ConfigText: configText})}
+	}
+	instances, err := m.manager.ListInstances()
+	if err != nil {
+		ix.err = err
+		m.indexer = ix
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ix.cancel = cancel
+	ix.updates = make(chan tea.Msg, 1)
+	ix.running = true
+	ix.err = nil
+	ix.done, ix.total, ix.current = 0, len(instances), ""
+	m.indexer = ix
+
+	updates, index := ix.updates, ix.index
+	go func() {
+		defer cancel()
+		err := index.Update(ctx, instances, func(done, total int, name string) {
+			// Never block the indexer on the UI
+			select {
+			case updates <- indexProgressMsg{ix, done, total, name}:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			err = nil // replaced by another update
+		}
+		updates <- indexDoneMsg{ix, err}
+	}()
+	return ix.wait()
+}
+
+// openSearch shows the search screen and brings the index up to date.
+func (m model) openSearch() (tea.Model, tea.Cmd) {
+	if m.manager == nil {
+		return m, nil
+	}
+	configText := m.indexer != nil && m.indexer.index.ConfigText
+	cmd := m.startIndexing(configText)
+	m.err = nil
+	m.message = ""
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = "Search mods, configs, worlds and packs..."
+	m.textInput.Focus()
+	m.runSearch()
+	m.state = stateSearch
+	return m, cmd
+}
+
+// runSearch fills the result list for the query typed so far.
+func (m *model) runSearch() {
+	if m.indexer == nil {
+		return
+	}
+	results := m.indexer.index.Search(m.textInput.Value(), searchLimit)
+	items := make([]list.Item, len(results))
+	for i, r := range results {
+		items[i] = searchResultItem{r}
+	}
+	m.searchList.SetItems(items)
+	m.searchList.Select(0)
+}
+
+// updateIndexing handles the messages of a background index update.
+func (m model) updateIndexing(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case indexProgressMsg:
+		if msg.ix != m.indexer {
+			return m, nil
+		}
+		msg.ix.done, msg.ix.total, msg.ix.current = msg.done, msg.total, msg.name
+		if m.state == stateSearch {
+			// Show what the instances indexed so far contain
+			index := m.searchList.Index()
+			m.runSearch()
+			m.searchList.Select(index)
+		}
+		return m, msg.ix.wait()
+	case indexDoneMsg:
+		if msg.ix != m.indexer {
+			return m, nil
+		}
+		msg.ix.running = false
+		msg.ix.err = msg.err
+		if m.state == stateSearch {
+			index := m.searchList.Index()
+			m.runSearch()
+			m.searchList.Select(index)
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.textInput.Blur()
+		m.state = stateList
+		return m, nil
+	case "tab":
+		// Rebuild the index with or without the lines of config files
+		cmd := m.startIndexing(!m.indexer.index.ConfigText)
+		m.runSearch()
+		return m, cmd
+	case "enter":
+		item, ok := m.searchList.SelectedItem().(searchResultItem)
+		if !ok {
+			return m, nil
+		}
+		m.textInput.Blur()
+		return m.openSearchResult(item.Result)
+	case "up", "down", "pgup", "pgdown":
+		var cmd tea.Cmd
+		m.searchList, cmd = m.searchList.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	query := m.textInput.Value()
+	m.textInput, cmd = m.textInput.Update(msg)
+	if m.textInput.Value() != query {
+		m.runSearch()
+	}
+	return m, cmd
+}
+
+// openSearchResult shows the detail panels of the result's instance with
+// the matching item selected. A line of a config file the built-in editor
+// reads opens the editor at that line.
+func (m model) openSearchResult(r search.Result) (tea.Model, tea.Cmd) {
+	if err := m.showInstance(r.Instance); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.err = nil
+	m.state = stateDetailPanel
+
+	switch r.Kind {
+	case search.KindMod:
+		m.activePanel = panelMods
+		selectItem(&m.modsList, func(item list.Item) bool {
+			f, ok := item.(fileItem)
+			return ok && f.Name == path.Base(r.Path)
+		})
+	case search.KindConfig, search.KindConfigText:
+		m.activePanel = panelConfigs
+		file := r.ConfigFile()
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			m.configExpanded[dir] = true
+		}
+		m.loadConfigTree(false)
+		selectItem(&m.configsList, func(item list.Item) bool {
+			c, ok := item.(configFileItem)
+			return ok && c.File.Path == file
+		})
+		if _, ok := modconfig.FormatOf(file); ok && r.Kind == search.KindConfigText {
+			return m.openModConfigAt(file, r.Line)
+		}
+	case search.KindWorld:
+		m.activePanel = panelSaves
+		selectItem(&m.savesList, func(item list.Item) bool {
+			f, ok := item.(fileItem)
+			return ok && f.Name == path.Base(r.Path)
+		})
+	case search.KindResourcePack, search.KindShaderPack:
+		l := &m.resourcePacksList
+		m.activePanel = panelResourcePacks
+		if r.Kind == search.KindShaderPack {
+			l = &m.shaderPacksList
+			m.activePanel = panelShaderPacks
+		}
+		selectItem(l, func(item list.Item) bool {
+			p, ok := item.(packItem)
+			return ok && p.File == path.Base(r.Path)
+		})
+	default:
+		m.activePanel = panelMods
+	}
+	m.updateItemSelectionState(m.activePanel)
+	return m, nil
+}
+
+// openModConfigAt opens a config file in the built-in editor with the last
+// entry starting at or before line selected.
+func (m model) openModConfigAt(name string, line int) (tea.Model, tea.Cmd) {
+	next, cmd := m.openModConfig(name)
+	m = next.(model)
+	for i, e := range m.modConfigList.Items() {
+		if item, ok := e.(modConfigItem); ok && item.Line <= line {
+			m.modConfigList.Select(i)
+		}
+	}
+	return m, cmd
+}
+
+// selectItem selects the first item of l that match accepts.
+func selectItem(l *list.Model, match func(list.Item) bool) {
+	for i, item := range l.Items() {
+		if match(item) {
+			l.Select(i)
+			return
+		}
+	}
+}
+
+// showInstance loads the detail panels of the named instance.
+func (m *model) showInstance(name string) error {
+	var inst *instance.Instance
+	for i := range m.instances {
+		if m.instances[i].Name == name {
+			inst = &m.instances[i]
+		}
+	}
+	if inst == nil {
+		return fmt.Errorf("instance '%s' not found", name)
+	}
+	info, err := m.manager.GetInstanceInfo(name)
+	if err != nil {
+		return err
+	}
+	m.selectedInstance = inst
+	m.instanceInfo = info
+	m.configsList.ResetFilter()
+	m.configExpanded = make(map[string]bool)
+	m.refreshDetailPanelLists()
+	for _, l := range []*list.Model{&m.modsList, &m.configsList, &m.savesList,
+		&m.resourcePacksList, &m.shaderPacksList, &m.serversList, &m.logsList} {
+		l.Select(0)
+	}
+	return nil
+}
+
+func (m model) viewSearch() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Search All Instances"))
+	content.WriteString("\n\n")
+	if m.err != nil {
+		content.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		content.WriteString("\n\n")
+	}
+	content.WriteString(m.textInput.View())
+	content.WriteString("\n")
+
+	ix := m.indexer
+	var status string
+	switch {
+	case ix == nil:
+	case ix.err != nil:
+		status = errorStyle.Render(fmt.Sprintf("Error: %v", ix.err))
+	case ix.running && ix.current != "":
+		status = dimStyle.Render(fmt.Sprintf("Indexing... %d/%d instances (%s)", ix.done, ix.total, ix.current))
+	case ix.running:
+		status = dimStyle.Render("Indexing...")
+	default:
+		status = dimStyle.Render(fmt.Sprintf("%d items indexed", ix.index.Len()))
+	}
+	if ix != nil && ix.index.ConfigText {
+		status += dimStyle.Render(" • including config text")
+	}
+	content.WriteString(status)
+	content.WriteString("\n")
+
+	if strings.TrimSpace(m.textInput.Value()) != "" && len(m.searchList.Items()) == 0 {
+		content.WriteString(dimStyle.Render("No matches"))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(m.searchList.View())
+		content.WriteString("\n")
+	}
+	content.WriteString(dimStyle.Render("Type to search • ↑/↓ to choose • Enter to open • Tab to include config text • ESC to go back"))
+	return content.String()
+}
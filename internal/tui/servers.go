@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/servers"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// serverItem is one entry of the servers panel.
+type serverItem struct {
+	servers.Server
+}
+
+func (s serverItem) FilterValue() string { return s.Name + " " + s.Address }
+func (s serverItem) Title() string       { return s.Name }
+func (s serverItem) Description() string {
+	if s.Hidden {
+		return s.Address + " (hidden)"
+	}
+	return s.Address
+}
+
+// loadServers fills the servers panel from the selected instance's
+// servers.dat.
+func (m *model) loadServers() {
+	m.serversList.SetItems(nil)
+	m.serversErr = nil
+	if m.selectedInstance == nil {
+		return
+	}
+	l, err := servers.Load(servers.Path(m.selectedInstance.Path))
+	if err != nil {
+		m.serversErr = err
+		return
+	}
+	entries := l.Servers()
+	items := make([]list.Item, len(entries))
+	for i, s := range entries {
+		items[i] = serverItem{s}
+	}
+	m.serversList.SetItems(items)
+}
+
+// editServers loads the selected instance's server list, applies edit and
+// saves it.
+func (m model) editServers(edit func(*servers.List) error) error {
+	path := servers.Path(m.selectedInstance.Path)
+	l, err := servers.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(l); err != nil {
+		return err
+	}
+	return l.Save(path)
+}
+
+// startServerAdd asks for the name of a new server, then its address.
+func (m model) startServerAdd() (tea.Model, tea.Cmd) {
+	m.newServer = servers.Server{}
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = "Server name..."
+	m.textInput.Focus()
+	m.editError = nil
+	m.state = stateServerAdd
+	return m, nil
+}
+
+func (m model) updateServerAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.textInput.Blur()
+		m.state = stateDetailPanel
+		return m, nil
+	case key.Matches(msg, m.keys.Enter):
+		value := strings.TrimSpace(m.textInput.Value())
+		if value == "" {
+			return m, nil
+		}
+		if m.newServer.Name == "" {
+			m.newServer.Name = value
+			m.textInput.SetValue("")
+			m.textInput.Placeholder = "Address, e.g. mc.example.org:25565..."
+			return m, nil
+		}
+		m.newServer.Address = value
+		if err := m.editServers(func(l *servers.List) error { return l.Add(m.newServer) }); err != nil {
+			m.editError = err
+			return m, nil
+		}
+		m.textInput.Blur()
+		m.err = nil
+		m.message = fmt.Sprintf("Added server %s (%s)", m.newServer.Name, m.newServer.Address)
+		m.loadServers()
+		m.state = stateDetailPanel
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewServerAdd() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Add Server"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("Instance: %s\n\n", m.selectedInstance.Name))
+	if m.newServer.Name == "" {
+		content.WriteString("Name:\n")
+	} else {
+		content.WriteString(fmt.Sprintf("Name: %s\nAddress:\n", m.newServer.Name))
+	}
+	content.WriteString(m.textInput.View())
+	content.WriteString("\n")
+	if m.editError != nil {
+		content.WriteString(errorStyle.Render("✗ " + m.editError.Error()))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(dimStyle.Render("Enter to continue • ESC to cancel"))
+	return content.String()
+}
+
+func (m model) updateConfirmServerRemove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		address := m.serverToRemove.Address
+		if err := m.editServers(func(l *servers.List) error {
+			_, err := l.Remove(address)
+			return err
+		}); err != nil {
+			m.err = err
+		} else {
+			m.err = nil
+			m.message = fmt.Sprintf("Removed server %s (%s)", m.serverToRemove.Name, address)
+			m.loadServers()
+		}
+		m.state = stateDetailPanel
+	case "n", "N", "esc":
+		m.state = stateDetailPanel
+	}
+	return m, nil
+}
+
+func (m model) viewConfirmServerRemove() string {
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Confirm Server Removal"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("Remove %s (%s) from the server list of %s?\n\n",
+		m.serverToRemove.Name, m.serverToRemove.Address, m.selectedInstance.Name))
+	content.WriteString(errorStyle.Render("Press 'y' to confirm, 'n' or ESC to cancel"))
+	return content.String()
+}
@@ -4,13 +4,18 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func RunTUI() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+func RunTUI(opts instance.Options) {
+	p := tea.NewProgram(initialModel(opts), tea.WithAltScreen())
 	
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if fm, ok := final.(model); ok && fm.watcher != nil {
+		fm.watcher.Close()
+	}
+	if err != nil {
 		fmt.Printf("Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/crash"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// watchDebounce is how long the filesystem has to stay quiet before a
+	// refresh is triggered
+	watchDebounce = 200 * time.Millisecond
+	// watchMaxDelay bounds the delay during a continuous stream of events,
+	// e.g. while a large instance is being copied
+	watchMaxDelay = time.Second
+)
+
+// watchedSubdirs are the instance folders whose contents feed the counts,
+// detail panels and crash badges.
+var watchedSubdirs = []string{"mods", "config", "saves", "resourcepacks", "shaderpacks", crash.ReportsDirName}
+
+// fsChangedMsg is sent (debounced) after something relevant changed on disk.
+// It names its watcher so that messages from a replaced one are ignored.
+type fsChangedMsg struct{ watcher *fsWatcher }
+
+// fsWatcher turns filesystem events below InstancesPath and around
+// MinecraftPath into fsChangedMsg values for the Bubble Tea program.
+type fsWatcher struct {
+	watcher *fsnotify.Watcher
+	manager *instance.Manager
+	changes chan tea.Msg
+
+	mu      sync.Mutex
+	watched map[string]bool
+}
+
+func newFSWatcher(manager *instance.Manager) (*fsWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw := &fsWatcher{
+		watcher: w,
+		manager: manager,
+		changes: make(chan tea.Msg, 1),
+		watched: make(map[string]bool),
+	}
+	fw.sync(nil)
+	go fw.run()
+	return fw, nil
+}
+
+// sync adjusts the watch list to the given instances. Directories that do not
+// exist (yet) are skipped; they are picked up by a later sync.
+func (fw *fsWatcher) sync(instances []instance.Instance) {
+	want := map[string]bool{
+		fw.manager.InstancesPath: true,
+		// The symlink itself cannot be watched without following it, so
+		// watch its parent and filter for its name in relevant()
+		filepath.Dir(fw.manager.MinecraftPath): true,
+	}
+	for _, inst := range instances {
+		want[inst.Path] = true
+		for _, sub := range watchedSubdirs {
+			want[filepath.Join(inst.Path, sub)] = true
+		}
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for path := range fw.watched {
+		if !want[path] {
+			fw.watcher.Remove(path)
+			delete(fw.watched, path)
+		}
+	}
+	for path := range want {
+		if fw.watched[path] {
+			continue
+		}
+		if err := fw.watcher.Add(path); err == nil {
+			fw.watched[path] = true
+		}
+	}
+}
+
+// relevant filters out events from the MinecraftPath parent (usually the home
+// directory) that do not concern MinecraftPath itself.
+func (fw *fsWatcher) relevant(ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	mcParent := filepath.Dir(fw.manager.MinecraftPath)
+	if filepath.Dir(ev.Name) == mcParent && ev.Name != fw.manager.MinecraftPath {
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+		// Still relevant if the parent directory doubles as a watched
+		// instance folder
+		return fw.watched[ev.Name]
+	}
+	return true
+}
+
+func (fw *fsWatcher) run() {
+	// Release the pending wait once the watcher is closed
+	defer close(fw.changes)
+
+	var (
+		timer      *time.Timer
+		timerC     <-chan time.Time
+		firstEvent time.Time
+	)
+
+	fire := func() {
+		timerC = nil
+		firstEvent = time.Time{}
+		// Coalesce with a refresh that has not been picked up yet
+		select {
+		case fw.changes <- fsChangedMsg{watcher: fw}:
+		default:
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			if !fw.relevant(ev) {
+				continue
+			}
+			if firstEvent.IsZero() {
+				firstEvent = time.Now()
+			}
+			if time.Since(firstEvent) >= watchMaxDelay {
+				if timer != nil {
+					timer.Stop()
+				}
+				fire()
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(watchDebounce)
+			} else {
+				timer.Reset(watchDebounce)
+			}
+			timerC = timer.C
+
+		case <-timerC:
+			fire()
+
+		case _, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// wait returns a command that blocks until the next debounced change, or
+// returns nil once the watcher is closed.
+func (fw *fsWatcher) wait() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-fw.changes
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func (fw *fsWatcher) Close() error {
+	return fw.watcher.Close()
+}
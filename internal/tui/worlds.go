@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/worlds"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// World actions of the saves panel that ask for a name first.
+const (
+	worldActionCopy   = "copy"
+	worldActionMove   = "move"
+	worldActionRename = "rename"
+)
+
+// loadWorlds reads level.dat of every world of the selected instance for
+// the info line below the saves panel.
+func (m *model) loadWorlds() {
+	m.worlds = nil
+	if m.selectedInstance == nil {
+		return
+	}
+	list, err := worlds.List(worlds.SavesDir(m.selectedInstance.Path))
+	if err != nil {
+		return
+	}
+	m.worlds = make(map[string]worlds.World, len(list))
+	for _, w := range list {
+		m.worlds[w.Folder] = w
+	}
+}
+
+// selectedWorld returns the folder selected in the saves panel, or "".
+func (m model) selectedWorld() string {
+	if item, ok := m.savesList.SelectedItem().(fileItem); ok {
+		return item.Name
+	}
+	return ""
+}
+
+// viewWorldInfo summarizes the selected world's level.dat.
+func (m model) viewWorldInfo() string {
+	w, ok := m.worlds[m.selectedWorld()]
+	if !ok {
+		return ""
+	}
+	if w.Error != "" {
+		return errorStyle.Render(fmt.Sprintf("%s: %s", w.Folder, w.Error))
+	}
+	parts := []string{w.Name, w.GameMode}
+	if w.Hardcore {
+		parts[1] += " (hardcore)"
+	}
+	if w.Version != "" {
+		parts = append(parts, w.Version)
+	}
+	parts = append(parts, fmt.Sprintf("seed %d", w.Seed), formatBytes(w.SizeBytes))
+	if !w.LastPlayed.IsZero() {
+		parts = append(parts, "last played "+w.LastPlayed.Local().Format("2006-01-02 15:04"))
+	}
+	return subtitleStyle.Render(strings.Join(parts, " • "))
+}
+
+// startWorldAction asks for the target instance or new folder name of the
+// selected world.
+func (m model) startWorldAction(action string) (tea.Model, tea.Cmd) {
+	folder := m.selectedWorld()
+	if m.activePanel != panelSaves || folder == "" {
+		return m, nil
+	}
+	m.worldAction = action
+	m.worldFolder = folder
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = "Target instance..."
+	if action == worldActionRename {
+		m.textInput.SetValue(folder)
+		m.textInput.Placeholder = "New folder name..."
+	}
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+	m.editError = nil
+	m.state = stateWorldAction
+	return m, nil
+}
+
+func (m model) updateWorldAction(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.textInput.Blur()
+		m.state = stateDetailPanel
+		return m, nil
+	case key.Matches(msg, m.keys.Enter):
+		value := strings.TrimSpace(m.textInput.Value())
+		if value == "" {
+			return m, nil
+		}
+		m.textInput.Blur()
+		return m.runWorldAction(value)
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) runWorldAction(value string) (tea.Model, tea.Cmd) {
+	src := filepath.Join(worlds.SavesDir(m.selectedInstance.Path), m.worldFolder)
+	folder := m.worldFolder
+
+	if m.worldAction == worldActionRename {
+		m.state = stateDetailPanel
+		if _, err := worlds.Rename(src, value); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.err = nil
+		m.message = fmt.Sprintf("Renamed world %s -> %s", folder, value)
+		m.refreshInstanceInfo()
+		return m, nil
+	}
+
+	target, err := m.manager.LookupInstance(value)
+	if err != nil {
+		m.editError = err
+		m.textInput.Focus()
+		return m, nil
+	}
+	dstSaves := worlds.SavesDir(target)
+	if m.worldAction == worldActionMove {
+		return m.runOperation("Moving "+folder, fmt.Sprintf("Moved world %s to %s", folder, value), stateDetailPanel,
+			func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+				_, err := worlds.Move(ctx, src, dstSaves, "", progress)
+				return nil, err
+			})
+	}
+	return m.runOperation("Copying "+folder, fmt.Sprintf("Copied world %s to %s", folder, value), stateDetailPanel,
+		func(ctx context.Context, progress instance.ProgressFunc) (any, error) {
+			_, err := worlds.Copy(ctx, src, dstSaves, "", progress)
+			return nil, err
+		})
+}
+
+// refreshInstanceInfo reloads the detail panels of the selected instance.
+func (m *model) refreshInstanceInfo() {
+	if m.selectedInstance == nil {
+		return
+	}
+	if info, err := m.manager.GetInstanceInfo(m.selectedInstance.Name); err == nil {
+		m.instanceInfo = info
+		m.refreshDetailPanelLists()
+	}
+}
+
+func (m model) viewWorldAction() string {
+	var content strings.Builder
+
+	titles := map[string]string{
+		worldActionCopy:   "Copy World",
+		worldActionMove:   "Move World",
+		worldActionRename: "Rename World",
+	}
+	content.WriteString(titleStyle.Render(titles[m.worldAction]))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("World: %s (instance %s)\n\n", m.worldFolder, m.selectedInstance.Name))
+	if m.worldAction == worldActionRename {
+		content.WriteString("New folder name:\n")
+	} else {
+		content.WriteString(fmt.Sprintf("%s to instance:\n", strings.ToUpper(m.worldAction[:1])+m.worldAction[1:]))
+	}
+	content.WriteString(m.textInput.View())
+	content.WriteString("\n")
+	if m.editError != nil {
+		content.WriteString(errorStyle.Render("✗ " + m.editError.Error()))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(dimStyle.Render("Enter to confirm • ESC to cancel"))
+	return content.String()
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package worlds
+
+import (
+	"os"
+	"syscall"
+)
+
+// sessionLocked reports whether another process holds a lock on the
+// session.lock file at path. The game locks it with fcntl for as long as
+// the world is open.
+func sessionLocked(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK}
+	if err := syscall.FcntlFlock(f.Fd(), syscall.F_GETLK, &lk); err != nil {
+		return false, err
+	}
+	return lk.Type != syscall.F_UNLCK, nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package worlds
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errorLockViolation is returned when reading a byte range another process
+// has locked.
+const errorLockViolation syscall.Errno = 33
+
+// sessionLocked reports whether another process holds a lock on the
+// session.lock file at path. The game locks it for as long as the world is
+// open, which makes reads of the locked range fail.
+func sessionLocked(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var b [1]byte
+	if _, err := f.Read(b[:]); err != nil && errors.Is(err, errorLockViolation) {
+		return true, nil
+	}
+	return false, nil
+}
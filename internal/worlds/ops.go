@@ -0,0 +1,172 @@
+package worlds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/nbt"
+)
+
+// SessionLockName is the lock file the game holds while a world is open.
+const SessionLockName = "session.lock"
+
+// copyExcludes are skipped when a world is copied; a copied lock would make
+// the new world look open.
+var copyExcludes = []string{SessionLockName}
+
+// validFolder rejects world folder names that are not a single path element.
+func validFolder(folder string) error {
+	if folder == "" || folder == "." || folder == ".." || strings.ContainsAny(folder, `/\`) {
+		return fmt.Errorf("invalid world folder name %q", folder)
+	}
+	return nil
+}
+
+// transferPaths checks that src is a world and that folder is free in
+// dstSavesDir, and returns the destination path.
+func transferPaths(src, dstSavesDir, folder string) (string, error) {
+	if folder == "" {
+		folder = filepath.Base(src)
+	}
+	if err := validFolder(folder); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", fmt.Errorf("world '%s' does not exist", filepath.Base(src))
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a world directory", src)
+	}
+	dst := filepath.Join(dstSavesDir, folder)
+	if filepath.Clean(dst) == filepath.Clean(src) {
+		return "", fmt.Errorf("source and destination are the same world")
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("a world named '%s' already exists in %s", folder, dstSavesDir)
+	}
+	return dst, nil
+}
+
+// PlanCopy returns the steps Copy would perform.
+func PlanCopy(src, dstSavesDir, folder string) (*instance.Plan, error) {
+	dst, err := transferPaths(src, dstSavesDir, folder)
+	if err != nil {
+		return nil, err
+	}
+	return &instance.Plan{Action: "copy-world", Steps: []instance.Step{
+		{Op: instance.OpMkdir, Path: dstSavesDir},
+		{Op: instance.OpCopy, Path: src, Target: dst, Detail: "without " + SessionLockName},
+	}}, nil
+}
+
+// Copy copies the world at src into dstSavesDir as folder, or under its own
+// name if folder is empty, and returns the new path. A partial copy is
+// removed again on failure.
+func Copy(ctx context.Context, src, dstSavesDir, folder string, progress instance.ProgressFunc) (string, error) {
+	dst, err := transferPaths(src, dstSavesDir, folder)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dstSavesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create saves directory: %w", err)
+	}
+	if err := instance.CopyTree(ctx, src, dst, instance.CopyOptions{Exclude: copyExcludes, Progress: progress}); err != nil {
+		os.RemoveAll(dst)
+		return "", fmt.Errorf("failed to copy world: %w", err)
+	}
+	return dst, nil
+}
+
+// PlanMove returns the steps Move would perform.
+func PlanMove(src, dstSavesDir, folder string) (*instance.Plan, error) {
+	dst, err := transferPaths(src, dstSavesDir, folder)
+	if err != nil {
+		return nil, err
+	}
+	return &instance.Plan{Action: "move-world", Steps: []instance.Step{
+		{Op: instance.OpMkdir, Path: dstSavesDir},
+		{Op: instance.OpMove, Path: src, Target: dst},
+	}}, nil
+}
+
+// Move moves the world at src into dstSavesDir like Copy, removing the
+// original once it has arrived.
+func Move(ctx context.Context, src, dstSavesDir, folder string, progress instance.ProgressFunc) (string, error) {
+	dst, err := transferPaths(src, dstSavesDir, folder)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dstSavesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create saves directory: %w", err)
+	}
+	if err := instance.MoveTree(ctx, src, dst, progress); err != nil {
+		return "", fmt.Errorf("failed to move world: %w", err)
+	}
+	return dst, nil
+}
+
+// PlanRename returns the steps Rename would perform.
+func PlanRename(src, folder string) (*instance.Plan, error) {
+	dst, err := transferPaths(src, filepath.Dir(src), folder)
+	if err != nil {
+		return nil, err
+	}
+	return &instance.Plan{Action: "rename-world", Steps: []instance.Step{
+		{Op: instance.OpRename, Path: src, Target: dst},
+	}}, nil
+}
+
+// Rename renames the folder of the world at src and returns the new path.
+// The display name in level.dat is left as it is; see SetName.
+func Rename(src, folder string) (string, error) {
+	dst, err := transferPaths(src, filepath.Dir(src), folder)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return "", fmt.Errorf("failed to rename world: %w", err)
+	}
+	return dst, nil
+}
+
+// checkNameEditable returns the Data compound and file of level.dat of the
+// world at path, unless the world is open: the game would overwrite the
+// edit when it next saves.
+func checkNameEditable(path, name string) (*nbt.Compound, *nbt.File, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, nil, fmt.Errorf("world name cannot be empty")
+	}
+	if inUse, err := InUse(path); err == nil && inUse {
+		return nil, nil, fmt.Errorf("world '%s' is open in the game", filepath.Base(path))
+	}
+	return readLevelData(path)
+}
+
+// PlanSetName returns the steps SetName would perform.
+func PlanSetName(path, name string) (*instance.Plan, error) {
+	if _, _, err := checkNameEditable(path, name); err != nil {
+		return nil, err
+	}
+	return &instance.Plan{Action: "rename-world", Steps: []instance.Step{
+		{Op: instance.OpWrite, Path: filepath.Join(path, LevelDatName), Detail: fmt.Sprintf("LevelName = %q", name)},
+	}}, nil
+}
+
+// SetName changes the display name of the world at path, the LevelName
+// stored in its level.dat. The rest of the file is written back unchanged.
+func SetName(path, name string) error {
+	data, f, err := checkNameEditable(path, name)
+	if err != nil {
+		return err
+	}
+	data.Set("LevelName", nbt.String(name))
+	if err := nbt.WriteFile(filepath.Join(path, LevelDatName), f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LevelDatName, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package worlds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/nbt"
+)
+
+// writeWorld creates a world folder in savesDir with a gzipped level.dat
+// named name, a region file and a session.lock, and returns its path.
+func writeWorld(t *testing.T, savesDir, folder, name string) string {
+	t.Helper()
+	path := filepath.Join(savesDir, folder)
+	if err := os.MkdirAll(filepath.Join(path, "region"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := &nbt.Compound{Fields: []nbt.Field{
+		{Name: "LevelName", Value: nbt.String(name)},
+		{Name: "GameType", Value: nbt.Int(1)},
+		{Name: "RandomSeed", Value: nbt.Long(42)},
+	}}
+	root := &nbt.Compound{Fields: []nbt.Field{{Name: "Data", Value: data}}}
+	if err := nbt.WriteFile(filepath.Join(path, LevelDatName), &nbt.File{Root: root, Compression: nbt.Gzip}); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"region/r.0.0.mca": strings.Repeat("chunk", 100),
+		SessionLockName:    "lock",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(path, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestTransfers(t *testing.T) {
+	type transfer func(src, dstSavesDir, folder string) (string, error)
+	copyWorld := func(src, dstSavesDir, folder string) (string, error) {
+		return Copy(context.Background(), src, dstSavesDir, folder, nil)
+	}
+	moveWorld := func(src, dstSavesDir, folder string) (string, error) {
+		return Move(context.Background(), src, dstSavesDir, folder, nil)
+	}
+	renameWorld := func(src, _, folder string) (string, error) {
+		return Rename(src, folder)
+	}
+
+	for name, fn := range map[string]transfer{"copy": copyWorld, "move": moveWorld, "rename": renameWorld} {
+		t.Run(name, func(t *testing.T) {
+			saves := t.TempDir()
+			src := writeWorld(t, saves, "World", "My World")
+			writeWorld(t, saves, "Taken", "Other")
+			dstSaves := saves
+			if name != "rename" {
+				dstSaves = filepath.Join(t.TempDir(), "saves")
+				writeWorld(t, dstSaves, "Taken", "Other")
+			}
+
+			for _, folder := range []string{"Taken", "..", "a/b", `a\b`} {
+				if _, err := fn(src, dstSaves, folder); err == nil {
+					t.Errorf("%s to %q succeeded", name, folder)
+				}
+			}
+			if name == "rename" {
+				if _, err := fn(src, dstSaves, "World"); err == nil {
+					t.Error("renaming a world to itself succeeded")
+				}
+			}
+			if _, err := fn(filepath.Join(saves, "Missing"), dstSaves, "New"); err == nil {
+				t.Errorf("%s of a missing world succeeded", name)
+			}
+			if _, err := os.Stat(filepath.Join(src, "region", "r.0.0.mca")); err != nil {
+				t.Fatalf("a refused %s changed the source: %v", name, err)
+			}
+
+			dst, err := fn(src, dstSaves, "New")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst != filepath.Join(dstSaves, "New") {
+				t.Errorf("%s returned %s", name, dst)
+			}
+			if got := LevelName(dst); got != "My World" {
+				t.Errorf("LevelName of the result = %q", got)
+			}
+			_, err = os.Stat(src)
+			if kept := err == nil; kept != (name == "copy") {
+				t.Errorf("source exists after %s: %v", name, kept)
+			}
+			_, err = os.Stat(filepath.Join(dst, SessionLockName))
+			if locked := err == nil; name == "copy" && locked {
+				t.Error("the copy took session.lock along")
+			}
+		})
+	}
+}
+
+func TestSetName(t *testing.T) {
+	path := writeWorld(t, t.TempDir(), "World", "Old Name")
+	before, err := nbt.ReadFile(filepath.Join(path, LevelDatName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetName(path, "  "); err == nil {
+		t.Error("SetName with a blank name succeeded")
+	}
+	if err := SetName(path, "Neue Welt ✓"); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "Neue Welt ✓" || w.GameMode != "creative" || w.Seed != 42 || w.Error != "" {
+		t.Errorf("Read after SetName = %+v", w)
+	}
+	after, err := nbt.ReadFile(filepath.Join(path, LevelDatName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Compression != before.Compression {
+		t.Errorf("compression changed from %s to %s", before.Compression, after.Compression)
+	}
+	root, _ := after.RootCompound()
+	data, _ := root.Compound("Data")
+	if len(data.Fields) != 3 || data.Fields[0].Name != "LevelName" {
+		t.Errorf("Data fields = %+v, want the original three in order", data.Fields)
+	}
+
+	if err := SetName(filepath.Join(filepath.Dir(path), "Missing"), "x"); err == nil {
+		t.Error("SetName of a missing world succeeded")
+	}
+}
@@ -0,0 +1,556 @@
+package worlds
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+)
+
+// SnapshotExt is the file extension of world snapshots.
+const SnapshotExt = ".tar.gz"
+
+// snapshotTimeLayout names snapshot files after their creation time in UTC.
+const snapshotTimeLayout = "20060102-150405"
+
+// Snapshot is a compressed copy of a world in an instance's snapshot store.
+type Snapshot struct {
+	ID        string    `json:"id" yaml:"id"` // instance/world/timestamp, as accepted by ParseSnapshotID
+	Instance  string    `json:"instance" yaml:"instance"`
+	World     string    `json:"world" yaml:"world"`
+	Path      string    `json:"path" yaml:"path"`
+	Created   time.Time `json:"created" yaml:"created"`
+	SizeBytes int64     `json:"size_bytes" yaml:"size_bytes"`
+}
+
+// Store is the snapshot store of one instance. The snapshots of each world
+// are kept in a subfolder named after the world folder.
+type Store struct {
+	Instance string
+	Dir      string
+}
+
+// Retention says how many snapshots of a world survive a prune: the newest
+// snapshot of each of the last Hourly hours, Daily days and Weekly ISO weeks
+// that have snapshots. A snapshot may count for several buckets, and the
+// newest snapshot is always kept.
+type Retention struct {
+	Hourly int `json:"hourly" yaml:"hourly"`
+	Daily  int `json:"daily" yaml:"daily"`
+	Weekly int `json:"weekly" yaml:"weekly"`
+}
+
+// DefaultRetention keeps a day of hourly, a week of daily and a month of
+// weekly snapshots.
+var DefaultRetention = Retention{Hourly: 24, Daily: 7, Weekly: 4}
+
+// InUse reports whether the game has the world at path open, judged by the
+// lock on its session.lock file.
+func InUse(path string) (bool, error) {
+	locked, err := sessionLocked(filepath.Join(path, SessionLockName))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return locked, err
+}
+
+// ParseSnapshotID splits a snapshot ID into its instance, world folder and
+// creation time.
+func ParseSnapshotID(id string) (instanceName, world string, created time.Time, err error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 || parts[0] == "" || validFolder(parts[1]) != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot ID %q, expected <instance>/<world>/<timestamp>", id)
+	}
+	created, err = time.Parse(snapshotTimeLayout, parts[2])
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot timestamp %q", parts[2])
+	}
+	return parts[0], parts[1], created, nil
+}
+
+func (s Store) id(world string, created time.Time) string {
+	return s.Instance + "/" + world + "/" + created.UTC().Format(snapshotTimeLayout)
+}
+
+func (s Store) snapshotPath(world string, created time.Time) string {
+	return filepath.Join(s.Dir, world, created.UTC().Format(snapshotTimeLayout)+SnapshotExt)
+}
+
+// Get returns the snapshot of world taken at created.
+func (s Store) Get(world string, created time.Time) (*Snapshot, error) {
+	p := s.snapshotPath(world, created)
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot '%s' does not exist", s.id(world, created))
+	}
+	return &Snapshot{
+		ID:        s.id(world, created),
+		Instance:  s.Instance,
+		World:     world,
+		Path:      p,
+		Created:   created.UTC().Truncate(time.Second),
+		SizeBytes: info.Size(),
+	}, nil
+}
+
+// List returns the snapshots of world, or of every world if world is empty,
+// newest first.
+func (s Store) List(world string) ([]Snapshot, error) {
+	folders := []string{world}
+	if world == "" {
+		entries, err := os.ReadDir(s.Dir)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot store: %w", err)
+		}
+		folders = folders[:0]
+		for _, e := range entries {
+			if e.IsDir() {
+				folders = append(folders, e.Name())
+			}
+		}
+	}
+
+	var snaps []Snapshot
+	for _, folder := range folders {
+		entries, err := os.ReadDir(filepath.Join(s.Dir, folder))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot store: %w", err)
+		}
+		for _, e := range entries {
+			// Unfinished snapshots are hidden temp files and never parse
+			created, err := time.Parse(snapshotTimeLayout, strings.TrimSuffix(e.Name(), SnapshotExt))
+			if err != nil || !strings.HasSuffix(e.Name(), SnapshotExt) {
+				continue
+			}
+			if snap, err := s.Get(folder, created); err == nil {
+				snaps = append(snaps, *snap)
+			}
+		}
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		if !snaps[i].Created.Equal(snaps[j].Created) {
+			return snaps[i].Created.After(snaps[j].Created)
+		}
+		return snaps[i].World < snaps[j].World
+	})
+	return snaps, nil
+}
+
+// expired returns the snapshots of one world (newest first) that r does not
+// keep.
+func (r Retention) expired(snaps []Snapshot) []Snapshot {
+	if len(snaps) == 0 {
+		return nil
+	}
+	keep := map[int]bool{0: true}
+	buckets := []struct {
+		n   int
+		key func(time.Time) string
+	}{
+		{r.Hourly, func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{r.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{r.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+	}
+	for _, b := range buckets {
+		seen := make(map[string]bool)
+		for i, snap := range snaps {
+			k := b.key(snap.Created.Local())
+			if seen[k] {
+				continue
+			}
+			if len(seen) >= b.n {
+				break
+			}
+			seen[k] = true
+			keep[i] = true
+		}
+	}
+
+	var out []Snapshot
+	for i, snap := range snaps {
+		if !keep[i] {
+			out = append(out, snap)
+		}
+	}
+	return out
+}
+
+// Expired returns the snapshots of world that a prune with r would remove.
+func (s Store) Expired(world string, r Retention) ([]Snapshot, error) {
+	snaps, err := s.List(world)
+	if err != nil {
+		return nil, err
+	}
+	return r.expired(snaps), nil
+}
+
+// Prune removes the snapshots of world that r does not keep and returns
+// them.
+func (s Store) Prune(world string, r Retention) ([]Snapshot, error) {
+	expired, err := s.Expired(world, r)
+	if err != nil {
+		return nil, err
+	}
+	for i, snap := range expired {
+		if err := os.Remove(snap.Path); err != nil {
+			return expired[:i], fmt.Errorf("failed to remove snapshot %s: %w", snap.ID, err)
+		}
+	}
+	return expired, nil
+}
+
+// checkWorld returns an error unless worldPath is a world directory.
+func checkWorld(worldPath string) error {
+	info, err := os.Stat(worldPath)
+	if err != nil {
+		return fmt.Errorf("world '%s' does not exist", filepath.Base(worldPath))
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a world directory", worldPath)
+	}
+	return nil
+}
+
+// PlanCreate returns the steps Create followed by a prune with r would
+// perform.
+func (s Store) PlanCreate(worldPath string, now time.Time, r Retention) (*instance.Plan, error) {
+	if err := checkWorld(worldPath); err != nil {
+		return nil, err
+	}
+	world := filepath.Base(worldPath)
+	dst := s.snapshotPath(world, now)
+	plan := &instance.Plan{Action: "snapshot-world", Steps: []instance.Step{
+		{Op: instance.OpMkdir, Path: filepath.Dir(dst)},
+		{Op: instance.OpWrite, Path: dst, Detail: "compressed copy of " + worldPath + " without " + SessionLockName},
+	}}
+
+	snaps, err := s.List(world)
+	if err != nil {
+		return nil, err
+	}
+	snaps = append([]Snapshot{{ID: s.id(world, now), Created: now.UTC()}}, snaps...)
+	for _, snap := range r.expired(snaps) {
+		plan.Steps = append(plan.Steps, instance.Step{Op: instance.OpRemove, Path: snap.Path, Detail: "expired snapshot"})
+	}
+	return plan, nil
+}
+
+// Create writes a snapshot of the world at worldPath, dated now, and returns
+// it. The world is archived as it is; callers check InUse first. A partial
+// archive is removed again on failure.
+func (s Store) Create(ctx context.Context, worldPath string, now time.Time, progress instance.ProgressFunc) (*Snapshot, error) {
+	if err := checkWorld(worldPath); err != nil {
+		return nil, err
+	}
+	world := filepath.Base(worldPath)
+	dst := s.snapshotPath(world, now)
+	if _, err := os.Stat(dst); err == nil {
+		return nil, fmt.Errorf("snapshot '%s' already exists", s.id(world, now))
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	err = writeArchive(ctx, tmp, worldPath, progress)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	return s.Get(world, now)
+}
+
+// writeArchive writes the world at worldPath as a gzipped tar to w. Entries
+// are prefixed with the world folder, and session.lock, symlinks and special
+// files are left out.
+func writeArchive(ctx context.Context, w io.Writer, worldPath string, progress instance.ProgressFunc) error {
+	p := instance.CopyProgress{Started: time.Now()}
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+	archived := func(rel string, d fs.DirEntry) bool {
+		return d.IsDir() || (d.Type().IsRegular() && rel != SessionLockName)
+	}
+
+	// First pass: totals for the progress report
+	err := filepath.WalkDir(worldPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(worldPath, path)
+		if archived(rel, d) && !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			p.FilesTotal++
+			p.BytesTotal += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report()
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	root := filepath.Base(worldPath)
+	err = filepath.WalkDir(worldPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(worldPath, path)
+		if !archived(rel, d) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(root, rel))
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uname, hdr.Gname = "", ""
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		p.Current = rel
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// The header already holds the size, so a file the game is still
+		// writing to cannot end up half in the archive unnoticed
+		r := &progressReader{ctx: ctx, r: f, onRead: func(n int64) {
+			p.BytesDone += n
+			report()
+		}}
+		if _, err := io.CopyN(tw, r, hdr.Size); err != nil {
+			return fmt.Errorf("%s changed while taking the snapshot: %w", rel, err)
+		}
+		p.FilesDone++
+		report()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// progressReader reports the bytes read from r and fails once ctx is
+// cancelled.
+type progressReader struct {
+	ctx    context.Context
+	r      io.Reader
+	onRead func(int64)
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(b)
+	if n > 0 && r.onRead != nil {
+		r.onRead(int64(n))
+	}
+	return n, err
+}
+
+// restoreTarget returns where snap is restored to in savesDir.
+func restoreTarget(snap Snapshot, savesDir, folder string) (string, error) {
+	if folder == "" {
+		folder = snap.World
+	}
+	if err := validFolder(folder); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(snap.Path); err != nil {
+		return "", fmt.Errorf("snapshot '%s' does not exist", snap.ID)
+	}
+	dst := filepath.Join(savesDir, folder)
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("a world named '%s' already exists in %s; restore it under another name", folder, savesDir)
+	}
+	return dst, nil
+}
+
+// PlanRestore returns the steps Restore would perform.
+func PlanRestore(snap Snapshot, savesDir, folder string) (*instance.Plan, error) {
+	dst, err := restoreTarget(snap, savesDir, folder)
+	if err != nil {
+		return nil, err
+	}
+	return &instance.Plan{Action: "restore-snapshot", Steps: []instance.Step{
+		{Op: instance.OpMkdir, Path: savesDir},
+		{Op: instance.OpWrite, Path: dst, Detail: "extract " + snap.Path},
+	}}, nil
+}
+
+// Restore extracts snap into savesDir as folder, or under the world's own
+// name if folder is empty, and returns the new path. An existing world is
+// never overwritten, and a partial restore is removed again on failure.
+func Restore(ctx context.Context, snap Snapshot, savesDir, folder string, progress instance.ProgressFunc) (string, error) {
+	dst, err := restoreTarget(snap, savesDir, folder)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(savesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create saves directory: %w", err)
+	}
+	if err := extractArchive(ctx, snap.Path, dst, progress); err != nil {
+		os.RemoveAll(dst)
+		return "", fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	return dst, nil
+}
+
+// extractArchive unpacks the snapshot at src into dst, dropping the world
+// folder prefix of its entries. The archive is read twice, the first time
+// to count its files for the progress report.
+func extractArchive(ctx context.Context, src, dst string, progress instance.ProgressFunc) error {
+	p := instance.CopyProgress{Started: time.Now()}
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	err := walkArchive(ctx, src, func(rel string, hdr *tar.Header, r io.Reader) error {
+		if hdr.Typeflag == tar.TypeReg {
+			p.FilesTotal++
+			p.BytesTotal += hdr.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report()
+
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	return walkArchive(ctx, src, func(rel string, hdr *tar.Header, r io.Reader) error {
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			p.Current = rel
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, &progressReader{ctx: ctx, r: r, onRead: func(n int64) {
+				p.BytesDone += n
+				report()
+			}})
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			p.FilesDone++
+			report()
+			return os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+		}
+		// Snapshots hold nothing else; anything else is not restored
+		return nil
+	})
+}
+
+// walkArchive calls fn for every entry of the snapshot at src with its path
+// relative to the world folder. Entries that would land outside the world
+// are rejected.
+func walkArchive(ctx context.Context, src string, fn func(rel string, hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("not a snapshot archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("corrupt snapshot archive: %w", err)
+		}
+		// Snapshots only hold relative slash paths; anything else was not
+		// written by Create and may point outside the world
+		name := path.Clean(hdr.Name)
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("snapshot entry %q points outside the world", hdr.Name)
+		}
+		_, rel, _ := strings.Cut(name, "/")
+		if rel == "" {
+			// The world folder itself
+			continue
+		}
+		if err := fn(rel, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package worlds
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+)
+
+// at returns a time in January 2026, local time, which has no DST change.
+func at(day, hour, min int) time.Time {
+	return time.Date(2026, time.January, day, hour, min, 0, 0, time.Local)
+}
+
+func TestRetentionExpired(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       Retention
+		times   []time.Time // newest first
+		expired []int       // indexes into times
+	}{
+		{"hourly keeps the newest of each hour", Retention{Hourly: 2},
+			[]time.Time{at(10, 12, 50), at(10, 12, 10), at(10, 11, 30), at(10, 10, 0)}, []int{1, 3}},
+		{"daily keeps the newest of each day", Retention{Daily: 2},
+			[]time.Time{at(10, 18, 0), at(10, 9, 0), at(9, 20, 0), at(8, 10, 0)}, []int{1, 3}},
+		// 2026-01-19 is a Monday, so the 18th still belongs to the week before
+		{"weekly follows ISO weeks", Retention{Weekly: 2},
+			[]time.Time{at(21, 9, 0), at(19, 0, 30), at(18, 23, 30), at(12, 9, 0), at(5, 9, 0)}, []int{1, 3, 4}},
+		{"a snapshot counts for several buckets", Retention{Hourly: 1, Daily: 2, Weekly: 1},
+			[]time.Time{at(21, 12, 30), at(21, 12, 0), at(21, 8, 0), at(20, 22, 0), at(13, 10, 0)}, []int{1, 2, 4}},
+		{"the newest snapshot is always kept", Retention{},
+			[]time.Time{at(10, 12, 0), at(9, 12, 0), at(8, 12, 0)}, []int{1, 2}},
+		{"days without snapshots do not count", Retention{Daily: 2},
+			[]time.Time{at(20, 12, 0), at(10, 12, 0), at(1, 12, 0)}, []int{2}},
+		{"nothing to prune", DefaultRetention, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var snaps []Snapshot
+			for _, created := range tt.times {
+				snaps = append(snaps, Snapshot{ID: created.Format(time.Stamp), Created: created.UTC()})
+			}
+			var got, want []string
+			for _, snap := range tt.r.expired(snaps) {
+				got = append(got, snap.ID)
+			}
+			for _, i := range tt.expired {
+				want = append(want, snaps[i].ID)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expired = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// writeTarGz writes a snapshot archive holding headers, each with its
+// name as the contents of regular files.
+func writeTarGz(t *testing.T, path string, headers []tar.Header) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, hdr := range headers {
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(hdr.Name))
+		}
+		hdr.Mode = 0644
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			tw.Write([]byte(hdr.Name))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchiveStaysInTheWorld(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  tar.Header
+	}{
+		{"parent of the world", tar.Header{Name: "World/../../evil.txt", Typeflag: tar.TypeReg}},
+		{"parent as the world folder", tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg}},
+		{"dot dot alone", tar.Header{Name: "World/../..", Typeflag: tar.TypeDir}},
+		{"absolute file", tar.Header{Name: "/tmp/evil.txt", Typeflag: tar.TypeReg}},
+		{"absolute folder", tar.Header{Name: "/World/region/", Typeflag: tar.TypeDir}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "snap"+SnapshotExt)
+			writeTarGz(t, src, []tar.Header{
+				{Name: "World/", Typeflag: tar.TypeDir},
+				{Name: "World/level.dat", Typeflag: tar.TypeReg},
+				tt.hdr,
+			})
+			saves := filepath.Join(dir, "instance", "saves")
+			snap := Snapshot{ID: "i/World/20260101-000000", World: "World", Path: src}
+
+			if _, err := Restore(context.Background(), snap, saves, "", nil); err == nil {
+				t.Fatal("Restore succeeded")
+			}
+			// Only the snapshot and the empty saves folder may be left
+			var found []string
+			filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+				if p != dir && p != src && p != saves && p != filepath.Dir(saves) {
+					found = append(found, p)
+				}
+				return nil
+			})
+			if len(found) > 0 {
+				t.Errorf("Restore left %v behind", found)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveSkipsLinks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "snap"+SnapshotExt)
+	writeTarGz(t, src, []tar.Header{
+		{Name: "World/level.dat", Typeflag: tar.TypeReg},
+		{Name: "World/link", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+		{Name: "World/hard", Typeflag: tar.TypeLink, Linkname: "World/level.dat"},
+	})
+	dst := filepath.Join(dir, "restored")
+	if err := extractArchive(context.Background(), src, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dst)
+	if len(entries) != 1 || entries[0].Name() != "level.dat" {
+		t.Errorf("restored %v, want only level.dat", entries)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	world := writeWorld(t, filepath.Join(dir, "saves"), "World", "My World")
+	mtime := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	region := filepath.Join(world, "region", "r.0.0.mca")
+	os.Chtimes(region, mtime, mtime)
+	store := Store{Instance: "survival", Dir: filepath.Join(dir, "snapshots", "survival")}
+
+	now := at(10, 12, 0)
+	var progressed bool
+	snap, err := store.Create(context.Background(), world, now, func(p instance.CopyProgress) {
+		progressed = p.FilesTotal == 2 // level.dat and the region file
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !progressed {
+		t.Error("Create reported no progress over the two files")
+	}
+	if snap.ID != "survival/World/"+now.UTC().Format(snapshotTimeLayout) || snap.SizeBytes == 0 {
+		t.Errorf("Create = %+v", snap)
+	}
+	if _, err := store.Create(context.Background(), world, now, nil); err == nil {
+		t.Error("a second snapshot at the same time succeeded")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(store.Dir, "World", ".*")); len(leftovers) > 0 {
+		t.Errorf("Create left %v behind", leftovers)
+	}
+
+	list, err := store.List("")
+	if err != nil || len(list) != 1 || list[0].ID != snap.ID {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	instanceName, folder, created, err := ParseSnapshotID(snap.ID)
+	if err != nil || instanceName != "survival" || folder != "World" || !created.Equal(now) {
+		t.Errorf("ParseSnapshotID(%s) = %s, %s, %s, %v", snap.ID, instanceName, folder, created, err)
+	}
+
+	// The world is restored next to the original, without session.lock
+	restored, err := Restore(context.Background(), *snap, filepath.Dir(world), "World (restored)", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{LevelDatName, filepath.Join("region", "r.0.0.mca")} {
+		want, _ := os.ReadFile(filepath.Join(world, name))
+		got, err := os.ReadFile(filepath.Join(restored, name))
+		if err != nil || string(got) != string(want) {
+			t.Errorf("restored %s differs: %v", name, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(restored, "region", "r.0.0.mca")); err != nil || !info.ModTime().Equal(mtime) {
+		t.Errorf("restored region file mtime = %v, want %v", info.ModTime(), mtime)
+	}
+	if _, err := os.Stat(filepath.Join(restored, SessionLockName)); !os.IsNotExist(err) {
+		t.Errorf("session.lock was restored: %v", err)
+	}
+	if got := LevelName(restored); got != "My World" {
+		t.Errorf("restored world is called %q", got)
+	}
+
+	// An existing world is never overwritten
+	if _, err := Restore(context.Background(), *snap, filepath.Dir(world), "", nil); err == nil {
+		t.Error("restoring over the original succeeded")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	world := writeWorld(t, filepath.Join(dir, "saves"), "World", "My World")
+	store := Store{Instance: "survival", Dir: filepath.Join(dir, "snapshots")}
+	times := []time.Time{at(12, 18, 0), at(12, 9, 0), at(11, 20, 0), at(10, 8, 0), at(3, 8, 0)}
+	for _, now := range times {
+		if _, err := store.Create(context.Background(), world, now, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A second world is pruned on its own
+	other := writeWorld(t, filepath.Join(dir, "saves"), "Other", "Other")
+	if _, err := store.Create(context.Background(), other, at(1, 0, 0), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Retention{Daily: 2, Weekly: 3}
+	plan, err := store.PlanCreate(world, at(12, 19, 0), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removes := len(plan.Steps) - 2; removes != 3 {
+		t.Errorf("PlanCreate removes %d snapshots, want 3: %v", removes, plan.Steps)
+	}
+
+	pruned, err := store.Prune("World", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kept: the 12th at 18:00 and the 11th by day and by week, as the 12th
+	// starts a new week, and the 3rd by week
+	var got []time.Time
+	for _, snap := range pruned {
+		got = append(got, snap.Created)
+	}
+	want := []time.Time{times[1].UTC(), times[3].UTC()}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Prune removed %v, want %v", got, want)
+	}
+	left, _ := store.List("")
+	if len(left) != 4 {
+		t.Errorf("%d snapshots left, want 3 of World and 1 of Other", len(left))
+	}
+	if pruned, err := store.Prune("World", r); err != nil || len(pruned) != 0 {
+		t.Errorf("pruning again removed %v, %v", pruned, err)
+	}
+}
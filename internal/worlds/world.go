@@ -0,0 +1,164 @@
+// Package worlds reads and manages the worlds (saves) of an instance.
+package worlds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/instance"
+	"github.com/Gerry3010/minecraft-instance-switcher/internal/nbt"
+)
+
+// SavesDirName is the folder of an instance that holds its worlds.
+const SavesDirName = "saves"
+
+// LevelDatName is the file holding a world's metadata.
+const LevelDatName = "level.dat"
+
+// World describes one folder of an instance's saves directory.
+type World struct {
+	Folder      string    `json:"folder" yaml:"folder"`
+	Path        string    `json:"path" yaml:"path"`
+	Name        string    `json:"name" yaml:"name"` // display name shown in the game
+	GameMode    string    `json:"game_mode" yaml:"game_mode"`
+	Hardcore    bool      `json:"hardcore" yaml:"hardcore"`
+	Seed        int64     `json:"seed" yaml:"seed"`
+	Version     string    `json:"version,omitempty" yaml:"version,omitempty"`
+	DataVersion int32     `json:"data_version,omitempty" yaml:"data_version,omitempty"`
+	LastPlayed  time.Time `json:"last_played" yaml:"last_played"`
+	SizeBytes   int64     `json:"size_bytes" yaml:"size_bytes"`
+	// Error is set if level.dat is missing or cannot be parsed; the other
+	// metadata fields are then empty
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// gameModes maps the GameType of level.dat to its name.
+var gameModes = map[int32]string{0: "survival", 1: "creative", 2: "adventure", 3: "spectator"}
+
+// SavesDir returns the saves directory of the instance at instancePath.
+func SavesDir(instancePath string) string {
+	return filepath.Join(instancePath, SavesDirName)
+}
+
+// Read returns the world at path. A missing or corrupt level.dat is
+// reported in World.Error rather than as an error, so that broken worlds
+// still show up and can be deleted.
+func Read(path string) (*World, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a world directory", path)
+	}
+
+	w := &World{Folder: filepath.Base(path), Path: path, Name: filepath.Base(path)}
+	w.SizeBytes, _ = instance.DirSize(path)
+	if err := w.readLevelDat(); err != nil {
+		w.Error = err.Error()
+	}
+	return w, nil
+}
+
+func (w *World) readLevelDat() error {
+	data, _, err := readLevelData(w.Path)
+	if err != nil {
+		return err
+	}
+
+	if name, ok := data.String("LevelName"); ok && name != "" {
+		w.Name = name
+	}
+	if mode, ok := data.Int64("GameType"); ok {
+		w.GameMode = gameModes[int32(mode)]
+	}
+	if hardcore, ok := data.Int64("hardcore"); ok {
+		w.Hardcore = hardcore != 0
+	}
+	if w.GameMode == "" {
+		w.GameMode = "unknown"
+	}
+	// 1.16 moved the seed into WorldGenSettings
+	if gen, ok := data.Compound("WorldGenSettings"); ok {
+		w.Seed, _ = gen.Int64("seed")
+	} else {
+		w.Seed, _ = data.Int64("RandomSeed")
+	}
+	if version, ok := data.Compound("Version"); ok {
+		w.Version, _ = version.String("Name")
+	}
+	if dv, ok := data.Int64("DataVersion"); ok {
+		w.DataVersion = int32(dv)
+	}
+	if ms, ok := data.Int64("LastPlayed"); ok && ms > 0 {
+		w.LastPlayed = time.UnixMilli(ms).UTC()
+	}
+	return nil
+}
+
+// readLevelData reads level.dat of the world at path and returns its Data
+// compound along with the whole file.
+func readLevelData(path string) (*nbt.Compound, *nbt.File, error) {
+	f, err := nbt.ReadFile(filepath.Join(path, LevelDatName))
+	if os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", LevelDatName, err)
+	}
+	root, err := f.RootCompound()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", LevelDatName, err)
+	}
+	data, ok := root.Compound("Data")
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no Data compound", LevelDatName)
+	}
+	return data, f, nil
+}
+
+// List returns the worlds in savesDir, most recently played first. A
+// missing saves directory has no worlds.
+func List(savesDir string) ([]World, error) {
+	entries, err := os.ReadDir(savesDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saves directory: %w", err)
+	}
+
+	var worlds []World
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		w, err := Read(filepath.Join(savesDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		worlds = append(worlds, *w)
+	}
+	sort.SliceStable(worlds, func(i, j int) bool {
+		if !worlds[i].LastPlayed.Equal(worlds[j].LastPlayed) {
+			return worlds[i].LastPlayed.After(worlds[j].LastPlayed)
+		}
+		return worlds[i].Folder < worlds[j].Folder
+	})
+	return worlds, nil
+}
+
+// LevelName returns the display name of the world at path, or its folder
+// name if level.dat cannot be read. Unlike Read it does not measure the
+// world.
+func LevelName(path string) string {
+	if data, _, err := readLevelData(path); err == nil {
+		if name, ok := data.String("LevelName"); ok && name != "" {
+			return name
+		}
+	}
+	return filepath.Base(path)
+}